@@ -0,0 +1,149 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/configs/tar"
+	"github.com/spf13/afero"
+)
+
+//go:generate mockgen -source=cache.go -destination=cache_mock.go -package=cache -write_package_comment=false Command
+
+// Command is used to test the CLi commands properly without executing the actual cache operations.
+//
+// The actual implementations are in the [DefaultCommand] struct.
+type Command interface {
+	List(fs afero.Fs, out io.Writer, cacheFile string) error
+	Get(fs afero.Fs, out io.Writer, cacheFile string, id string) error
+	Verify(fs afero.Fs, cacheFile string) error
+	Compact(fs afero.Fs, cacheFile string) error
+}
+
+// DefaultCommand is used to implement the [Command] interface.
+type DefaultCommand struct{}
+
+// make sure DefaultCommand implements the Command interface
+var (
+	_ Command = (*DefaultCommand)(nil)
+)
+
+func (d DefaultCommand) List(fs afero.Fs, out io.Writer, cacheFile string) error {
+	tarFolder, err := openCache(fs, cacheFile)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tarFolder.Entries() {
+		if entry.Offset == 0 {
+			fmt.Fprintf(out, "%s\t%s\t(stub only)\n", entry.ID, entry.Name)
+			continue
+		}
+		fmt.Fprintf(out, "%s\t%s\n", entry.ID, entry.Name)
+	}
+
+	return nil
+}
+
+func (d DefaultCommand) Get(fs afero.Fs, out io.Writer, cacheFile string, id string) error {
+	tarFolder, err := openCache(fs, cacheFile)
+	if err != nil {
+		return err
+	}
+
+	stub, data, err := tarFolder.Get(id)
+	if err != nil {
+		return err
+	}
+	if stub == nil {
+		return fmt.Errorf("no object with id %s in cache %s", id, tarFolder.Name())
+	}
+	if data == nil {
+		return fmt.Errorf("object %s (%s) in cache %s is a stub only, it holds no data", id, stub.Name, tarFolder.Name())
+	}
+
+	_, err = out.Write(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+
+	return nil
+}
+
+func (d DefaultCommand) Verify(fs afero.Fs, cacheFile string) error {
+	tarFolder, err := openCache(fs, cacheFile)
+	if err != nil {
+		return err
+	}
+
+	result, err := tarFolder.Verify()
+	if err != nil {
+		return err
+	}
+
+	for _, verifyErr := range result.Errors {
+		log.Error("%v", verifyErr)
+	}
+
+	log.Info("Verified %d indexed objects of %s: %d stubs only, %d unreferenced entries, %d errors",
+		result.Indexed, tarFolder.Name(), result.StubsOnly, result.Unreferenced, len(result.Errors))
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("cache %s is corrupt, found %d errors", tarFolder.Name(), len(result.Errors))
+	}
+
+	return nil
+}
+
+func (d DefaultCommand) Compact(fs afero.Fs, cacheFile string) error {
+	tarFolder, err := openCache(fs, cacheFile)
+	if err != nil {
+		return err
+	}
+
+	dropped, err := tarFolder.Compact()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Compacted %s, dropped %d unreferenced entries", tarFolder.Name(), dropped)
+
+	return nil
+}
+
+func openCache(fs afero.Fs, cacheFile string) (*tar.Folder, error) {
+	exists, err := afero.Exists(fs, cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("cache file %s does not exist", cacheFile)
+	}
+
+	tarFolder, _, err := tar.NewExisting(fs, strings.TrimSuffix(cacheFile, ".tar"))
+	if err != nil {
+		return nil, fmt.Errorf("could not open cache file %s: %w", cacheFile, err)
+	}
+	if tarFolder == nil {
+		return nil, fmt.Errorf("cache file %s does not exist", cacheFile)
+	}
+
+	return tarFolder, nil
+}
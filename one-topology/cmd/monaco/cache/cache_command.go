@@ -0,0 +1,103 @@
+/*
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func GetCacheCommand(fs afero.Fs, command Command) (cacheCmd *cobra.Command) {
+
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and maintain the tar caches written by monaco match",
+		Example: `- monaco cache ls output/cache/management-zone.tar
+- monaco cache get output/cache/management-zone.tar -1234567890
+- monaco cache verify output/cache/management-zone.tar
+- monaco cache compact output/cache/management-zone.tar`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("'ls', 'get', 'verify' or 'compact' sub-command is required")
+		},
+	}
+
+	lsCmd := &cobra.Command{
+		Use:     "ls <file>",
+		Aliases: []string{"list"},
+		Short:   "List the objects of a cache",
+		Args:    requireCacheFile(1, "the cache file has to be provided as positional argument"),
+		PreRun:  cmdutils.SilenceUsageCommand(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return command.List(fs, cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:    "get <file> <id>",
+		Short:  "Print the JSON content of an object of a cache",
+		Args:   requireCacheFile(2, "the cache file and the object id have to be provided as positional arguments"),
+		PreRun: cmdutils.SilenceUsageCommand(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return command.Get(fs, cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:    "verify <file>",
+		Short:  "Verify that the index of a cache matches its entries",
+		Args:   requireCacheFile(1, "the cache file has to be provided as positional argument"),
+		PreRun: cmdutils.SilenceUsageCommand(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return command.Verify(fs, args[0])
+		},
+	}
+
+	compactCmd := &cobra.Command{
+		Use:    "compact <file>",
+		Short:  "Rewrite a cache without its deleted and overwritten entries",
+		Args:   requireCacheFile(1, "the cache file has to be provided as positional argument"),
+		PreRun: cmdutils.SilenceUsageCommand(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return command.Compact(fs, args[0])
+		},
+	}
+
+	cacheCmd.AddCommand(lsCmd)
+	cacheCmd.AddCommand(getCmd)
+	cacheCmd.AddCommand(verifyCmd)
+	cacheCmd.AddCommand(compactCmd)
+
+	return cacheCmd
+}
+
+func requireCacheFile(argCount int, message string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != argCount {
+			return errors.New(message)
+		}
+		for _, arg := range args {
+			if arg == "" {
+				return errors.New(message)
+			}
+		}
+		return nil
+	}
+}
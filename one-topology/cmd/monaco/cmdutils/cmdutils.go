@@ -44,19 +44,20 @@ func SilenceUsageCommand() func(cmd *cobra.Command, args []string) {
 // CreateDTClient is driven by data given through a manifest.EnvironmentDefinition to create an appropriate client.Client.
 //
 // In case when flag dryRun is true this factory returns the client.DummyClient.
-func CreateDTClient(env manifest.EnvironmentDefinition, dryRun bool) (client.Client, error) {
+// The opts are applied to the created client.DynatraceClient.
+func CreateDTClient(env manifest.EnvironmentDefinition, dryRun bool, opts ...func(*client.DynatraceClient)) (client.Client, error) {
 	switch {
 	case dryRun:
 		return client.NewDummyClient(), nil
 	case env.Type == manifest.Classic:
-		return client.NewClassicClient(env.URL.Value, env.Auth.Token.Value)
+		return client.NewClassicClient(env.URL.Value, env.Auth.Token.Value, opts...)
 	case env.Type == manifest.Platform:
 		oauthCredentials := client.OauthCredentials{
 			ClientID:     env.Auth.OAuth.ClientID.Value,
 			ClientSecret: env.Auth.OAuth.ClientSecret.Value,
 			TokenURL:     env.Auth.OAuth.GetTokenEndpointValue(),
 		}
-		return client.NewPlatformClient(env.URL.Value, env.Auth.Token.Value, oauthCredentials)
+		return client.NewPlatformClient(env.URL.Value, env.Auth.Token.Value, oauthCredentials, opts...)
 	default:
 		return nil, fmt.Errorf("unable to create authorizing HTTP Client for environment %s - no oauth credentials given", env.URL.Value)
 	}
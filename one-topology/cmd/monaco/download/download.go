@@ -18,13 +18,17 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"path/filepath"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
 
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 )
 
@@ -38,6 +42,10 @@ type Command interface {
 	DownloadConfigs(fs afero.Fs, cmdOptions directDownloadOptions) error
 	DownloadEntitiesBasedOnManifest(fs afero.Fs, cmdOptions entitiesManifestDownloadOptions) error
 	DownloadEntities(fs afero.Fs, cmdOptions entitiesDirectDownloadOptions) error
+	DownloadSchemasBasedOnManifest(fs afero.Fs, cmdOptions schemasManifestDownloadOptions) error
+	DownloadSchemas(fs afero.Fs, cmdOptions schemasDirectDownloadOptions) error
+	CreateSnapshotBasedOnManifest(fs afero.Fs, cmdOptions snapshotManifestOptions) error
+	CreateSnapshot(fs afero.Fs, cmdOptions snapshotDirectOptions) error
 }
 
 // DefaultCommand is used to implement the [Command] interface.
@@ -48,10 +56,62 @@ var (
 	_ Command = (*DefaultCommand)(nil)
 )
 
+const summaryActionDownloaded = "Downloaded"
+
+// checkpointsFolder holds the checkpoints of the paginated downloads per project, within the output folder
+const checkpointsFolder = ".checkpoints"
+
+func checkpointsDir(opts downloadCommandOptionsShared) string {
+	return filepath.Join(opts.outputFolder, checkpointsFolder, opts.projectName)
+}
+
+// newCheckpointStore persists the progress of the paginated downloads, so that a failed download can be resumed.
+// The checkpoints are written on every run, also without --resume, so that any failed run can be resumed.
+// The spool files of the received pages are plain JSON lines, --compress only applies to the written project.
+func newCheckpointStore(fs afero.Fs, opts downloadCommandOptionsShared) *client.CheckpointStore {
+	dir := checkpointsDir(opts)
+	if opts.resume {
+		log.Info("Resuming the paginated downloads from the checkpoints in %s", dir)
+	}
+	return client.NewCheckpointStore(fs, dir, opts.resume)
+}
+
+// removeCheckpoints deletes the checkpoints of the project after a run in which every paginated download finished.
+// If any download failed, e.g. a type that used up its retries, the checkpoints are kept so that it can be resumed.
+func removeCheckpoints(fs afero.Fs, opts downloadCommandOptionsShared, checkpoints *client.CheckpointStore) {
+	dir := checkpointsDir(opts)
+
+	pending, err := checkpoints.Pending()
+	if err != nil {
+		log.Warn("Failed to read the checkpoints in %s, they are kept: %v", dir, err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Warn("%d paginated downloads did not finish, their checkpoints are kept in %s. Run the download again with --resume to continue them: %v", len(pending), dir, pending)
+		return
+	}
+
+	err = fs.RemoveAll(dir)
+	if err != nil {
+		log.Warn("Failed to remove the checkpoints in %s: %v", dir, err)
+		return
+	}
+
+	parent := filepath.Dir(dir)
+	if entries, err := afero.ReadDir(fs, parent); err == nil && len(entries) == 0 {
+		_ = fs.Remove(parent)
+	}
+}
+
 type downloadCommandOptionsShared struct {
 	projectName    string
 	outputFolder   string
 	forceOverwrite bool
+	metricsFile    string
+	// resume continues the paginated downloads from the checkpoints of a failed run
+	resume bool
+	// compress is the compression format of the written templates, empty for plain JSON
+	compress string
 }
 
 type downloadOptionsShared struct {
@@ -62,9 +122,12 @@ type downloadOptionsShared struct {
 	projectName             string
 	forceOverwriteManifest  bool
 	concurrentDownloadLimit int
+	metricsFile             string
+	compress                string
 }
 
-func writeConfigs(downloadedConfigs project.ConfigsPerType, opts downloadOptionsShared, fs afero.Fs) error {
+// writeConfigs writes the downloaded configs as a project, and returns the folder of the written project
+func writeConfigs(downloadedConfigs project.ConfigsPerType, opts downloadOptionsShared, fs afero.Fs) (string, error) {
 	proj := download.CreateProjectData(downloadedConfigs, opts.projectName)
 
 	downloadWriterContext := download.WriterContext{
@@ -74,14 +137,25 @@ func writeConfigs(downloadedConfigs project.ConfigsPerType, opts downloadOptions
 		EnvironmentType:        opts.environmentType,
 		OutputFolder:           opts.outputFolder,
 		ForceOverwriteManifest: opts.forceOverwriteManifest,
+		Compression:            compression.Format(opts.compress),
 	}
-	err := download.WriteToDisk(fs, downloadWriterContext)
+	outputFolder, err := download.WriteToDisk(fs, downloadWriterContext)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	log.Info("Finished download")
-	return nil
+	return path.Join(outputFolder, proj.Id), nil
+}
+
+// writeSummary writes the summary of the download into the project folder, with the number of configs or entities per type
+func writeSummary(fs afero.Fs, projectFolder string, downloadedConfigs project.ConfigsPerType, unit string, opts downloadOptionsShared) error {
+	for configType, configs := range downloadedConfigs {
+		summary.SetCount(configType, summaryActionDownloaded, len(configs))
+	}
+	summary.SetTotal(unit, sumConfigs(downloadedConfigs))
+
+	return summary.Write(fs, projectFolder, opts.metricsFile, summary.Finish())
 }
 
 func sumConfigs(configs project.ConfigsPerType) int {
@@ -126,6 +200,11 @@ func preDownloadValidations(fs afero.Fs, opts downloadOptionsShared) error {
 		return errutils.PrintAndFormatErrors(errs, "output folder is invalid")
 	}
 
+	if _, err := compression.ParseFormat(opts.compress); err != nil {
+		log.Error("%v", err)
+		return err
+	}
+
 	return nil
 }
 
@@ -20,9 +20,11 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/version"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
 
 	"github.com/spf13/afero"
@@ -50,18 +52,24 @@ To download entities, use download entities`,
 
 	getDownloadConfigsCommand(fs, command, downloadCmd)
 	getDownloadEntitiesCommand(fs, command, downloadCmd)
+	getDownloadSchemasCommand(fs, command, downloadCmd)
 
 	return downloadCmd
 }
 
 func getDownloadConfigsCommand(fs afero.Fs, command Command, downloadCmd *cobra.Command) {
-	var project, outputFolder string
-	var forceOverwrite bool
+	var project, outputFolder, metricsFile string
+	var forceOverwrite, resume bool
 	var specificApis []string
 	var specificSettings []string
 	var onlyAPIs bool
 	var onlySettings bool
 	var flatDump bool
+	var settingsScopes []string
+	var redactSecrets, redactSchemaSecrets bool
+	var secretPathsFile string
+	var filterFile string
+	var compress string
 
 	manifestDownloadCmd := &cobra.Command{
 		Use:     "manifest [manifest file] [environment to download]",
@@ -86,12 +94,22 @@ func getDownloadConfigsCommand(fs afero.Fs, command Command, downloadCmd *cobra.
 						projectName:    project,
 						outputFolder:   outputFolder,
 						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+						resume:         resume,
+						compress:       compress,
 					},
 					specificAPIs:    specificApis,
 					specificSchemas: specificSettings,
 					onlyAPIs:        onlyAPIs,
 					onlySettings:    onlySettings,
 					flatDump:        flatDump,
+					settingsScopes:  settingsScopes,
+					filterFile:      filterFile,
+					redactionOptions: redactionOptions{
+						redactSecrets:       redactSecrets,
+						secretPathsFile:     secretPathsFile,
+						redactSchemaSecrets: redactSchemaSecrets,
+					},
 				},
 			}
 
@@ -130,12 +148,22 @@ func getDownloadConfigsCommand(fs afero.Fs, command Command, downloadCmd *cobra.
 						projectName:    project,
 						outputFolder:   outputFolder,
 						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+						resume:         resume,
+						compress:       compress,
 					},
 					specificAPIs:    specificApis,
 					specificSchemas: specificSettings,
 					onlyAPIs:        onlyAPIs,
 					onlySettings:    onlySettings,
 					flatDump:        flatDump,
+					settingsScopes:  settingsScopes,
+					filterFile:      filterFile,
+					redactionOptions: redactionOptions{
+						redactSecrets:       redactSecrets,
+						secretPathsFile:     secretPathsFile,
+						redactSchemaSecrets: redactSchemaSecrets,
+					},
 				},
 			}
 			return command.DownloadConfigs(fs, options)
@@ -143,20 +171,28 @@ func getDownloadConfigsCommand(fs afero.Fs, command Command, downloadCmd *cobra.
 		},
 	}
 
-	setupSharedConfigsFlags(manifestDownloadCmd, &project, &outputFolder, &forceOverwrite, &specificApis, &specificSettings, &onlyAPIs, &onlySettings, &flatDump)
-	setupSharedConfigsFlags(directDownloadCmd, &project, &outputFolder, &forceOverwrite, &specificApis, &specificSettings, &onlyAPIs, &onlySettings, &flatDump)
+	setupSharedConfigsFlags(manifestDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificApis, &specificSettings, &onlyAPIs, &onlySettings, &flatDump, &settingsScopes, &filterFile)
+	setupSharedConfigsFlags(directDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificApis, &specificSettings, &onlyAPIs, &onlySettings, &flatDump, &settingsScopes, &filterFile)
+	setupRedactionFlags(manifestDownloadCmd, &redactSecrets, &secretPathsFile, &redactSchemaSecrets)
+	setupRedactionFlags(directDownloadCmd, &redactSecrets, &secretPathsFile, &redactSchemaSecrets)
+	setupCompressFlag(manifestDownloadCmd, &compress)
+	setupCompressFlag(directDownloadCmd, &compress)
 
 	downloadCmd.AddCommand(manifestDownloadCmd)
 	downloadCmd.AddCommand(directDownloadCmd)
 }
 
 func getDownloadEntitiesCommand(fs afero.Fs, command Command, downloadCmd *cobra.Command) {
-	var project, outputFolder string
-	var forceOverwrite bool
+	var project, outputFolder, metricsFile string
+	var forceOverwrite, resume bool
 	var specificEntitiesTypes []string
 	var timeFromMinutes int
 	var timeToMinutes int
 	var entityPageSize int
+	var shardThreshold int
+	var incremental bool
+	var entitySelector, fields, typeOverridesFile string
+	var compress string
 
 	downloadEntitiesCmd := &cobra.Command{
 		Use:   "entities",
@@ -194,12 +230,20 @@ Either downloading based on an existing manifest, or by defining environment URL
 						projectName:    project,
 						outputFolder:   outputFolder,
 						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+						resume:         resume,
+						compress:       compress,
 					},
 					specificEntitiesTypes: specificEntitiesTypes,
 					listEntitiesOptions: listEntitiesOptions{
-						timeFromMinutes: timeFromMinutes,
-						timeToMinutes:   timeToMinutes,
-						entityPageSize:  entityPageSize,
+						timeFromMinutes:   timeFromMinutes,
+						timeToMinutes:     timeToMinutes,
+						entityPageSize:    entityPageSize,
+						shardThreshold:    shardThreshold,
+						incremental:       incremental,
+						entitySelector:    entitySelector,
+						fields:            fields,
+						typeOverridesFile: typeOverridesFile,
 					},
 				},
 			}
@@ -230,12 +274,20 @@ Either downloading based on an existing manifest, or by defining environment URL
 						projectName:    project,
 						outputFolder:   outputFolder,
 						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+						resume:         resume,
+						compress:       compress,
 					},
 					specificEntitiesTypes: specificEntitiesTypes,
 					listEntitiesOptions: listEntitiesOptions{
-						timeFromMinutes: timeFromMinutes,
-						timeToMinutes:   timeToMinutes,
-						entityPageSize:  entityPageSize,
+						timeFromMinutes:   timeFromMinutes,
+						timeToMinutes:     timeToMinutes,
+						entityPageSize:    entityPageSize,
+						shardThreshold:    shardThreshold,
+						incremental:       incremental,
+						entitySelector:    entitySelector,
+						fields:            fields,
+						typeOverridesFile: typeOverridesFile,
 					},
 				},
 			}
@@ -244,8 +296,10 @@ Either downloading based on an existing manifest, or by defining environment URL
 		},
 	}
 
-	setupSharedEntitiesFlags(manifestDownloadCmd, &project, &outputFolder, &forceOverwrite, &specificEntitiesTypes, &timeFromMinutes, &timeToMinutes, &entityPageSize)
-	setupSharedEntitiesFlags(directDownloadCmd, &project, &outputFolder, &forceOverwrite, &specificEntitiesTypes, &timeFromMinutes, &timeToMinutes, &entityPageSize)
+	setupSharedEntitiesFlags(manifestDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificEntitiesTypes, &timeFromMinutes, &timeToMinutes, &entityPageSize, &shardThreshold, &incremental, &entitySelector, &fields, &typeOverridesFile)
+	setupSharedEntitiesFlags(directDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificEntitiesTypes, &timeFromMinutes, &timeToMinutes, &entityPageSize, &shardThreshold, &incremental, &entitySelector, &fields, &typeOverridesFile)
+	setupCompressFlag(manifestDownloadCmd, &compress)
+	setupCompressFlag(directDownloadCmd, &compress)
 
 	downloadEntitiesCmd.AddCommand(manifestDownloadCmd)
 	downloadEntitiesCmd.AddCommand(directDownloadCmd)
@@ -253,15 +307,109 @@ Either downloading based on an existing manifest, or by defining environment URL
 	downloadCmd.AddCommand(downloadEntitiesCmd)
 }
 
-func setupSharedConfigsFlags(cmd *cobra.Command, project, outputFolder *string, forceOverwrite *bool, specificApis *[]string, specificSettings *[]string, onlyAPIs, onlySettings *bool, flatDump *bool) {
-	setupSharedFlags(cmd, project, outputFolder, forceOverwrite)
+func getDownloadSchemasCommand(fs afero.Fs, command Command, downloadCmd *cobra.Command) {
+	var project, outputFolder, metricsFile string
+	var forceOverwrite, resume bool
+	var specificSchemas []string
+
+	downloadSchemasCmd := &cobra.Command{
+		Use:   "schemas",
+		Short: "Download settings 2.0 schema definitions from Dynatrace",
+		Long: `Download settings 2.0 schema definitions from Dynatrace into the schemas folder of the project
+
+The definitions are used by matching to compare lists, detect secrets and validate the translated settings.`,
+		Example: `- monaco download schemas manifest manifest.yaml some_environment_from_manifest
+- monaco download schemas direct https://environment.live.dynatrace.com API_TOKEN_ENV_VAR_NAME`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("'direct' or 'manifest' sub-command is required")
+		},
+	}
+
+	manifestDownloadCmd := &cobra.Command{
+		Use:     "manifest [manifest file] [environment to download]",
+		Aliases: []string{"m"},
+		Short:   "Download settings 2.0 schema definitions from Dynatrace via a manifest file",
+		Example: `monaco download schemas manifest.yaml some_environment_from_manifest`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 || args[0] == "" || args[1] == "" {
+				return fmt.Errorf(`manifest and environment name have to be provided as positional arguments`)
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.DownloadManifestCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m := args[0]
+			specificEnvironment := args[1]
+			options := schemasManifestDownloadOptions{
+				manifestFile:            m,
+				specificEnvironmentName: specificEnvironment,
+				schemasDownloadCommandOptions: schemasDownloadCommandOptions{
+					downloadCommandOptionsShared: downloadCommandOptionsShared{
+						projectName:    project,
+						outputFolder:   outputFolder,
+						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+					},
+					specificSchemas: specificSchemas,
+				},
+			}
+			return command.DownloadSchemasBasedOnManifest(fs, options)
+		},
+	}
+
+	directDownloadCmd := &cobra.Command{
+		Use:     "direct [URL] [TOKEN_NAME]",
+		Aliases: []string{"d"},
+		Short:   "Download settings 2.0 schema definitions from a Dynatrace environment specified on the command line",
+		Example: `monaco download schemas direct https://environment.live.dynatrace.com API_TOKEN_ENV_VAR_NAME`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 || args[0] == "" || args[1] == "" {
+				return fmt.Errorf(`url and token have to be provided as positional argument`)
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.DownloadDirectCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := args[0]
+			tokenEnvVar := args[1]
+			options := schemasDirectDownloadOptions{
+				environmentUrl: url,
+				envVarName:     tokenEnvVar,
+				schemasDownloadCommandOptions: schemasDownloadCommandOptions{
+					downloadCommandOptionsShared: downloadCommandOptionsShared{
+						projectName:    project,
+						outputFolder:   outputFolder,
+						forceOverwrite: forceOverwrite,
+						metricsFile:    metricsFile,
+					},
+					specificSchemas: specificSchemas,
+				},
+			}
+			return command.DownloadSchemas(fs, options)
+		},
+	}
+
+	setupSharedSchemasFlags(manifestDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificSchemas)
+	setupSharedSchemasFlags(directDownloadCmd, &project, &outputFolder, &metricsFile, &forceOverwrite, &resume, &specificSchemas)
+
+	downloadSchemasCmd.AddCommand(manifestDownloadCmd)
+	downloadSchemasCmd.AddCommand(directDownloadCmd)
+
+	downloadCmd.AddCommand(downloadSchemasCmd)
+}
+
+func setupSharedConfigsFlags(cmd *cobra.Command, project, outputFolder, metricsFile *string, forceOverwrite, resume *bool, specificApis *[]string, specificSettings *[]string, onlyAPIs, onlySettings *bool, flatDump *bool, settingsScopes *[]string, filterFile *string) {
+	setupSharedFlags(cmd, project, outputFolder, metricsFile, forceOverwrite, resume)
 	// flags always available
 	cmd.Flags().StringSliceVarP(specificApis, "api", "a", make([]string, 0), "One or more APIs to download (flag can be repeated or value defined as comma-separated list)")
 	cmd.Flags().StringSliceVarP(specificSettings, "settings-schema", "s", make([]string, 0), "One or more settings 2.0 schemas to download (flag can be repeated or value defined as comma-separated list)")
 	cmd.Flags().BoolVar(onlyAPIs, "only-apis", false, "Only download config APIs, skip downloading settings 2.0 objects")
 	cmd.Flags().BoolVar(onlySettings, "only-settings", false, "Only download settings 2.0 objects, skip downloading config APIs")
 	cmd.Flags().BoolVar(flatDump, "flat-dump", false, "Dump results in a big unformatted json array for processing/cache purposes")
+	cmd.Flags().StringSliceVar(settingsScopes, "settings-scope", nil, "One or more scopes to download settings 2.0 objects for, e.g. 'environment', 'HOST-1234' or a scope type prefix like 'HOST_GROUP-' (flag can be repeated or value defined as comma-separated list)")
+	cmd.Flags().StringVar(filterFile, "filter-file", "", "YAML file of additional pre-download and post-download discard conditions per API or settings 2.0 schema")
 	cmd.MarkFlagsMutuallyExclusive("settings-schema", "only-apis")
+	cmd.MarkFlagsMutuallyExclusive("settings-scope", "only-apis")
 	cmd.MarkFlagsMutuallyExclusive("api", "only-settings")
 	cmd.MarkFlagsMutuallyExclusive("only-apis", "only-settings")
 
@@ -271,19 +419,41 @@ func setupSharedConfigsFlags(cmd *cobra.Command, project, outputFolder *string,
 	}
 }
 
-func setupSharedEntitiesFlags(cmd *cobra.Command, project, outputFolder *string, forceOverwrite *bool, specificEntitiesTypes *[]string, timeFromMinutes *int, timeToMinutes *int, entityPageSize *int) {
-	setupSharedFlags(cmd, project, outputFolder, forceOverwrite)
+func setupCompressFlag(cmd *cobra.Command, compress *string) {
+	cmd.Flags().StringVar(compress, "compress", "", fmt.Sprintf("Write the downloaded templates compressed, either %q or %q. Compressed templates are decompressed transparently when loaded", compression.Gzip, compression.Zstd))
+}
+
+func setupRedactionFlags(cmd *cobra.Command, redactSecrets *bool, secretPathsFile *string, redactSchemaSecrets *bool) {
+	cmd.Flags().BoolVar(redactSecrets, "redact-secrets", false, "Replace the values of known secret properties, e.g. passwords and tokens, by references to environment variable parameters and list them in "+secrets.ReportFileName)
+	cmd.Flags().StringVar(secretPathsFile, "secret-paths", "", "YAML file of additional secret property paths per API or settings 2.0 schema to redact, implies --redact-secrets")
+	cmd.Flags().BoolVar(redactSchemaSecrets, "redact-schema-secrets", false, "Also redact all properties of type secret of the settings 2.0 schema definitions, implies --redact-secrets")
+}
+
+func setupSharedEntitiesFlags(cmd *cobra.Command, project, outputFolder, metricsFile *string, forceOverwrite, resume *bool, specificEntitiesTypes *[]string, timeFromMinutes *int, timeToMinutes *int, entityPageSize *int, shardThreshold *int, incremental *bool, entitySelector, fields, typeOverridesFile *string) {
+	setupSharedFlags(cmd, project, outputFolder, metricsFile, forceOverwrite, resume)
 	cmd.Flags().StringSliceVarP(specificEntitiesTypes, "specific-types", "s", make([]string, 0), "List of entity type IDs specifying which entity types to download")
 	cmd.Flags().IntVarP(timeFromMinutes, "time-from-minutes", "b", client.DefaultEntityMinutesTimeframeFrom, fmt.Sprintf("How many minutes behind do we want to get entities From, defaults to %d weeks, or %d minutes", client.DefaultEntityWeeksTimeframeFrom, client.DefaultEntityMinutesTimeframeFrom))
 	cmd.Flags().IntVarP(timeToMinutes, "time-to-minutes", "t", client.DefaultEntityMinutesTimeframeTo, fmt.Sprintf("How many minutes behind do we want to get entities To, defaults to %d minutes", client.DefaultEntityMinutesTimeframeTo))
 	cmd.Flags().IntVarP(entityPageSize, "entity-page-size", "e", client.DefaultPageSizeEntitiesInt, fmt.Sprintf("How many entities per call to download, defaults to %d minutes", client.DefaultPageSizeEntitiesInt))
+	cmd.Flags().IntVar(shardThreshold, "shard-threshold", client.DefaultEntitiesShardThreshold, fmt.Sprintf("Count of entities of a type above which its timeframe is split into shards downloaded in parallel, unless the shards overlap by long-lived entities, defaults to %d, 0 disables sharding", client.DefaultEntitiesShardThreshold))
+	cmd.Flags().BoolVar(incremental, "incremental", false, "Only download the entities seen since the previous download of the project in the output-folder, and merge them into it")
+	cmd.Flags().StringVar(entitySelector, "entity-selector", "", `Entity selector appended to the type clause of every entities type, e.g. 'mzName("prod"),healthState("HEALTHY")'`)
+	cmd.Flags().StringVar(fields, "fields", "", "Comma separated fields to download in addition to the computed ones, e.g. '+tags,+managementZones'. Fields not available for an entities type are skipped")
+	cmd.Flags().StringVar(typeOverridesFile, "type-overrides", "", "YAML file with an entitySelector and fields per entities type, replacing --entity-selector and --fields for those types")
 
 }
-func setupSharedFlags(cmd *cobra.Command, project, outputFolder *string, forceOverwrite *bool) {
+func setupSharedSchemasFlags(cmd *cobra.Command, project, outputFolder, metricsFile *string, forceOverwrite, resume *bool, specificSchemas *[]string) {
+	setupSharedFlags(cmd, project, outputFolder, metricsFile, forceOverwrite, resume)
+	cmd.Flags().StringSliceVarP(specificSchemas, "settings-schema", "s", make([]string, 0), "One or more settings 2.0 schemas to download the definition of (flag can be repeated or value defined as comma-separated list)")
+}
+
+func setupSharedFlags(cmd *cobra.Command, project, outputFolder, metricsFile *string, forceOverwrite, resume *bool) {
 	// flags always available
 	cmd.Flags().StringVarP(project, "project", "p", "project", "Project to create within the output-folder")
 	cmd.Flags().StringVarP(outputFolder, "output-folder", "o", "", "Folder to write downloaded configs to")
 	cmd.Flags().BoolVarP(forceOverwrite, "force", "f", false, "Force overwrite any existing manifest.yaml, rather than creating an additional manifest_{timestamp}.yaml. Manifest download: additionally never append source environment name to project folder name")
+	cmd.Flags().StringVar(metricsFile, "metrics-file", "", "Also write the statistics of the run written to summary.json into the given file, in the Prometheus text format")
+	cmd.Flags().BoolVar(resume, "resume", false, "Continue the paginated downloads of a failed run from their checkpoints in the output-folder, restarting those whose page key has expired")
 	err := cmd.MarkFlagDirname("output-folder")
 	if err != nil {
 		log.Fatal("failed to setup CLI %v", err)
@@ -294,9 +464,16 @@ func setupSharedFlags(cmd *cobra.Command, project, outputFolder *string, forceOv
 // notifying the user that downloaded objects cannot be uploaded to the same environment.
 // It verifies the version of the tenant and, depending on the result, it may or may not display the warning.
 func printUploadToSameEnvironmentWarning(env manifest.EnvironmentDefinition) {
-	var serverVersion version.Version
-	var err error
+	serverVersion, err := getDynatraceVersion(env)
+	if err != nil {
+		log.Warn("Unable to determine server version %q: %w", env.URL.Value, err)
+	} else if serverVersion.SmallerThan(version.Version{Major: 1, Minor: 262}) {
+		logUploadToSameEnvironmentWarning()
+	}
+}
 
+// getDynatraceVersion fetches the cluster version of the environment, authenticating with its token or OAuth credentials
+func getDynatraceVersion(env manifest.EnvironmentDefinition) (version.Version, error) {
 	var httpClient *http.Client
 	if env.Type == manifest.Classic {
 		httpClient = client.NewTokenAuthClient(env.Auth.Token.Value)
@@ -310,12 +487,7 @@ func printUploadToSameEnvironmentWarning(env manifest.EnvironmentDefinition) {
 		httpClient = client.NewOAuthClient(context.TODO(), credentials)
 	}
 
-	serverVersion, err = client.GetDynatraceVersion(httpClient, env.URL.Value)
-	if err != nil {
-		log.Warn("Unable to determine server version %q: %w", env.URL.Value, err)
-	} else if serverVersion.SmallerThan(version.Version{Major: 1, Minor: 262}) {
-		logUploadToSameEnvironmentWarning()
-	}
+	return client.GetDynatraceVersion(httpClient, env.URL.Value)
 }
 
 func logUploadToSameEnvironmentWarning() {
@@ -17,6 +17,7 @@
 package download
 
 import (
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/afero"
 	"gotest.tools/assert"
@@ -142,6 +143,129 @@ func TestValidCommands(t *testing.T) {
 				})
 			},
 		},
+		{
+			"direct download with metrics file",
+			"direct test.url token --project test --metrics-file run.prom",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "test",
+							outputFolder:   "",
+							forceOverwrite: false,
+							metricsFile:    "run.prom",
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+					},
+				})
+			},
+		},
+		{
+			"direct download resumed from checkpoints",
+			"direct test.url token --output-folder myDownloads --resume",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "myDownloads",
+							forceOverwrite: false,
+							resume:         true,
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+					},
+				})
+			},
+		},
+		{
+			"direct download of settings for specific scopes",
+			"direct test.url token --settings-scope environment,HOST_GROUP-",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "",
+							forceOverwrite: false,
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+						settingsScopes:  []string{"environment", "HOST_GROUP-"},
+					},
+				})
+			},
+		},
+		{
+			"direct download with compressed templates",
+			"direct test.url token --compress zstd",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "",
+							forceOverwrite: false,
+							compress:       "zstd",
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+					},
+				})
+			},
+		},
+		{
+			"direct download with filter file",
+			"direct test.url token --filter-file filters.yaml",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "",
+							forceOverwrite: false,
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+						filterFile:      "filters.yaml",
+					},
+				})
+			},
+		},
+		{
+			"direct download with redacted secrets",
+			"direct test.url token --secret-paths secrets.yaml --redact-schema-secrets",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadConfigs(gomock.Any(), directDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					downloadCommandOptions: downloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "",
+							forceOverwrite: false,
+						},
+						specificAPIs:    []string{},
+						specificSchemas: []string{},
+						redactionOptions: redactionOptions{
+							secretPathsFile:     "secrets.yaml",
+							redactSchemaSecrets: true,
+						},
+					},
+				})
+			},
+		},
 		{
 			"direct download with default project",
 			"direct test.url token",
@@ -556,6 +680,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -574,6 +704,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -592,6 +728,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -610,6 +752,87 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: true,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
+					},
+				})
+			},
+		},
+		{
+			"entities direct download incremental",
+			"entities direct test.url token --output-folder myDownloads --incremental",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadEntities(gomock.Any(), entitiesDirectDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					entitiesDownloadCommandOptions: entitiesDownloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							outputFolder:   "myDownloads",
+							forceOverwrite: false,
+						},
+						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+							incremental:     true,
+						},
+					},
+				})
+			},
+		},
+		{
+			"entities direct download with entity selector, fields and type overrides",
+			`entities direct test.url token --entity-selector mzName("prod") --fields +tags,+managementZones --type-overrides overrides.yaml`,
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadEntities(gomock.Any(), entitiesDirectDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					entitiesDownloadCommandOptions: entitiesDownloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							forceOverwrite: false,
+						},
+						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes:   client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:     client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:    client.DefaultPageSizeEntitiesInt,
+							shardThreshold:    client.DefaultEntitiesShardThreshold,
+							entitySelector:    `mzName("prod")`,
+							fields:            "+tags,+managementZones",
+							typeOverridesFile: "overrides.yaml",
+						},
+					},
+				})
+			},
+		},
+		{
+			"entities direct download resumed from checkpoints",
+			"entities direct test.url token --resume",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadEntities(gomock.Any(), entitiesDirectDownloadOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					entitiesDownloadCommandOptions: entitiesDownloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							forceOverwrite: false,
+							resume:         true,
+						},
+						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -628,6 +851,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -646,6 +875,38 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
+					},
+				})
+			},
+		},
+		{
+			"entities manifest download with entity selector, fields and type overrides",
+			`entities manifest test.yaml test_env --entity-selector healthState("HEALTHY") --fields +tags --type-overrides overrides.yaml`,
+			func(cmd *MockCommand) {
+				cmd.EXPECT().DownloadEntitiesBasedOnManifest(gomock.Any(), entitiesManifestDownloadOptions{
+					manifestFile:            "test.yaml",
+					specificEnvironmentName: "test_env",
+					entitiesDownloadCommandOptions: entitiesDownloadCommandOptions{
+						downloadCommandOptionsShared: downloadCommandOptionsShared{
+							projectName:    "project",
+							forceOverwrite: false,
+						},
+						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes:   client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:     client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:    client.DefaultPageSizeEntitiesInt,
+							shardThreshold:    client.DefaultEntitiesShardThreshold,
+							entitySelector:    `healthState("HEALTHY")`,
+							fields:            "+tags",
+							typeOverridesFile: "overrides.yaml",
+						},
 					},
 				})
 			},
@@ -664,6 +925,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -682,6 +949,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: true,
 						},
 						specificEntitiesTypes: []string{},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -700,6 +973,12 @@ func TestValidCommands(t *testing.T) {
 							forceOverwrite: false,
 						},
 						specificEntitiesTypes: []string{"HOST", "SERVICE"},
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
 					},
 				})
 			},
@@ -720,6 +999,66 @@ func TestValidCommands(t *testing.T) {
 	}
 }
 
+func TestValidSnapshotCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  string
+		setup func(command *MockCommand)
+	}{
+		{
+			"manifest snapshot with defaults",
+			"create manifest test.yaml test_env",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().CreateSnapshotBasedOnManifest(gomock.Any(), snapshotManifestOptions{
+					manifestFile:            "test.yaml",
+					specificEnvironmentName: "test_env",
+					snapshotCommandOptions: snapshotCommandOptions{
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: client.DefaultEntityMinutesTimeframeFrom,
+							timeToMinutes:   client.DefaultEntityMinutesTimeframeTo,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
+					},
+				})
+			},
+		},
+		{
+			"direct snapshot with output file and entities timeframe",
+			"create direct test.url token --output-file snapshots/test.tar.gz --force --time-from-minutes 60 --time-to-minutes 5",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().CreateSnapshot(gomock.Any(), snapshotDirectOptions{
+					environmentUrl: "test.url",
+					envVarName:     "token",
+					snapshotCommandOptions: snapshotCommandOptions{
+						outputFile:     "snapshots/test.tar.gz",
+						forceOverwrite: true,
+						listEntitiesOptions: listEntitiesOptions{
+							timeFromMinutes: 60,
+							timeToMinutes:   5,
+							entityPageSize:  client.DefaultPageSizeEntitiesInt,
+							shardThreshold:  client.DefaultEntitiesShardThreshold,
+						},
+					},
+				})
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commandMock := createDownloadCommandMock(t)
+			test.setup(commandMock)
+
+			cmd := GetSnapshotCommand(afero.NewOsFs(), commandMock)
+			cmd.SetArgs(strings.Split(test.args, " "))
+			cmd.SetOut(io.Discard) // skip output to ensure that the error message contains the error, not the help message
+			err := cmd.Execute()
+
+			assert.NilError(t, err, "no error expected")
+		})
+	}
+}
+
 // TestInvalidCliCommands is a very basic test testing that invalid commands error.
 // It is not the goal to test the exact message that cobra generates, except if we supply the message.
 // Otherwise, we would run into issues upon upgrading.
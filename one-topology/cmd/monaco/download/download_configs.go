@@ -28,9 +28,12 @@ import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/classic"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/settings"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 )
 
@@ -41,6 +44,9 @@ type downloadCommandOptions struct {
 	onlyAPIs        bool
 	onlySettings    bool
 	flatDump        bool
+	settingsScopes  []string
+	filterFile      string
+	redactionOptions
 }
 
 type manifestDownloadOptions struct {
@@ -54,6 +60,18 @@ type directDownloadOptions struct {
 	downloadCommandOptions
 }
 
+// redactionOptions specify if and which secrets are replaced by references to environment variable parameters during download
+type redactionOptions struct {
+	redactSecrets       bool
+	secretPathsFile     string
+	redactSchemaSecrets bool
+}
+
+// enabled returns true if secrets need to be redacted. Specifying additional secrets to redact implies redaction
+func (o redactionOptions) enabled() bool {
+	return o.redactSecrets || o.secretPathsFile != "" || o.redactSchemaSecrets
+}
+
 func (d DefaultCommand) DownloadConfigsBasedOnManifest(fs afero.Fs, cmdOptions manifestDownloadOptions) error {
 
 	env, err := cmdutils.GetEnvFromManifest(fs, cmdOptions.manifestFile, cmdOptions.specificEnvironmentName)
@@ -78,20 +96,32 @@ func (d DefaultCommand) DownloadConfigsBasedOnManifest(fs afero.Fs, cmdOptions m
 			projectName:             cmdOptions.projectName,
 			forceOverwriteManifest:  cmdOptions.forceOverwrite,
 			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+			compress:                cmdOptions.compress,
 		},
-		specificAPIs:    cmdOptions.specificAPIs,
-		specificSchemas: cmdOptions.specificSchemas,
-		onlyAPIs:        cmdOptions.onlyAPIs,
-		onlySettings:    cmdOptions.onlySettings,
-		flatDump:        cmdOptions.flatDump,
+		specificAPIs:     cmdOptions.specificAPIs,
+		specificSchemas:  cmdOptions.specificSchemas,
+		onlyAPIs:         cmdOptions.onlyAPIs,
+		onlySettings:     cmdOptions.onlySettings,
+		flatDump:         cmdOptions.flatDump,
+		settingsScopes:   cmdOptions.settingsScopes,
+		filterFile:       cmdOptions.filterFile,
+		redactionOptions: cmdOptions.redactionOptions,
+	}
+
+	checkpoints := newCheckpointStore(fs, cmdOptions.downloadCommandOptionsShared)
+	dtClient, err := cmdutils.CreateDTClient(env, false, client.WithCheckpoints(checkpoints))
+	if err != nil {
+		return err
 	}
 
-	dtClient, err := cmdutils.CreateDTClient(env, false)
+	err = doDownloadConfigs(fs, dtClient, api.NewAPIs(), options)
 	if err != nil {
 		return err
 	}
 
-	return doDownloadConfigs(fs, dtClient, api.NewAPIs(), options)
+	removeCheckpoints(fs, cmdOptions.downloadCommandOptionsShared, checkpoints)
+	return nil
 }
 
 func (d DefaultCommand) DownloadConfigs(fs afero.Fs, cmdOptions directDownloadOptions) error {
@@ -116,20 +146,32 @@ func (d DefaultCommand) DownloadConfigs(fs afero.Fs, cmdOptions directDownloadOp
 			projectName:             cmdOptions.projectName,
 			forceOverwriteManifest:  cmdOptions.forceOverwrite,
 			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+			compress:                cmdOptions.compress,
 		},
-		specificAPIs:    cmdOptions.specificAPIs,
-		specificSchemas: cmdOptions.specificSchemas,
-		onlyAPIs:        cmdOptions.onlyAPIs,
-		onlySettings:    cmdOptions.onlySettings,
-		flatDump:        cmdOptions.flatDump,
+		specificAPIs:     cmdOptions.specificAPIs,
+		specificSchemas:  cmdOptions.specificSchemas,
+		onlyAPIs:         cmdOptions.onlyAPIs,
+		onlySettings:     cmdOptions.onlySettings,
+		flatDump:         cmdOptions.flatDump,
+		settingsScopes:   cmdOptions.settingsScopes,
+		filterFile:       cmdOptions.filterFile,
+		redactionOptions: cmdOptions.redactionOptions,
 	}
 
-	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token)
+	checkpoints := newCheckpointStore(fs, cmdOptions.downloadCommandOptionsShared)
+	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token, client.WithCheckpoints(checkpoints))
 	if err != nil {
 		return err
 	}
 
-	return doDownloadConfigs(fs, dtClient, api.NewAPIs(), options)
+	err = doDownloadConfigs(fs, dtClient, api.NewAPIs(), options)
+	if err != nil {
+		return err
+	}
+
+	removeCheckpoints(fs, cmdOptions.downloadCommandOptionsShared, checkpoints)
+	return nil
 }
 
 type downloadConfigsOptions struct {
@@ -139,6 +181,9 @@ type downloadConfigsOptions struct {
 	onlyAPIs        bool
 	onlySettings    bool
 	flatDump        bool
+	settingsScopes  []string
+	filterFile      string
+	redactionOptions
 }
 
 func doDownloadConfigs(fs afero.Fs, c client.Client, apis api.APIs, opts downloadConfigsOptions) error {
@@ -161,18 +206,109 @@ func doDownloadConfigs(fs afero.Fs, c client.Client, apis api.APIs, opts downloa
 		return err
 	}
 
+	redactor, err := newRedactor(fs, opts.redactionOptions)
+	if err != nil {
+		return err
+	}
+
+	discardConditions, err := loadDiscardConditions(fs, opts.filterFile)
+	if err != nil {
+		return err
+	}
+
+	summary.Start("download configs")
+	summary.StartPhase("download")
+
 	log.Info("Downloading from environment '%v' into project '%v'", opts.environmentUrl, opts.projectName)
-	downloadedConfigs, err := downloadConfigs(c, apis, opts)
+	downloadedConfigs, err := downloadConfigs(c, apis, opts, redactor, discardConditions)
 	if err != nil {
 		return err
 	}
 
 	if !opts.flatDump {
+		summary.StartPhase("resolve dependencies")
 		log.Info("Resolving dependencies between configurations")
 		downloadedConfigs = download.ResolveDependencies(downloadedConfigs)
 	}
 
-	return writeConfigs(downloadedConfigs, opts.downloadOptionsShared, fs)
+	summary.StartPhase("write")
+	projectFolder, err := writeConfigs(downloadedConfigs, opts.downloadOptionsShared, fs)
+	if err != nil {
+		return err
+	}
+
+	if shouldDownloadSettings(opts) {
+		writeSchemaVersions(fs, c, projectFolder)
+	}
+
+	err = writeRedactionReport(fs, redactor, projectFolder)
+	if err != nil {
+		return err
+	}
+
+	return writeSummary(fs, projectFolder, downloadedConfigs, "configs", opts.downloadOptionsShared)
+}
+
+// writeSchemaVersions persists the versions of the settings 2.0 schemas of the environment, so that matching can detect
+// schemas missing or of a different major version on the target environment
+func writeSchemaVersions(fs afero.Fs, c client.SettingsClient, projectFolder string) {
+	schemas, err := c.ListSchemas()
+	if err != nil {
+		log.Warn("Failed to fetch the settings 2.0 schema versions, matching will not detect incompatible schemas: %v", err)
+		return
+	}
+
+	err = settings.WriteSchemaVersions(fs, projectFolder, settings.NewSchemaVersions(schemas))
+	if err != nil {
+		log.Warn("Matching will not detect incompatible schemas: %v", err)
+	}
+}
+
+// loadDiscardConditions reads the user defined discard conditions, if a filter file is given
+func loadDiscardConditions(fs afero.Fs, filterFile string) (discard.File, error) {
+	if filterFile == "" {
+		return nil, nil
+	}
+
+	conditions, err := discard.LoadFile(fs, filterFile)
+	if err != nil {
+		log.Error("Failed to load the filter file: %v", err)
+		return nil, err
+	}
+	return conditions, nil
+}
+
+// newRedactor creates the redactor of the known and the user defined secret paths, or nil if secrets are not redacted
+func newRedactor(fs afero.Fs, opts redactionOptions) (*secrets.Redactor, error) {
+	if !opts.enabled() {
+		return nil, nil
+	}
+
+	paths := secrets.DefaultPaths()
+	if opts.secretPathsFile != "" {
+		userPaths, err := secrets.LoadPaths(fs, opts.secretPathsFile)
+		if err != nil {
+			log.Error("Failed to load the secret paths: %v", err)
+			return nil, err
+		}
+		paths = paths.With(userPaths)
+	}
+
+	return secrets.NewRedactor(paths), nil
+}
+
+// writeRedactionReport lists all redacted secrets in the project folder, so that the referenced environment variables
+// can be set before deploying
+func writeRedactionReport(fs afero.Fs, redactor *secrets.Redactor, projectFolder string) error {
+	reportFile, err := redactor.WriteReport(fs, projectFolder)
+	if err != nil {
+		return err
+	}
+
+	if reportFile != "" {
+		log.Info("Redacted %d secrets. Set the environment variables listed in %s before deploying", len(redactor.Redactions()), reportFile)
+	}
+	return nil
 }
 
 func validateSpecificAPIs(a api.APIs, apiNames []string) (valid bool, unknownAPIs []string) {
@@ -207,11 +343,11 @@ func validateSpecificSchemas(c client.SettingsClient, schemas []string) (valid b
 	return len(unknownSchemas) == 0, unknownSchemas
 }
 
-func downloadConfigs(c client.Client, apis api.APIs, opts downloadConfigsOptions) (project.ConfigsPerType, error) {
+func downloadConfigs(c client.Client, apis api.APIs, opts downloadConfigsOptions, redactor *secrets.Redactor, discardConditions discard.File) (project.ConfigsPerType, error) {
 	configObjects := make(project.ConfigsPerType)
 
 	if shouldDownloadClassicConfigs(opts) {
-		classicCfgs, err := downloadClassicConfigs(c, apis, opts.specificAPIs, opts.projectName, opts.flatDump)
+		classicCfgs, err := downloadClassicConfigs(c, apis, opts.specificAPIs, opts.projectName, opts.flatDump, redactor, discardConditions)
 		if err != nil {
 			return nil, err
 		}
@@ -219,7 +355,7 @@ func downloadConfigs(c client.Client, apis api.APIs, opts downloadConfigsOptions
 	}
 
 	if shouldDownloadSettings(opts) {
-		settingsObjects := downloadSettings(c, opts.specificSchemas, opts.projectName, opts.flatDump)
+		settingsObjects := downloadSettings(c, opts.specificSchemas, opts.settingsScopes, opts.projectName, opts.flatDump, redactor, opts.redactSchemaSecrets, discardConditions)
 		maps.Copy(configObjects, settingsObjects)
 	}
 
@@ -236,7 +372,7 @@ func shouldDownloadSettings(opts downloadConfigsOptions) bool {
 	return !opts.onlyAPIs && (len(opts.specificAPIs) == 0 || len(opts.specificSchemas) > 0)
 }
 
-func downloadClassicConfigs(c client.Client, apis api.APIs, specificAPIs []string, projectName string, flatDump bool) (project.ConfigsPerType, error) {
+func downloadClassicConfigs(c client.Client, apis api.APIs, specificAPIs []string, projectName string, flatDump bool, redactor *secrets.Redactor, discardConditions discard.File) (project.ConfigsPerType, error) {
 	apisToDownload := getApisToDownload(apis, specificAPIs)
 	if len(apisToDownload) == 0 {
 		return nil, fmt.Errorf("no APIs to download")
@@ -244,23 +380,33 @@ func downloadClassicConfigs(c client.Client, apis api.APIs, specificAPIs []strin
 
 	if len(specificAPIs) > 0 {
 		log.Debug("APIs to download: \n - %v", strings.Join(maps.Keys(apisToDownload), "\n - "))
-		cfgs := classic.DownloadAllConfigs(apisToDownload, c, projectName, flatDump)
+		cfgs := classic.NewDownloader(c, classic.WithRedactor(redactor), classic.WithDiscardConditions(discardConditions)).DownloadAll(apisToDownload, projectName, flatDump)
 		return cfgs, nil
 	}
 
 	log.Debug("APIs to download: \n - %v", strings.Join(maps.Keys(apisToDownload), "\n - "))
-	cfgs := classic.DownloadAllConfigs(apisToDownload, c, projectName, flatDump)
+	cfgs := classic.NewDownloader(c, classic.WithRedactor(redactor), classic.WithDiscardConditions(discardConditions)).DownloadAll(apisToDownload, projectName, flatDump)
 	return cfgs, nil
 }
 
-func downloadSettings(c client.Client, specificSchemas []string, projectName string, flatDump bool) project.ConfigsPerType {
+func downloadSettings(c client.Client, specificSchemas []string, settingsScopes []string, projectName string, flatDump bool, redactor *secrets.Redactor, redactSchemaSecrets bool, discardConditions discard.File) project.ConfigsPerType {
+	opts := []func(*settings.Downloader){settings.WithRedactor(redactor), settings.WithDiscardConditions(discardConditions)}
+	if len(settingsScopes) > 0 {
+		log.Info("Downloading settings for scopes: %v", strings.Join(settingsScopes, ", "))
+		opts = append(opts, settings.WithScopes(settingsScopes))
+	}
+	if redactSchemaSecrets {
+		opts = append(opts, settings.WithSchemaSecrets())
+	}
+	downloader := settings.NewSettingsDownloader(c, opts...)
+
 	if len(specificSchemas) > 0 {
 		log.Debug("Settings to download: \n - %v", strings.Join(specificSchemas, "\n - "))
-		s := settings.Download(c, specificSchemas, projectName, flatDump)
+		s := downloader.Download(specificSchemas, projectName, flatDump)
 		return s
 	}
 
-	s := settings.DownloadAll(c, projectName, flatDump)
+	s := downloader.DownloadAll(projectName, flatDump)
 	return s
 }
 
@@ -214,7 +214,7 @@ func TestDownloadConfigsBehaviour(t *testing.T) {
 
 			tt.expectedBehaviour(c)
 
-			_, err := downloadConfigs(c, api.NewAPIs(), tt.givenOpts)
+			_, err := downloadConfigs(c, api.NewAPIs(), tt.givenOpts, nil, nil)
 			assert.NoError(t, err)
 		})
 	}
@@ -406,7 +406,7 @@ func Test_validateSpecificSettings(t *testing.T) {
 		{
 			"valid if setting is found",
 			given{
-				settingsOnEnvironment:     client.SchemaList{{"builtin:magic.setting"}},
+				settingsOnEnvironment:     client.SchemaList{{SchemaId: "builtin:magic.setting"}},
 				specificSettingsRequested: []string{"builtin:magic.setting"},
 			},
 			true,
@@ -415,7 +415,7 @@ func Test_validateSpecificSettings(t *testing.T) {
 		{
 			"not valid if setting not found",
 			given{
-				settingsOnEnvironment:     client.SchemaList{{"builtin:magic.setting"}},
+				settingsOnEnvironment:     client.SchemaList{{SchemaId: "builtin:magic.setting"}},
 				specificSettingsRequested: []string{"builtin:unknown"},
 			},
 			false,
@@ -424,7 +424,7 @@ func Test_validateSpecificSettings(t *testing.T) {
 		{
 			"not valid if one setting not found",
 			given{
-				settingsOnEnvironment:     client.SchemaList{{"builtin:magic.setting"}},
+				settingsOnEnvironment:     client.SchemaList{{SchemaId: "builtin:magic.setting"}},
 				specificSettingsRequested: []string{"builtin:magic.setting", "builtin:unknown"},
 			},
 			false,
@@ -433,7 +433,7 @@ func Test_validateSpecificSettings(t *testing.T) {
 		{
 			"valid if no specific schemas requested (empty)",
 			given{
-				settingsOnEnvironment:     client.SchemaList{{"builtin:magic.setting"}},
+				settingsOnEnvironment:     client.SchemaList{{SchemaId: "builtin:magic.setting"}},
 				specificSettingsRequested: []string{},
 			},
 			true,
@@ -442,7 +442,7 @@ func Test_validateSpecificSettings(t *testing.T) {
 		{
 			"valid if no specific schemas requested (nil)",
 			given{
-				settingsOnEnvironment:     client.SchemaList{{"builtin:magic.setting"}},
+				settingsOnEnvironment:     client.SchemaList{{SchemaId: "builtin:magic.setting"}},
 				specificSettingsRequested: nil,
 			},
 			true,
@@ -541,7 +541,7 @@ func TestDownloadConfigsExitsEarlyForUnknownSettingsSchema(t *testing.T) {
 		},
 	}
 
-	c.EXPECT().ListSchemas().Return(client.SchemaList{{"builtin:some.schema"}}, nil)
+	c.EXPECT().ListSchemas().Return(client.SchemaList{{SchemaId: "builtin:some.schema"}}, nil)
 
 	givenDefaultAPIs := api.NewAPIs()
 	err := doDownloadConfigs(afero.NewMemMapFs(), c, givenDefaultAPIs, givenOpts)
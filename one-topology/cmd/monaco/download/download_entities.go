@@ -24,9 +24,11 @@ import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/entities"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 )
 
@@ -57,6 +59,13 @@ type listEntitiesOptions struct {
 	timeFromMinutes int
 	timeToMinutes   int
 	entityPageSize  int
+	incremental     bool
+	entitySelector  string
+	fields          string
+	// shardThreshold is the count of entities of a type above which its timeframe is split into shards, 0 disables sharding
+	shardThreshold int
+	// typeOverridesFile holds the entity selector and fields overrides per entities type
+	typeOverridesFile string
 }
 
 func (d DefaultCommand) DownloadEntitiesBasedOnManifest(fs afero.Fs, cmdOptions entitiesManifestDownloadOptions) error {
@@ -80,20 +89,34 @@ func (d DefaultCommand) DownloadEntitiesBasedOnManifest(fs afero.Fs, cmdOptions
 			projectName:             cmdOptions.projectName,
 			forceOverwriteManifest:  cmdOptions.forceOverwrite,
 			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+			compress:                cmdOptions.compress,
 		},
 		specificEntitiesTypes: cmdOptions.specificEntitiesTypes,
 		listEntitiesOptions: listEntitiesOptions{
-			timeFromMinutes: cmdOptions.timeFromMinutes,
-			timeToMinutes:   cmdOptions.timeToMinutes,
-			entityPageSize:  cmdOptions.entityPageSize,
+			timeFromMinutes:   cmdOptions.timeFromMinutes,
+			timeToMinutes:     cmdOptions.timeToMinutes,
+			entityPageSize:    cmdOptions.entityPageSize,
+			shardThreshold:    cmdOptions.shardThreshold,
+			incremental:       cmdOptions.incremental,
+			entitySelector:    cmdOptions.entitySelector,
+			fields:            cmdOptions.fields,
+			typeOverridesFile: cmdOptions.typeOverridesFile,
 		},
 	}
 
-	dtClient, err := cmdutils.CreateDTClient(env, false)
+	checkpoints := newCheckpointStore(fs, cmdOptions.downloadCommandOptionsShared)
+	dtClient, err := cmdutils.CreateDTClient(env, false, client.WithCheckpoints(checkpoints))
 	if err != nil {
 		return err
 	}
-	return doDownloadEntities(fs, dtClient, options)
+	err = doDownloadEntities(fs, dtClient, options)
+	if err != nil {
+		return err
+	}
+
+	removeCheckpoints(fs, cmdOptions.downloadCommandOptionsShared, checkpoints)
+	return nil
 }
 
 func (d DefaultCommand) DownloadEntities(fs afero.Fs, cmdOptions entitiesDirectDownloadOptions) error {
@@ -118,21 +141,35 @@ func (d DefaultCommand) DownloadEntities(fs afero.Fs, cmdOptions entitiesDirectD
 			projectName:             cmdOptions.projectName,
 			forceOverwriteManifest:  cmdOptions.forceOverwrite,
 			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+			compress:                cmdOptions.compress,
 		},
 		specificEntitiesTypes: cmdOptions.specificEntitiesTypes,
 		listEntitiesOptions: listEntitiesOptions{
-			timeFromMinutes: cmdOptions.timeFromMinutes,
-			timeToMinutes:   cmdOptions.timeToMinutes,
-			entityPageSize:  cmdOptions.entityPageSize,
+			timeFromMinutes:   cmdOptions.timeFromMinutes,
+			timeToMinutes:     cmdOptions.timeToMinutes,
+			entityPageSize:    cmdOptions.entityPageSize,
+			shardThreshold:    cmdOptions.shardThreshold,
+			incremental:       cmdOptions.incremental,
+			entitySelector:    cmdOptions.entitySelector,
+			fields:            cmdOptions.fields,
+			typeOverridesFile: cmdOptions.typeOverridesFile,
 		},
 	}
 
-	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token)
+	checkpoints := newCheckpointStore(fs, cmdOptions.downloadCommandOptionsShared)
+	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token, client.WithCheckpoints(checkpoints))
 	if err != nil {
 		return err
 	}
 
-	return doDownloadEntities(fs, dtClient, options)
+	err = doDownloadEntities(fs, dtClient, options)
+	if err != nil {
+		return err
+	}
+
+	removeCheckpoints(fs, cmdOptions.downloadCommandOptionsShared, checkpoints)
+	return nil
 }
 
 func doDownloadEntities(fs afero.Fs, dtClient client.Client, opts downloadEntitiesOptions) error {
@@ -144,29 +181,76 @@ func doDownloadEntities(fs afero.Fs, dtClient client.Client, opts downloadEntiti
 	log.Info("Downloading from environment '%v' into project '%v'", opts.environmentUrl, opts.projectName)
 	log.Info("Time from minutes: %v, Time to minutes: %v", opts.timeFromMinutes, opts.timeToMinutes)
 	log.Info("Entity page Size: %v", opts.entityPageSize)
+	log.Info("Entities shard threshold: %v", opts.shardThreshold)
+
+	err = entities.ValidateEntitySelector(opts.entitySelector)
+	if err != nil {
+		return err
+	}
 
-	downloadedConfigs := downloadEntities(dtClient, opts)
+	var typeOverrides entities.TypeOverrides
+	if opts.typeOverridesFile != "" {
+		typeOverrides, err = entities.LoadTypeOverrides(fs, opts.typeOverridesFile)
+		if err != nil {
+			return err
+		}
+		log.Info("Loaded overrides for %d entities types from %s", len(typeOverrides), opts.typeOverridesFile)
+	}
 
-	return writeConfigs(downloadedConfigs, opts.downloadOptionsShared, fs)
+	summary.Start("download entities")
+
+	var previousConfigs project.ConfigsPerType
+	if opts.incremental {
+		if opts.outputFolder == "" {
+			return fmt.Errorf("--incremental requires the --output-folder of the previous download")
+		}
+
+		summary.StartPhase("read previous download")
+		previousConfigs, err = download.ReadFromDisk(fs, opts.outputFolder, opts.projectName)
+		if err != nil {
+			return err
+		}
+		log.Info("Incremental download into %d entity types of the previous download", len(previousConfigs))
+	}
+
+	summary.StartPhase("download")
+
+	downloadedConfigs := downloadEntities(dtClient, opts, previousConfigs, typeOverrides)
+
+	summary.StartPhase("write")
+	projectFolder, err := writeConfigs(downloadedConfigs, opts.downloadOptionsShared, fs)
+	if err != nil {
+		return err
+	}
+
+	return writeSummary(fs, projectFolder, downloadedConfigs, "entities", opts.downloadOptionsShared)
 }
 
-func downloadEntities(dtClient client.Client, opts downloadEntitiesOptions) project.ConfigsPerType {
+func downloadEntities(dtClient client.Client, opts downloadEntitiesOptions, previousConfigs project.ConfigsPerType, typeOverrides entities.TypeOverrides) project.ConfigsPerType {
 	dtClient = client.LimitClientParallelRequests(dtClient, opts.downloadOptionsShared.concurrentDownloadLimit)
 
+	downloader := entities.NewEntitiesDownloader(dtClient, entities.WithTypeOverrides(typeOverrides))
+	if opts.incremental {
+		downloader = entities.NewIncrementalEntitiesDownloader(dtClient, previousConfigs, entities.WithTypeOverrides(typeOverrides))
+	}
+
 	var entitiesObjects project.ConfigsPerType
 
 	listEntitiesOptions := client.ListEntitiesOptions{
 		TimeFromMinutes: opts.timeFromMinutes,
 		TimeToMinutes:   opts.timeToMinutes,
 		EntityPageSize:  opts.entityPageSize,
+		EntitySelector:  opts.entitySelector,
+		Fields:          opts.fields,
+		ShardThreshold:  opts.shardThreshold,
 	}
 
 	// download specific entity types only
 	if len(opts.specificEntitiesTypes) > 0 {
 		log.Debug("Entity Types to download: \n - %v", strings.Join(opts.specificEntitiesTypes, "\n - "))
-		entitiesObjects = entities.Download(dtClient, opts.specificEntitiesTypes, listEntitiesOptions, opts.projectName)
+		entitiesObjects = downloader.Download(opts.specificEntitiesTypes, listEntitiesOptions, opts.projectName)
 	} else {
-		entitiesObjects = entities.DownloadAll(dtClient, listEntitiesOptions, opts.downloadOptionsShared.projectName)
+		entitiesObjects = downloader.DownloadAll(listEntitiesOptions, opts.downloadOptionsShared.projectName)
 	}
 
 	if numEntities := sumConfigs(entitiesObjects); numEntities > 0 {
@@ -0,0 +1,171 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/environment"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/settings"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
+	"github.com/spf13/afero"
+)
+
+type schemasDownloadCommandOptions struct {
+	downloadCommandOptionsShared
+	specificSchemas []string
+}
+
+type schemasManifestDownloadOptions struct {
+	manifestFile            string
+	specificEnvironmentName string
+	schemasDownloadCommandOptions
+}
+
+type schemasDirectDownloadOptions struct {
+	environmentUrl, envVarName string
+	schemasDownloadCommandOptions
+}
+
+type downloadSchemasOptions struct {
+	downloadOptionsShared
+	specificSchemas []string
+}
+
+func (d DefaultCommand) DownloadSchemasBasedOnManifest(fs afero.Fs, cmdOptions schemasManifestDownloadOptions) error {
+
+	env, err := cmdutils.GetEnvFromManifest(fs, cmdOptions.manifestFile, cmdOptions.specificEnvironmentName)
+	if err != nil {
+		return err
+	}
+
+	if !cmdOptions.forceOverwrite {
+		cmdOptions.projectName = fmt.Sprintf("%s_%s", cmdOptions.projectName, cmdOptions.specificEnvironmentName)
+	}
+
+	concurrentDownloadLimit := environment.GetEnvValueIntLog(environment.ConcurrentRequestsEnvKey)
+
+	options := downloadSchemasOptions{
+		downloadOptionsShared: downloadOptionsShared{
+			environmentUrl:          env.URL.Value,
+			environmentType:         env.Type,
+			auth:                    env.Auth,
+			outputFolder:            cmdOptions.outputFolder,
+			projectName:             cmdOptions.projectName,
+			forceOverwriteManifest:  cmdOptions.forceOverwrite,
+			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+		},
+		specificSchemas: cmdOptions.specificSchemas,
+	}
+
+	dtClient, err := cmdutils.CreateDTClient(env, false)
+	if err != nil {
+		return err
+	}
+
+	return doDownloadSchemas(fs, dtClient, options)
+}
+
+func (d DefaultCommand) DownloadSchemas(fs afero.Fs, cmdOptions schemasDirectDownloadOptions) error {
+	token := os.Getenv(cmdOptions.envVarName)
+	concurrentDownloadLimit := environment.GetEnvValueIntLog(environment.ConcurrentRequestsEnvKey)
+	errors := validateParameters(cmdOptions.envVarName, cmdOptions.environmentUrl, cmdOptions.projectName, token)
+
+	if len(errors) > 0 {
+		return errutils.PrintAndFormatErrors(errors, "not all necessary information is present to start downloading schemas")
+	}
+
+	options := downloadSchemasOptions{
+		downloadOptionsShared: downloadOptionsShared{
+			environmentUrl: cmdOptions.environmentUrl,
+			auth: manifest.Auth{
+				Token: manifest.AuthSecret{
+					Name:  cmdOptions.envVarName,
+					Value: token,
+				},
+			},
+			outputFolder:            cmdOptions.outputFolder,
+			projectName:             cmdOptions.projectName,
+			forceOverwriteManifest:  cmdOptions.forceOverwrite,
+			concurrentDownloadLimit: concurrentDownloadLimit,
+			metricsFile:             cmdOptions.metricsFile,
+		},
+		specificSchemas: cmdOptions.specificSchemas,
+	}
+
+	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token)
+	if err != nil {
+		return err
+	}
+
+	return doDownloadSchemas(fs, dtClient, options)
+}
+
+// doDownloadSchemas writes the definitions of the settings 2.0 schemas into the schemas folder of the project,
+// so that matching can rely on them
+func doDownloadSchemas(fs afero.Fs, c client.Client, opts downloadSchemasOptions) error {
+	err := preDownloadValidations(fs, opts.downloadOptionsShared)
+	if err != nil {
+		return err
+	}
+
+	c = client.LimitClientParallelRequests(c, opts.concurrentDownloadLimit)
+
+	if ok, unknownSchemas := validateSpecificSchemas(c, opts.specificSchemas); !ok {
+		err := fmt.Errorf("requested settings-schema(s) '%v' are not known", strings.Join(unknownSchemas, ","))
+		log.Error("%v. Please consult the documentation for available schemas and verify they are available in your environment.", err)
+		return err
+	}
+
+	summary.Start("download schemas")
+	summary.StartPhase("download")
+
+	log.Info("Downloading schemas from environment '%v' into project '%v'", opts.environmentUrl, opts.projectName)
+
+	schemaIDs := opts.specificSchemas
+	if len(schemaIDs) == 0 {
+		schemas, err := c.ListSchemas()
+		if err != nil {
+			return fmt.Errorf("failed to fetch all known schemas: %w", err)
+		}
+		for _, s := range schemas {
+			schemaIDs = append(schemaIDs, s.SchemaId)
+		}
+	}
+
+	definitions := settings.DownloadSchemaDefinitions(c, schemaIDs)
+
+	summary.StartPhase("write")
+	projectFolder := filepath.Join(opts.outputFolder, opts.projectName)
+	for schemaId, definition := range definitions {
+		err = settings.WriteSchemaDefinition(fs, projectFolder, schemaId, definition)
+		if err != nil {
+			return err
+		}
+	}
+	log.Info("Downloaded the definitions of %d of %d schemas", len(definitions), len(schemaIDs))
+
+	summary.SetTotal("schemas", len(definitions))
+	return summary.Write(fs, projectFolder, opts.metricsFile, summary.Finish())
+}
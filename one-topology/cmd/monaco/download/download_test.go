@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/spf13/afero"
+	"gotest.tools/assert"
 )
 
 func Test_validateOutputFolder(t *testing.T) {
@@ -107,3 +108,33 @@ func getTestFs(existingFolderPaths []string, existingFilePaths []string) afero.F
 	}
 	return fs
 }
+
+func Test_removeCheckpoints(t *testing.T) {
+	fs := getTestFs([]string{"output/.checkpoints/other"}, []string{"output/.checkpoints/project/HOST.spool"})
+	project := downloadCommandOptionsShared{outputFolder: "output", projectName: "project"}
+	other := downloadCommandOptionsShared{outputFolder: "output", projectName: "other"}
+
+	removeCheckpoints(fs, project, newCheckpointStore(fs, project))
+	exists, _ := afero.Exists(fs, "output/.checkpoints/project")
+	assert.Assert(t, !exists)
+	exists, _ = afero.Exists(fs, "output/.checkpoints/other")
+	assert.Assert(t, exists, "the checkpoints of other projects are kept")
+
+	removeCheckpoints(fs, other, newCheckpointStore(fs, other))
+	exists, _ = afero.Exists(fs, "output/.checkpoints")
+	assert.Assert(t, !exists)
+}
+
+func Test_removeCheckpointsKeepsFailedDownloads(t *testing.T) {
+	fs := getTestFs([]string{}, []string{
+		"output/.checkpoints/project/_api_v2_entities_HOST.checkpoint.json",
+		"output/.checkpoints/project/_api_v2_entities_HOST.spool",
+	})
+	project := downloadCommandOptionsShared{outputFolder: "output", projectName: "project"}
+
+	removeCheckpoints(fs, project, newCheckpointStore(fs, project))
+	exists, _ := afero.Exists(fs, "output/.checkpoints/project/_api_v2_entities_HOST.checkpoint.json")
+	assert.Assert(t, exists, "the checkpoint of the failed type is kept")
+	exists, _ = afero.Exists(fs, "output/.checkpoints/project/_api_v2_entities_HOST.spool")
+	assert.Assert(t, exists)
+}
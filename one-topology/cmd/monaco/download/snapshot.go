@@ -0,0 +1,258 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/environment"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/api"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/snapshot"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/version"
+	"github.com/spf13/afero"
+)
+
+type snapshotCommandOptions struct {
+	outputFile     string
+	forceOverwrite bool
+	listEntitiesOptions
+}
+
+type snapshotManifestOptions struct {
+	manifestFile            string
+	specificEnvironmentName string
+	snapshotCommandOptions
+}
+
+type snapshotDirectOptions struct {
+	environmentUrl, envVarName string
+	snapshotCommandOptions
+}
+
+type createSnapshotOptions struct {
+	environment             manifest.EnvironmentDefinition
+	concurrentDownloadLimit int
+	snapshotCommandOptions
+}
+
+func (d DefaultCommand) CreateSnapshotBasedOnManifest(fs afero.Fs, cmdOptions snapshotManifestOptions) error {
+	env, err := cmdutils.GetEnvFromManifest(fs, cmdOptions.manifestFile, cmdOptions.specificEnvironmentName)
+	if err != nil {
+		return err
+	}
+
+	options := createSnapshotOptions{
+		environment:             env,
+		concurrentDownloadLimit: environment.GetEnvValueIntLog(environment.ConcurrentRequestsEnvKey),
+		snapshotCommandOptions:  cmdOptions.snapshotCommandOptions,
+	}
+
+	dtClient, err := cmdutils.CreateDTClient(env, false)
+	if err != nil {
+		return err
+	}
+
+	return doCreateSnapshot(fs, dtClient, options)
+}
+
+func (d DefaultCommand) CreateSnapshot(fs afero.Fs, cmdOptions snapshotDirectOptions) error {
+	token := os.Getenv(cmdOptions.envVarName)
+	environmentName := environmentNameFromUrl(cmdOptions.environmentUrl)
+	errors := validateParameters(cmdOptions.envVarName, cmdOptions.environmentUrl, environmentName, token)
+
+	if len(errors) > 0 {
+		return errutils.PrintAndFormatErrors(errors, "not all necessary information is present to create a snapshot")
+	}
+
+	options := createSnapshotOptions{
+		environment: manifest.EnvironmentDefinition{
+			Name: environmentName,
+			Type: manifest.Classic,
+			URL: manifest.URLDefinition{
+				Type:  manifest.ValueURLType,
+				Value: cmdOptions.environmentUrl,
+			},
+			Group: "default",
+			Auth: manifest.Auth{
+				Token: manifest.AuthSecret{
+					Name:  cmdOptions.envVarName,
+					Value: token,
+				},
+			},
+		},
+		concurrentDownloadLimit: environment.GetEnvValueIntLog(environment.ConcurrentRequestsEnvKey),
+		snapshotCommandOptions:  cmdOptions.snapshotCommandOptions,
+	}
+
+	dtClient, err := client.NewClassicClient(cmdOptions.environmentUrl, token)
+	if err != nil {
+		return err
+	}
+
+	return doCreateSnapshot(fs, dtClient, options)
+}
+
+// doCreateSnapshot downloads the configs, the schema definitions and the entities of the environment into a staging
+// folder, and archives them with a manifest referencing both projects and the metadata of the downloads
+func doCreateSnapshot(fs afero.Fs, c client.Client, opts createSnapshotOptions) error {
+	archivePath := opts.outputFile
+	if archivePath == "" {
+		archivePath = fmt.Sprintf("snapshot_%s_%s.tar.gz", opts.environment.Name, time.Now().Format("2006-01-02-150405"))
+	}
+
+	exists, err := afero.Exists(fs, archivePath)
+	if err != nil {
+		return err
+	}
+	if exists && !opts.forceOverwrite {
+		return fmt.Errorf("snapshot %s already exists, use --force to overwrite it", archivePath)
+	}
+
+	stagingFolder, err := afero.TempDir(fs, "", "monaco-snapshot")
+	if err != nil {
+		return fmt.Errorf("failed to create the staging folder of the snapshot, see error: %w", err)
+	}
+	defer func() {
+		if err := fs.RemoveAll(stagingFolder); err != nil {
+			log.Warn("Failed to remove the staging folder %s of the snapshot: %v", stagingFolder, err)
+		}
+	}()
+
+	metadata := snapshot.Metadata{
+		CreatedAt:      time.Now().UTC(),
+		ToolVersion:    version.MonitoringAsCode,
+		EnvironmentUrl: opts.environment.URL.Value,
+		Environment:    opts.environment.Name,
+		Downloads:      map[string]snapshot.Download{},
+	}
+
+	clusterVersion, err := getDynatraceVersion(opts.environment)
+	if err != nil {
+		log.Warn("Unable to determine server version %q, the snapshot will not record it: %v", opts.environment.URL.Value, err)
+	} else {
+		metadata.ClusterVersion = clusterVersion.String()
+	}
+
+	sharedOptions := func(projectName string) downloadOptionsShared {
+		return downloadOptionsShared{
+			environmentUrl:          opts.environment.URL.Value,
+			environmentType:         opts.environment.Type,
+			auth:                    opts.environment.Auth,
+			outputFolder:            stagingFolder,
+			projectName:             projectName,
+			forceOverwriteManifest:  true,
+			concurrentDownloadLimit: opts.concurrentDownloadLimit,
+		}
+	}
+
+	downloads := []struct {
+		name     string
+		download func() error
+	}{
+		{
+			name: "configs",
+			download: func() error {
+				return doDownloadConfigs(fs, c, api.NewAPIs(), downloadConfigsOptions{downloadOptionsShared: sharedOptions(snapshot.ConfigsProject)})
+			},
+		},
+		{
+			name: "schemas",
+			download: func() error {
+				return doDownloadSchemas(fs, c, downloadSchemasOptions{downloadOptionsShared: sharedOptions(snapshot.ConfigsProject)})
+			},
+		},
+		{
+			name: "entities",
+			download: func() error {
+				return doDownloadEntities(fs, c, downloadEntitiesOptions{
+					downloadOptionsShared: sharedOptions(snapshot.EntitiesProject),
+					listEntitiesOptions:   opts.listEntitiesOptions,
+				})
+			},
+		},
+	}
+
+	for _, d := range downloads {
+		start := time.Now().UTC()
+		if err := d.download(); err != nil {
+			return fmt.Errorf("failed to download the %s of the snapshot, see error: %w", d.name, err)
+		}
+		metadata.Downloads[d.name] = snapshot.Download{
+			Start: start,
+			End:   time.Now().UTC(),
+		}
+	}
+
+	entitiesDownload := metadata.Downloads["entities"]
+	entitiesDownload.TimeFromMinutes = opts.timeFromMinutes
+	entitiesDownload.TimeToMinutes = opts.timeToMinutes
+	metadata.Downloads["entities"] = entitiesDownload
+
+	err = manifest.WriteManifest(&manifest.WriterContext{
+		Fs:           fs,
+		ManifestPath: filepath.Join(stagingFolder, snapshot.ManifestFileName),
+	}, createSnapshotManifest(opts.environment))
+	if err != nil {
+		return fmt.Errorf("failed to write the manifest of the snapshot, see error: %w", err)
+	}
+
+	metadata, err = snapshot.Create(fs, stagingFolder, archivePath, metadata)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Created snapshot %s of environment %q (%s) with %d files", archivePath, metadata.Environment, metadata.EnvironmentUrl, len(metadata.Checksums))
+	return nil
+}
+
+// createSnapshotManifest references the configs and entities projects of a snapshot, and the environment they were downloaded from
+func createSnapshotManifest(env manifest.EnvironmentDefinition) manifest.Manifest {
+	return manifest.Manifest{
+		Projects: manifest.ProjectDefinitionByProjectID{
+			snapshot.ConfigsProject: {
+				Name: snapshot.ConfigsProject,
+				Path: snapshot.ConfigsProject,
+			},
+			snapshot.EntitiesProject: {
+				Name: snapshot.EntitiesProject,
+				Path: snapshot.EntitiesProject,
+			},
+		},
+		Environments: map[string]manifest.EnvironmentDefinition{
+			env.Name: env,
+		},
+	}
+}
+
+// environmentNameFromUrl names the environment of a direct snapshot after the first label of its host,
+// e.g. abc12345 for https://abc12345.live.dynatrace.com
+func environmentNameFromUrl(environmentUrl string) string {
+	u, err := url.Parse(environmentUrl)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	return strings.Split(u.Hostname(), ".")[0]
+}
@@ -0,0 +1,138 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"fmt"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/runner/completion"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func GetSnapshotCommand(fs afero.Fs, command Command) (snapshotCmd *cobra.Command) {
+
+	snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Capture Dynatrace environments into single-file snapshots",
+		Long: `Capture Dynatrace environments into single-file snapshots
+
+A snapshot is a versioned archive holding the configs, the settings 2.0 schema definitions and the entities of an environment,
+with a manifest referencing them and the metadata of the capture. Match files can reference a snapshot via 'snapshotPath'.`,
+		Example: `- monaco snapshot create manifest manifest.yaml some_environment_from_manifest
+- monaco snapshot create direct https://environment.live.dynatrace.com API_TOKEN_ENV_VAR_NAME`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("'create' sub-command is required")
+		},
+	}
+
+	getCreateSnapshotCommand(fs, command, snapshotCmd)
+
+	return snapshotCmd
+}
+
+func getCreateSnapshotCommand(fs afero.Fs, command Command, snapshotCmd *cobra.Command) {
+	var outputFile string
+	var forceOverwrite bool
+	var timeFromMinutes, timeToMinutes, entityPageSize, shardThreshold int
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Download the configs, schemas and entities of an environment into a snapshot",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("'direct' or 'manifest' sub-command is required")
+		},
+	}
+
+	commandOptions := func() snapshotCommandOptions {
+		return snapshotCommandOptions{
+			outputFile:     outputFile,
+			forceOverwrite: forceOverwrite,
+			listEntitiesOptions: listEntitiesOptions{
+				timeFromMinutes: timeFromMinutes,
+				timeToMinutes:   timeToMinutes,
+				entityPageSize:  entityPageSize,
+				shardThreshold:  shardThreshold,
+			},
+		}
+	}
+
+	manifestCreateCmd := &cobra.Command{
+		Use:     "manifest [manifest file] [environment to capture]",
+		Aliases: []string{"m"},
+		Short:   "Create a snapshot of an environment of a manifest file",
+		Example: `monaco snapshot create manifest manifest.yaml some_environment_from_manifest -o snapshots/some_environment.tar.gz`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 || args[0] == "" || args[1] == "" {
+				return fmt.Errorf(`manifest and environment name have to be provided as positional arguments`)
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.DownloadManifestCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := snapshotManifestOptions{
+				manifestFile:            args[0],
+				specificEnvironmentName: args[1],
+				snapshotCommandOptions:  commandOptions(),
+			}
+			return command.CreateSnapshotBasedOnManifest(fs, options)
+		},
+	}
+
+	directCreateCmd := &cobra.Command{
+		Use:     "direct [URL] [TOKEN_NAME]",
+		Aliases: []string{"d"},
+		Short:   "Create a snapshot of a Dynatrace environment specified on the command line",
+		Example: `monaco snapshot create direct https://environment.live.dynatrace.com API_TOKEN_ENV_VAR_NAME -o snapshots/environment.tar.gz`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 || args[0] == "" || args[1] == "" {
+				return fmt.Errorf(`url and token have to be provided as positional argument`)
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.DownloadDirectCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := snapshotDirectOptions{
+				environmentUrl:         args[0],
+				envVarName:             args[1],
+				snapshotCommandOptions: commandOptions(),
+			}
+			return command.CreateSnapshot(fs, options)
+		},
+	}
+
+	setupSnapshotFlags(manifestCreateCmd, &outputFile, &forceOverwrite, &timeFromMinutes, &timeToMinutes, &entityPageSize, &shardThreshold)
+	setupSnapshotFlags(directCreateCmd, &outputFile, &forceOverwrite, &timeFromMinutes, &timeToMinutes, &entityPageSize, &shardThreshold)
+
+	createCmd.AddCommand(manifestCreateCmd)
+	createCmd.AddCommand(directCreateCmd)
+
+	snapshotCmd.AddCommand(createCmd)
+}
+
+func setupSnapshotFlags(cmd *cobra.Command, outputFile *string, forceOverwrite *bool, timeFromMinutes, timeToMinutes, entityPageSize, shardThreshold *int) {
+	cmd.Flags().StringVarP(outputFile, "output-file", "o", "", "The snapshot file to create, defaults to snapshot_{ENVIRONMENT}_{TIMESTAMP}.tar.gz")
+	cmd.Flags().BoolVarP(forceOverwrite, "force", "f", false, "Overwrite the snapshot file if it already exists")
+	cmd.Flags().IntVarP(timeFromMinutes, "time-from-minutes", "b", client.DefaultEntityMinutesTimeframeFrom, fmt.Sprintf("How many minutes behind do we want to get entities From, defaults to %d weeks, or %d minutes", client.DefaultEntityWeeksTimeframeFrom, client.DefaultEntityMinutesTimeframeFrom))
+	cmd.Flags().IntVarP(timeToMinutes, "time-to-minutes", "t", client.DefaultEntityMinutesTimeframeTo, fmt.Sprintf("How many minutes behind do we want to get entities To, defaults to %d minutes", client.DefaultEntityMinutesTimeframeTo))
+	cmd.Flags().IntVarP(entityPageSize, "entity-page-size", "e", client.DefaultPageSizeEntitiesInt, fmt.Sprintf("How many entities per call to download, defaults to %d", client.DefaultPageSizeEntitiesInt))
+	cmd.Flags().IntVar(shardThreshold, "shard-threshold", client.DefaultEntitiesShardThreshold, fmt.Sprintf("Count of entities of a type above which its timeframe is split into shards downloaded in parallel, defaults to %d, 0 disables sharding", client.DefaultEntitiesShardThreshold))
+	err := cmd.MarkFlagFilename("output-file", "tar.gz", "tgz")
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+}
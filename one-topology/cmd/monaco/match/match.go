@@ -17,6 +17,7 @@ package match
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
@@ -27,7 +28,9 @@ import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
 	matchConfigs "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/configs"
 	matchEntities "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -39,7 +42,16 @@ import (
 //
 // The actual implementations are in the [DefaultCommand] struct.
 type Command interface {
-	Match(fs afero.Fs, matchFileName string) error
+	Match(fs afero.Fs, matchFileName string, options matchOptions) error
+}
+
+// matchOptions holds the options of the match command that are not part of the match file
+type matchOptions struct {
+	emitProjectDir string
+	sqliteFile     string
+	reportFormat   string
+	htmlReportFile string
+	metricsFile    string
 }
 
 // DefaultCommand is used to implement the [Command] interface.
@@ -50,15 +62,32 @@ var (
 	_ Command = (*DefaultCommand)(nil)
 )
 
-func (d DefaultCommand) Match(fs afero.Fs, matchFileName string) error {
+func (d DefaultCommand) Match(fs afero.Fs, matchFileName string, options matchOptions) error {
 
 	startTime := time.Now()
+	summary.Start("match")
 
 	matchParameters, err := match.LoadMatchingParameters(fs, matchFileName)
 	if err != nil {
 		return err
 	}
 
+	if options.emitProjectDir != "" {
+		if matchParameters.Type != "configs" {
+			return fmt.Errorf("--emit-project can only be used when matching configs, the match type is: %s", matchParameters.Type)
+		}
+		matchParameters.EmitProjectDir = options.emitProjectDir
+	}
+
+	matchParameters.SqliteFile = options.sqliteFile
+
+	if options.reportFormat != "" && !report.IsValidFormat(options.reportFormat) {
+		return fmt.Errorf("--report must be one of: %s, got: %s", strings.Join(report.Formats, ", "), options.reportFormat)
+	}
+	matchParameters.ReportFormat = options.reportFormat
+	matchParameters.HtmlReportFile = options.htmlReportFile
+
+	summary.StartPhase("load projects")
 	configsSource, configsTarget, err := loadProjects(fs, matchParameters)
 	if err != nil {
 		return err
@@ -80,17 +109,20 @@ func (d DefaultCommand) Match(fs afero.Fs, matchFileName string) error {
 
 	}
 
-	return nil
+	return summary.Write(fs, matchParameters.OutputDir, options.metricsFile, summary.Finish())
 }
 
 var STATS_HEADER = fmt.Sprintf("%65s %10s %12s %10s %10s %10s", "Type", "Matched", "MultiMatched", "UnMatched", "Total", "Source")
 
 func runAndPrintMatchEntities(fs afero.Fs, matchParameters match.MatchParameters, configsSource project.ConfigsPerType, configsTarget project.ConfigsPerType, startTime time.Time) error {
 
+	summary.StartPhase("match")
 	stats, entitiesSourceCount, entitiesTargetCount, err := matchEntities.MatchEntities(fs, matchParameters, configsSource, configsTarget)
 	if err != nil {
 		return err
 	}
+	summary.SetTotal("sourceEntities", entitiesSourceCount)
+	summary.SetTotal("targetEntities", entitiesTargetCount)
 
 	printSortedStatsWithHeader(stats)
 
@@ -98,12 +130,46 @@ func runAndPrintMatchEntities(fs afero.Fs, matchParameters match.MatchParameters
 	log.Info("Finished matching %d entity types, %s source entities and %s target entities in %v (pre-hierarchy)",
 		len(configsSource), p.Sprintf("%d", entitiesSourceCount), p.Sprintf("%d", entitiesTargetCount), time.Since(startTime))
 
+	summary.StartPhase("hierarchy")
 	stats, err = matchEntities.MatchEntitiesHierarchy(fs, matchParameters, configsSource, configsTarget, stats)
 	if err != nil {
 		return err
 	}
 	printSortedStatsWithHeader(stats)
 
+	err = matchEntities.ApplySourceScope(fs, matchParameters, configsSource)
+	if err != nil {
+		return err
+	}
+
+	err = matchEntities.ApplySpecificStatuses(fs, matchParameters, configsTarget)
+	if err != nil {
+		return err
+	}
+
+	summary.StartPhase("write results")
+
+	if matchParameters.SqliteFile != "" {
+		err = matchEntities.WriteMatchStore(fs, matchParameters, configsSource, configsTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	if matchParameters.ReportFormat != "" {
+		err = matchEntities.WriteReport(fs, matchParameters, configsSource, configsTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	if matchParameters.HtmlReportFile != "" {
+		err = matchEntities.WriteHtmlReport(fs, matchParameters, configsSource, configsTarget)
+		if err != nil {
+			return err
+		}
+	}
+
 	log.Info("Finished matching %d entity types, %s source entities and %s target entities in %v",
 		len(configsSource), p.Sprintf("%d", entitiesSourceCount), p.Sprintf("%d", entitiesTargetCount), time.Since(startTime))
 
@@ -112,10 +178,13 @@ func runAndPrintMatchEntities(fs afero.Fs, matchParameters match.MatchParameters
 
 func runAndPrintMatchConfigs(fs afero.Fs, matchParameters match.MatchParameters, configsSource project.ConfigsPerType, configsTarget project.ConfigsPerType, startTime time.Time) error {
 
+	summary.StartPhase("match")
 	stats, configsSourceCount, configsTargetCount, err := matchConfigs.MatchConfigs(fs, matchParameters, configsSource, configsTarget)
 	if err != nil {
 		return err
 	}
+	summary.SetTotal("sourceConfigs", configsSourceCount)
+	summary.SetTotal("targetConfigs", configsTargetCount)
 
 	for _, stat := range stats {
 		log.Info(stat)
@@ -21,16 +21,28 @@ import (
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/runner/completion"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
 func GetMatchCommand(fs afero.Fs, command Command) (matchCmd *cobra.Command) {
+	var emitProjectDir string
+	var sqliteFile string
+	var reportFormat string
+	var htmlReportFile string
+	var metricsFile string
 
 	matchCmd = &cobra.Command{
-		Use:     "match <match.yaml>",
-		Short:   "Match environments defined in match.yaml from the environments defined in the manifest",
-		Example: "monaco match match.yaml",
+		Use:   "match <match.yaml>",
+		Short: "Match environments defined in match.yaml from the environments defined in the manifest",
+		Example: `- monaco match match.yaml
+- monaco match match.yaml --emit-project ./migrated
+- monaco match match.yaml --sqlite ./results/match.db
+- monaco match match.yaml --report xlsx
+- monaco match match.yaml --html ./results/match.html
+- monaco match match.yaml --metrics-file ./results/match.prom`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) >= 2 {
 				return fmt.Errorf(`only the match.yaml file can be provided and it is optional`)
@@ -45,10 +57,50 @@ func GetMatchCommand(fs afero.Fs, command Command) (matchCmd *cobra.Command) {
 				matchFile = args[0]
 			}
 
-			return command.Match(fs, matchFile)
+			options := matchOptions{
+				emitProjectDir: emitProjectDir,
+				sqliteFile:     sqliteFile,
+				reportFormat:   reportFormat,
+				htmlReportFile: htmlReportFile,
+				metricsFile:    metricsFile,
+			}
+
+			return command.Match(fs, matchFile, options)
 		},
 		ValidArgsFunction: completion.MatchCompletion,
 	}
 
+	matchCmd.Flags().StringVar(&emitProjectDir, "emit-project", "", "Write the translated source configs as a Monaco project deployable to the target environment into the given folder")
+	err := matchCmd.MarkFlagDirname("emit-project")
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
+	matchCmd.Flags().StringVar(&sqliteFile, "sqlite", "", "Also write the entities, configs, matches and statuses of the run into the given SQLite file, replacing a previous store at that path")
+	err = matchCmd.MarkFlagFilename("sqlite", "db", "sqlite")
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
+	matchCmd.Flags().StringVar(&reportFormat, "report", "", "Also write a report of the matches per type into the report folder of the output, as 'csv' files or a single 'xlsx' workbook")
+	err = matchCmd.RegisterFlagCompletionFunc("report", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return report.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
+	matchCmd.Flags().StringVar(&htmlReportFile, "html", "", "Also write the matches and the entity stats as a single static html file, that can be opened offline")
+	err = matchCmd.MarkFlagFilename("html", "html")
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
+	matchCmd.Flags().StringVar(&metricsFile, "metrics-file", "", "Also write the statistics of the run written to summary.json into the given file, in the Prometheus text format")
+	err = matchCmd.MarkFlagFilename("metrics-file", "prom", "txt")
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
 	return matchCmd
 }
@@ -83,7 +83,42 @@ func TestValidCommands(t *testing.T) {
 			"match yaml",
 			"match.yaml",
 			func(cmd *MockCommand) {
-				cmd.EXPECT().Match(gomock.Any(), "match.yaml")
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{})
+			},
+		},
+		{
+			"match yaml with emitted project",
+			"match.yaml --emit-project out",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{emitProjectDir: "out"})
+			},
+		},
+		{
+			"match yaml with sqlite store",
+			"match.yaml --sqlite match.db",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{sqliteFile: "match.db"})
+			},
+		},
+		{
+			"match yaml with xlsx report",
+			"match.yaml --report xlsx",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{reportFormat: "xlsx"})
+			},
+		},
+		{
+			"match yaml with html report",
+			"match.yaml --html match.html",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{htmlReportFile: "match.html"})
+			},
+		},
+		{
+			"match yaml with metrics file",
+			"match.yaml --metrics-file match.prom",
+			func(cmd *MockCommand) {
+				cmd.EXPECT().Match(gomock.Any(), "match.yaml", matchOptions{metricsFile: "match.prom"})
 			},
 		},
 	}
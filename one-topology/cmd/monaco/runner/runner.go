@@ -15,8 +15,10 @@
 package runner
 
 import (
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cache"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/download"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/topology"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/version"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 
@@ -70,8 +72,11 @@ Examples:
 
 	// commands
 	rootCmd.AddCommand(download.GetDownloadCommand(fs, &download.DefaultCommand{}))
+	rootCmd.AddCommand(download.GetSnapshotCommand(fs, &download.DefaultCommand{}))
 	rootCmd.AddCommand(version.GetVersionCommand())
 	rootCmd.AddCommand(match.GetMatchCommand(fs, &match.DefaultCommand{}))
+	rootCmd.AddCommand(cache.GetCacheCommand(fs, &cache.DefaultCommand{}))
+	rootCmd.AddCommand(topology.GetTopologyCommand(fs, &topology.DefaultCommand{}))
 
 	return rootCmd
 }
@@ -0,0 +1,99 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/topology"
+	"github.com/spf13/afero"
+)
+
+//go:generate mockgen -source=topology.go -destination=topology_mock.go -package=topology -write_package_comment=false Command
+
+// Command is used to test the CLi commands properly without executing the actual topology export.
+//
+// The actual implementations are in the [DefaultCommand] struct.
+type Command interface {
+	Export(fs afero.Fs, out io.Writer, projectFolder string, options exportOptions) error
+}
+
+// exportOptions holds the format and the filters of the exported topology
+type exportOptions struct {
+	format          string
+	outputFile      string
+	specificTypes   []string
+	around          string
+	depth           int
+	toRelationships bool
+}
+
+// DefaultCommand is used to implement the [Command] interface.
+type DefaultCommand struct{}
+
+// make sure DefaultCommand implements the Command interface
+var (
+	_ Command = (*DefaultCommand)(nil)
+)
+
+func (d DefaultCommand) Export(fs afero.Fs, out io.Writer, projectFolder string, options exportOptions) error {
+	format, err := topology.ParseFormat(options.format)
+	if err != nil {
+		return err
+	}
+
+	exists, err := afero.DirExists(fs, projectFolder)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("entities project %s does not exist", projectFolder)
+	}
+
+	graph, err := topology.Load(fs, projectFolder, topology.Options{ToRelationships: options.toRelationships})
+	if err != nil {
+		return err
+	}
+
+	// the component is computed on the whole topology, so that entities of other types still connect the kept ones
+	if options.around != "" {
+		graph, err = graph.Component(options.around, options.depth)
+		if err != nil {
+			return err
+		}
+	}
+	graph = graph.FilterTypes(options.specificTypes)
+
+	if options.outputFile == "" {
+		return topology.Write(out, graph, format)
+	}
+
+	file, err := fs.OpenFile(options.outputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s, see error: %w", options.outputFile, err)
+	}
+	defer file.Close()
+
+	err = topology.Write(file, graph, format)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Exported %d entities and %d relationships to %s", len(graph.Nodes), len(graph.Edges), options.outputFile)
+	return file.Close()
+}
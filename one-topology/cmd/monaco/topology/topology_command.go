@@ -0,0 +1,88 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/topology"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func GetTopologyCommand(fs afero.Fs, command Command) (topologyCmd *cobra.Command) {
+
+	topologyCmd = &cobra.Command{
+		Use:   "topology",
+		Short: "Inspect the topology of downloaded entities",
+		Example: `- monaco topology export download_2023-06-01/project_env --format dot -o topology.dot
+- monaco topology export download_2023-06-01/project_env --around HOST-1234567890ABCDEF --specific-types HOST,PROCESS_GROUP_INSTANCE`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("'export' sub-command is required")
+		},
+	}
+
+	getExportCommand(fs, command, topologyCmd)
+
+	return topologyCmd
+}
+
+func getExportCommand(fs afero.Fs, command Command, topologyCmd *cobra.Command) {
+	var options exportOptions
+
+	formats := make([]string, len(topology.Formats))
+	for i, f := range topology.Formats {
+		formats[i] = string(f)
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export <entities project folder>",
+		Short: "Export the entities and their relationships as a graph",
+		Long: `Export the entities and their relationships as a graph
+
+The entities become nodes with their type, name and scalar properties, and their fromRelationships become edges.
+Entities that are referenced by relationships but were not downloaded are exported as stub nodes.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 || args[0] == "" {
+				return errors.New("the entities project folder has to be provided as positional argument")
+			}
+			return nil
+		},
+		PreRun: cmdutils.SilenceUsageCommand(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return command.Export(fs, cmd.OutOrStdout(), args[0], options)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&options.format, "format", "f", string(topology.GraphML), fmt.Sprintf("Format of the exported graph, one of: %s", strings.Join(formats, ", ")))
+	exportCmd.Flags().StringVarP(&options.outputFile, "output-file", "o", "", "File to write the graph to, defaults to the standard output")
+	exportCmd.Flags().StringSliceVarP(&options.specificTypes, "specific-types", "s", nil, "Only export the entities of these types, and the relationships between them")
+	exportCmd.Flags().StringVar(&options.around, "around", "", "Only export the entities connected to this entity ID, following relationships in both directions")
+	exportCmd.Flags().IntVar(&options.depth, "depth", 0, "Maximum number of relationships between the --around entity and the exported entities, 0 for the whole connected component")
+	exportCmd.Flags().BoolVar(&options.toRelationships, "to-relationships", false, "Add the toRelationships of the entities as edges, in addition to their fromRelationships")
+
+	err := exportCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formats, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		log.Fatal("failed to setup CLI %v", err)
+	}
+
+	topologyCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,147 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package compression compresses downloaded templates and transparently decompresses them when loading
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is the compression format of a template file
+type Format string
+
+const (
+	// None writes templates as plain JSON
+	None Format = ""
+	Gzip Format = "gzip"
+	Zstd Format = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// MagicLength is the number of leading bytes needed to detect the format of a file
+const MagicLength = 4
+
+// ParseFormat parses the value of the --compress flag
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case None, Gzip, Zstd:
+		return f, nil
+	}
+	return None, fmt.Errorf("unknown compression format %q, supported formats are %q and %q", s, Gzip, Zstd)
+}
+
+// Extension returns the file extension appended to compressed templates, e.g. .gz for config.json.gz
+func (f Format) Extension() string {
+	switch f {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// Compress compresses the data in the given format. Data is returned as is for None
+func Compress(f Format, data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+
+	switch f {
+	case None:
+		return data, nil
+	case Gzip:
+		writer = gzip.NewWriter(&buffer)
+	case Zstd:
+		zstdWriter, err := zstd.NewWriter(&buffer)
+		if err != nil {
+			return nil, err
+		}
+		writer = zstdWriter
+	default:
+		return nil, fmt.Errorf("unknown compression format %q", f)
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("failed to compress using %s: %w", f, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress using %s: %w", f, err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Detect returns the compression format of data based on its leading magic bytes
+func Detect(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd
+	}
+	return None
+}
+
+// Decompress detects the compression format of data and decompresses it. Uncompressed data is returned as is
+func Decompress(data []byte) ([]byte, error) {
+	f := Detect(data)
+	if f == None {
+		return data, nil
+	}
+	return DecompressReader(f, bytes.NewReader(data))
+}
+
+// DecompressReader reads and decompresses all data of r in the given format
+func DecompressReader(f Format, r io.Reader) ([]byte, error) {
+	switch f {
+	case None:
+		return io.ReadAll(r)
+	case Gzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress using %s: %w", f, err)
+		}
+		defer gzipReader.Close()
+		return readAll(f, gzipReader)
+	case Zstd:
+		zstdReader, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress using %s: %w", f, err)
+		}
+		defer zstdReader.Close()
+		return readAll(f, zstdReader)
+	}
+	return nil, fmt.Errorf("unknown compression format %q", f)
+}
+
+func readAll(f Format, r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress using %s: %w", f, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,80 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compression
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"", "gzip", "zstd"} {
+		f, err := ParseFormat(s)
+		assert.NoError(t, err)
+		assert.Equal(t, Format(s), f)
+	}
+
+	_, err := ParseFormat("zip")
+	assert.Error(t, err)
+}
+
+func TestCompressAndDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"entityId": "HOST-1234", "displayName": "host"},`), 100)
+
+	tests := []struct {
+		format    Format
+		extension string
+	}{
+		{None, ""},
+		{Gzip, ".gz"},
+		{Zstd, ".zst"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			compressed, err := Compress(tt.format, data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.format, Detect(compressed))
+			assert.Equal(t, tt.extension, tt.format.Extension())
+
+			if tt.format != None {
+				assert.Less(t, len(compressed), len(data))
+			}
+
+			decompressed, err := Decompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		})
+	}
+}
+
+func TestDecompressCorruptData(t *testing.T) {
+	_, err := Decompress(append([]byte{}, gzipMagic...))
+	assert.Error(t, err)
+
+	_, err = Decompress(append(append([]byte{}, zstdMagic...), 0x00, 0x01))
+	assert.Error(t, err)
+}
+
+func TestDetectShortInput(t *testing.T) {
+	assert.Equal(t, None, Detect(nil))
+	assert.Equal(t, None, Detect([]byte{0x28, 0xb5}))
+	assert.Equal(t, None, Detect([]byte(`[]`)))
+}
@@ -19,6 +19,7 @@ package idutils
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 )
 
 // GenerateExternalID generates the externalID for settings 2.0 objects based on the schema, and ID.
@@ -41,3 +42,28 @@ func GenerateExternalID(schema, id string) string {
 
 	return externalID
 }
+
+// GetConfigIdFromExternalID returns the id the externalID was generated from by GenerateExternalID for the schema.
+// False is returned if the externalID was not generated by GenerateExternalID, or was cut.
+func GetConfigIdFromExternalID(schema, externalID string) (string, bool) {
+	encodedID, found := strings.CutPrefix(externalID, "monaco:")
+	if !found {
+		return "", false
+	}
+
+	decodedID, err := base64.StdEncoding.DecodeString(encodedID)
+	if err != nil {
+		return "", false
+	}
+
+	id, found := strings.CutPrefix(string(decodedID), schema+"$")
+	if !found || id == "" {
+		return "", false
+	}
+
+	if GenerateExternalID(schema, id) != externalID {
+		return "", false
+	}
+
+	return id, true
+}
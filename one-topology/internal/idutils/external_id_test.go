@@ -75,3 +75,18 @@ func TestGenerateExternalIdWithOther500CharsStartsWithKnownPrefix(t *testing.T)
 
 	assert.Assert(t, strings.HasPrefix(extId, "monaco:"))
 }
+
+func TestGetConfigIdFromExternalID(t *testing.T) {
+	id, found := GetConfigIdFromExternalID("builtin:alerting.profile", GenerateExternalID("builtin:alerting.profile", "my-profile"))
+	assert.Assert(t, found)
+	assert.Equal(t, id, "my-profile")
+
+	_, found = GetConfigIdFromExternalID("builtin:other", GenerateExternalID("builtin:alerting.profile", "my-profile"))
+	assert.Assert(t, !found)
+
+	_, found = GetConfigIdFromExternalID("builtin:alerting.profile", "ex1")
+	assert.Assert(t, !found)
+
+	_, found = GetConfigIdFromExternalID("builtin:alerting.profile", "monaco:not-base64")
+	assert.Assert(t, !found)
+}
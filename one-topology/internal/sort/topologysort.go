@@ -16,7 +16,10 @@
 
 package sort
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // TopologySortError is an error returned for any unresolved dependency after sorting
 // The error marks the ID of the node left with unresolved incoming edges after sorting,
@@ -81,7 +84,66 @@ func TopologySort(incomingEdges [][]bool, inDegrees []int) (topoSorted []int, er
 	return topoSorted, errs
 }
 
-// getAllLeaves returns all leaves in the graph to sort - by returning all node ids with no incoming edges
+// TopologySortStages sorts the graph with Kahn's algorithm and groups the sorted node ids into stages.
+// Each node is placed in the stage following the last stage of the nodes it has incoming edges from,
+// so that all nodes of a stage only depend on nodes of previous stages and can be processed in parallel.
+//
+// incomingEdges[i] lists the nodes that node i has incoming edges from, each at most once.
+// Unlike [TopologySort], the graph is given as adjacency lists, so large sparse graphs do not need an n×n matrix.
+// Nodes left with unresolved edges are not part of any stage and are returned as [TopologySortError] like in [TopologySort].
+// The given incomingEdges are not modified, the nodes of a stage are sorted by id.
+func TopologySortStages(incomingEdges [][]int) (stages [][]int, errs []TopologySortError) {
+
+	inDegrees := make([]int, len(incomingEdges))
+	outgoingEdges := make([][]int, len(incomingEdges))
+	for to, froms := range incomingEdges {
+		inDegrees[to] = len(froms)
+		for _, from := range froms {
+			outgoingEdges[from] = append(outgoingEdges[from], to)
+		}
+	}
+
+	stages = [][]int{}
+	stage := getAllLeaves(inDegrees)
+	for len(stage) > 0 {
+		stages = append(stages, stage)
+
+		var next []int
+		for _, cur := range stage {
+			for _, to := range outgoingEdges[cur] {
+				inDegrees[to]--
+				if inDegrees[to] == 0 {
+					next = append(next, to)
+				}
+			}
+		}
+		sort.Ints(next)
+		stage = next
+	}
+
+	errs = []TopologySortError{}
+	for i := range inDegrees {
+		if inDegrees[i] == 0 {
+			continue
+		}
+
+		var unresolved []int
+		for _, from := range incomingEdges[i] {
+			if inDegrees[from] != 0 {
+				unresolved = append(unresolved, from)
+			}
+		}
+		sort.Ints(unresolved)
+
+		errs = append(errs, TopologySortError{
+			OnId:                        i,
+			UnresolvedIncomingEdgesFrom: unresolved,
+		})
+	}
+
+	return stages, errs
+}
+
 func getAllLeaves(inDegrees []int) []int {
 	var nodes []int
 	for i := range inDegrees {
@@ -90,3 +90,69 @@ func TestTopologySort(t *testing.T) {
 		})
 	}
 }
+
+func TestTopologySortStages(t *testing.T) {
+	type args struct {
+		incomingEdges [][]int
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantStages [][]int
+		wantErrs   []TopologySortError
+	}{
+		{
+			"groups independent nodes: 0->1, 0->2, 3",
+			args{
+				[][]int{
+					{},
+					{0},
+					{0},
+					{},
+				},
+			},
+			[][]int{{0, 3}, {1, 2}},
+			[]TopologySortError{},
+		},
+		{
+			"places nodes after their latest dependency: 0->1->2, 0->2",
+			args{
+				[][]int{
+					{},
+					{0},
+					{1, 0},
+				},
+			},
+			[][]int{{0}, {1}, {2}},
+			[]TopologySortError{},
+		},
+		{
+			"reports errors on dependency cycle 1->2->1 and on the nodes depending on it: 0->3, 1->3",
+			args{
+				[][]int{
+					{},
+					{2},
+					{1},
+					{0, 1},
+				},
+			},
+			[][]int{{0}},
+			[]TopologySortError{
+				{OnId: 1, UnresolvedIncomingEdgesFrom: []int{2}},
+				{OnId: 2, UnresolvedIncomingEdgesFrom: []int{1}},
+				{OnId: 3, UnresolvedIncomingEdgesFrom: []int{1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStages, gotErrs := TopologySortStages(tt.args.incomingEdges)
+			if !reflect.DeepEqual(gotStages, tt.wantStages) {
+				t.Errorf("TopologySortStages() gotStages = %v, want %v", gotStages, tt.wantStages)
+			}
+			if !reflect.DeepEqual(gotErrs, tt.wantErrs) {
+				t.Errorf("TopologySortStages() gotErrs = %v, want %v", gotErrs, tt.wantErrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/rest"
+	"github.com/spf13/afero"
+)
+
+// checkpoint is the progress of a paginated download, it is persisted after every page that has a next page,
+// so that the download can be resumed after a failure
+type checkpoint struct {
+	UrlPath            string     `json:"urlPath"`
+	Params             url.Values `json:"params"`
+	NextPageKey        string     `json:"nextPageKey"`
+	ReceivedCount      int        `json:"receivedCount"`
+	ExpectedTotalCount int        `json:"expectedTotalCount"`
+	// Pages is the count of pages written to the spool file
+	Pages int `json:"pages"`
+	// SpoolSize is the size of the spool file after the last page of the checkpoint.
+	// Lines after it were appended by a run that failed before updating the checkpoint, they are ignored and truncated.
+	SpoolSize int64 `json:"spoolSize"`
+
+	// failed is set once a page could not be saved, the later pages are not saved to keep the spool consistent
+	failed bool
+}
+
+// CheckpointStore persists a checkpoint and a spool file of the received pages per paginated download.
+// A nil CheckpointStore does not persist anything.
+type CheckpointStore struct {
+	fs     afero.Fs
+	dir    string
+	resume bool
+}
+
+// errCheckpointRestart is returned when a checkpoint can not be resumed and the download has to restart
+var errCheckpointRestart = errors.New("checkpoint can not be resumed")
+
+// timeframeParams are recomputed on every run, they are taken from the checkpoint when resuming
+var timeframeParams = []string{"from", "to"}
+
+var checkpointKeyRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+const (
+	checkpointFileSuffix = ".checkpoint.json"
+	shardPlanFileSuffix  = ".shards.json"
+)
+
+// NewCheckpointStore creates a store writing the checkpoints into dir.
+// Existing checkpoints are only continued from if resume is set, otherwise the downloads restart.
+func NewCheckpointStore(fs afero.Fs, dir string, resume bool) *CheckpointStore {
+	return &CheckpointStore{
+		fs:     fs,
+		dir:    dir,
+		resume: resume,
+	}
+}
+
+// WithCheckpoints sets the store persisting the progress of paginated downloads
+func WithCheckpoints(store *CheckpointStore) func(*DynatraceClient) {
+	return func(d *DynatraceClient) {
+		d.checkpoints = store
+	}
+}
+
+// Pending returns the checkpoints and shard plans of the downloads that did not finish.
+// A finished download removes its checkpoint, so the remaining ones belong to downloads that failed and can be resumed.
+func (s *CheckpointStore) Pending() ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	entries, err := afero.ReadDir(s.fs, s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), checkpointFileSuffix) || strings.HasSuffix(entry.Name(), shardPlanFileSuffix) {
+			pending = append(pending, entry.Name())
+		}
+	}
+
+	return pending, nil
+}
+
+func (s *CheckpointStore) checkpointPath(urlPath string, logLabel string) string {
+	key := checkpointKeyRegex.ReplaceAllString(urlPath+"_"+logLabel, "_")
+	return filepath.Join(s.dir, key+checkpointFileSuffix)
+}
+
+func (s *CheckpointStore) spoolPath(urlPath string, logLabel string) string {
+	key := checkpointKeyRegex.ReplaceAllString(urlPath+"_"+logLabel, "_")
+	return filepath.Join(s.dir, key+".spool")
+}
+
+func (s *CheckpointStore) read(urlPath string, logLabel string) (checkpoint, bool) {
+	data, err := afero.ReadFile(s.fs, s.checkpointPath(urlPath, logLabel))
+	if err != nil {
+		return checkpoint{}, false
+	}
+
+	var cp checkpoint
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		log.Warn("Ignoring unreadable checkpoint of %s: %v", logLabel, err)
+		return checkpoint{}, false
+	}
+
+	return cp, true
+}
+
+// resumeTimeframe returns the params of the checkpoint to resume, when they only differ from params by their timeframe
+func (s *CheckpointStore) resumeTimeframe(urlPath string, logLabel string, params url.Values) url.Values {
+	if s == nil || !s.resume {
+		return params
+	}
+
+	cp, found := s.read(urlPath, logLabel)
+	if !found || cp.UrlPath != urlPath || !reflect.DeepEqual(withoutTimeframe(cp.Params), withoutTimeframe(params)) {
+		return params
+	}
+
+	return cp.Params
+}
+
+func withoutTimeframe(params url.Values) url.Values {
+	result := url.Values{}
+	for key, value := range params {
+		result[key] = value
+	}
+	for _, key := range timeframeParams {
+		delete(result, key)
+	}
+	return result
+}
+
+// load returns the checkpoint to resume from, if resuming is enabled and a checkpoint of the same query exists
+func (s *CheckpointStore) load(urlPath string, logLabel string, params url.Values) (checkpoint, bool) {
+	if s == nil || !s.resume {
+		return checkpoint{}, false
+	}
+
+	cp, found := s.read(urlPath, logLabel)
+	if !found || cp.NextPageKey == "" {
+		return checkpoint{}, false
+	}
+
+	if cp.UrlPath != urlPath || !reflect.DeepEqual(cp.Params, params) {
+		log.Info("Not resuming %s, the checkpoint was created for other query parameters: %v", logLabel, cp.Params)
+		return checkpoint{}, false
+	}
+
+	return cp, true
+}
+
+// shardPlan is the timeframe of an entities type that was split into shards. It is persisted next to the checkpoints,
+// so that a resumed download splits the same timeframe into the same shards and continues their checkpoints
+type shardPlan struct {
+	Params     url.Values `json:"params"`
+	TotalCount int        `json:"totalCount"`
+	Threshold  int        `json:"threshold"`
+}
+
+func (p shardPlan) shardingRequiredError(entityType string) ShardingRequiredError {
+	return ShardingRequiredError{
+		EntitiesType: entityType,
+		TotalCount:   p.TotalCount,
+		Threshold:    p.Threshold,
+		From:         p.Params.Get("from"),
+		To:           p.Params.Get("to"),
+	}
+}
+
+func (s *CheckpointStore) shardPlanPath(entityType string) string {
+	key := checkpointKeyRegex.ReplaceAllString(pathEntitiesObjects+"_"+entityType, "_")
+	return filepath.Join(s.dir, key+shardPlanFileSuffix)
+}
+
+// saveShardPlan persists the timeframe of the sharded entities type, replacing the plan of a previous run
+func (s *CheckpointStore) saveShardPlan(entityType string, params url.Values, shardingErr ShardingRequiredError) {
+	if s == nil {
+		return
+	}
+
+	err := s.writeShardPlan(entityType, shardPlan{
+		Params:     params,
+		TotalCount: shardingErr.TotalCount,
+		Threshold:  shardingErr.Threshold,
+	})
+	if err != nil {
+		log.Warn("Failed to write the shards of entities Type %s, their download could not be resumed: %v", entityType, err)
+	}
+}
+
+func (s *CheckpointStore) writeShardPlan(entityType string, plan shardPlan) error {
+	err := s.fs.MkdirAll(s.dir, 0777)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(s.fs, s.shardPlanPath(entityType), data, 0644)
+}
+
+// removeShardPlan deletes the shards of an entities type once all of them were listed
+func (s *CheckpointStore) removeShardPlan(entityType string) {
+	if s == nil {
+		return
+	}
+
+	path := s.shardPlanPath(entityType)
+	err := s.fs.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("Failed to remove the shards file %s: %v", path, err)
+	}
+}
+
+// loadShardPlan returns the shards to resume, if resuming is enabled and the type was sharded with the same query
+// and threshold. Only the timeframe may differ, as it is computed from the time of the run
+func (s *CheckpointStore) loadShardPlan(entityType string, params url.Values, threshold int) (shardPlan, bool) {
+	if s == nil || !s.resume || threshold <= 0 {
+		return shardPlan{}, false
+	}
+
+	data, err := afero.ReadFile(s.fs, s.shardPlanPath(entityType))
+	if err != nil {
+		return shardPlan{}, false
+	}
+
+	var plan shardPlan
+	err = json.Unmarshal(data, &plan)
+	if err != nil {
+		log.Warn("Ignoring unreadable shards of entities Type %s: %v", entityType, err)
+		return shardPlan{}, false
+	}
+
+	if plan.Threshold != threshold || !reflect.DeepEqual(withoutTimeframe(plan.Params), withoutTimeframe(params)) {
+		log.Info("Not resuming the shards of entities Type %s, they were created for other query parameters: %v", entityType, plan.Params)
+		return shardPlan{}, false
+	}
+
+	return plan, true
+}
+
+// readPages reads the pages of the checkpoint from the spool file
+func (s *CheckpointStore) readPages(urlPath string, logLabel string, cp checkpoint) ([][]byte, error) {
+	file, err := s.fs.Open(s.spoolPath(urlPath, logLabel))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pages := make([][]byte, 0, cp.Pages)
+	reader := bufio.NewReader(io.LimitReader(file, cp.SpoolSize))
+
+	for len(pages) < cp.Pages {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, bytes.TrimSuffix(line, []byte("\n")))
+	}
+
+	if len(pages) != cp.Pages {
+		return nil, fmt.Errorf("spool file of %s holds %d pages, expected %d", logLabel, len(pages), cp.Pages)
+	}
+
+	return pages, nil
+}
+
+// savePage appends the page to the spool file and updates the checkpoint to continue after it.
+// Only pages that have a next page are saved, a finished download is removed.
+// cp is only updated once the page is saved, after a failure the download resumes from the last saved page.
+func (s *CheckpointStore) savePage(urlPath string, logLabel string, cp *checkpoint, resp rest.Response, totalReceivedCount int) {
+	if s == nil || resp.NextPageKey == "" || cp.failed {
+		return
+	}
+
+	next := *cp
+	next.Pages++
+	next.NextPageKey = resp.NextPageKey
+	next.ReceivedCount = totalReceivedCount
+
+	err := s.writePage(urlPath, logLabel, &next, resp.Body)
+	if err != nil {
+		log.Warn("Failed to write the checkpoint of %s, the download can only be resumed from page %d: %v", logLabel, cp.Pages, err)
+		cp.failed = true
+		return
+	}
+
+	*cp = next
+}
+
+// writePage writes the page at the spool size of the previous checkpoint, and then the checkpoint with the new spool size.
+// Truncating the spool first drops the lines of a run that failed between writing the spool and the checkpoint.
+func (s *CheckpointStore) writePage(urlPath string, logLabel string, cp *checkpoint, body []byte) error {
+	err := s.fs.MkdirAll(s.dir, 0777)
+	if err != nil {
+		return err
+	}
+
+	var page bytes.Buffer
+	err = json.Compact(&page, body)
+	if err != nil {
+		return err
+	}
+	page.WriteByte('\n')
+
+	spool, err := s.fs.OpenFile(s.spoolPath(urlPath, logLabel), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	err = writeAt(spool, cp.SpoolSize, page.Bytes())
+	closeErr := spool.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	cp.SpoolSize += int64(page.Len())
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	checkpointPath := s.checkpointPath(urlPath, logLabel)
+	err = afero.WriteFile(s.fs, checkpointPath+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return s.fs.Rename(checkpointPath+".tmp", checkpointPath)
+}
+
+func writeAt(file afero.File, offset int64, data []byte) error {
+	err := file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+// remove deletes the checkpoint and the spool file of a finished download
+func (s *CheckpointStore) remove(urlPath string, logLabel string) {
+	if s == nil {
+		return
+	}
+
+	for _, path := range []string{s.checkpointPath(urlPath, logLabel), s.spoolPath(urlPath, logLabel)} {
+		err := s.fs.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn("Failed to remove the checkpoint file %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/rest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagesServer serves three pages of HOST entities. The page keys are only valid for the current generation,
+// and the status of the third page is returned instead of it if it is not 200
+type pagesServer struct {
+	generation      int
+	pageThreeStatus int
+	calls           []string
+}
+
+func newPagesServer(t *testing.T, pages *pagesServer) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		nextPageKey := req.URL.Query().Get("nextPageKey")
+		pages.calls = append(pages.calls, nextPageKey)
+
+		switch nextPageKey {
+		case "":
+			_, _ = rw.Write([]byte(fmt.Sprintf(`{"totalCount": 3, "nextPageKey": "page2-%d", "entities": [{"entityId": "HOST-1"}]}`, pages.generation)))
+		case fmt.Sprintf("page2-%d", pages.generation):
+			_, _ = rw.Write([]byte(fmt.Sprintf(`{"totalCount": 3, "nextPageKey": "page3-%d", "entities": [{"entityId": "HOST-2"}]}`, pages.generation)))
+		case fmt.Sprintf("page3-%d", pages.generation):
+			if pages.pageThreeStatus != http.StatusOK {
+				http.Error(rw, "{}", pages.pageThreeStatus)
+				return
+			}
+			_, _ = rw.Write([]byte(`{"totalCount": 3, "entities": [{"entityId": "HOST-3"}]}`))
+		default:
+			http.Error(rw, `{"error": {"code": 400, "message": "nextPageKey expired"}}`, http.StatusBadRequest)
+		}
+	}))
+}
+
+func newCheckpointTestClient(server *httptest.Server, store *CheckpointStore) DynatraceClient {
+	return DynatraceClient{
+		environmentURL: server.URL,
+		client:         server.Client(),
+		retrySettings:  testRetrySettings,
+		checkpoints:    store,
+	}
+}
+
+func TestListEntities_ResumeFromCheckpoint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.Error(t, err)
+
+	cp, found := client.checkpoints.read(pathEntitiesObjects, "HOST")
+	assert.True(t, found)
+	assert.Equal(t, "page3-1", cp.NextPageKey)
+	assert.Equal(t, 2, cp.Pages)
+	assert.Equal(t, 2, cp.ReceivedCount)
+
+	pages.pageThreeStatus = http.StatusOK
+	pages.calls = []string{}
+	client = newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", true))
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"page3-1"}, pages.calls)
+	assert.Equal(t, []string{`{"entityId":"HOST-1"}`, `{"entityId":"HOST-2"}`, `{"entityId": "HOST-3"}`}, entityList.Entities)
+	assert.Equal(t, cp.Params.Get("from"), entityList.From)
+	assert.Equal(t, cp.Params.Get("to"), entityList.To)
+
+	_, found = client.checkpoints.read(pathEntitiesObjects, "HOST")
+	assert.False(t, found)
+	exists, _ := afero.Exists(fs, client.checkpoints.spoolPath(pathEntitiesObjects, "HOST"))
+	assert.False(t, exists)
+}
+
+func TestCheckpointStore_PendingKeepsFailedType(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.Error(t, err)
+
+	pages.pageThreeStatus = http.StatusOK
+	_, err = client.ListEntities(EntitiesType{EntitiesTypeId: "SERVICE"}, ListEntitiesOptions{})
+	assert.NoError(t, err)
+
+	pending, err := client.checkpoints.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Base(client.checkpoints.checkpointPath(pathEntitiesObjects, "HOST"))}, pending)
+	exists, _ := afero.Exists(fs, client.checkpoints.spoolPath(pathEntitiesObjects, "HOST"))
+	assert.True(t, exists)
+	exists, _ = afero.Exists(fs, client.checkpoints.spoolPath(pathEntitiesObjects, "SERVICE"))
+	assert.False(t, exists)
+}
+
+func TestListEntities_RestartOnExpiredPageKey(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.Error(t, err)
+
+	pages.generation = 2
+	pages.pageThreeStatus = http.StatusOK
+	pages.calls = []string{}
+	client = newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", true))
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "page3-1", pages.calls[0])
+	assert.Equal(t, []string{"", "page2-2", "page3-2"}, pages.calls[len(pages.calls)-3:])
+	assert.Equal(t, []string{`{"entityId": "HOST-1"}`, `{"entityId": "HOST-2"}`, `{"entityId": "HOST-3"}`}, entityList.Entities)
+}
+
+func TestListEntities_NoResumeWithoutFlag(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.Error(t, err)
+
+	pages.pageThreeStatus = http.StatusOK
+	pages.calls = []string{}
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "page2-1", "page3-1"}, pages.calls)
+	assert.Len(t, entityList.Entities, 3)
+}
+
+func TestListEntities_ResumeIgnoresPagesAfterCheckpoint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.Error(t, err)
+
+	// a run failing between appending to the spool and writing the checkpoint leaves a line after the spool size
+	spoolPath := client.checkpoints.spoolPath(pathEntitiesObjects, "HOST")
+	spool, err := fs.OpenFile(spoolPath, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = spool.Write([]byte(`{"entities":[{"entityId":"HOST-ORPHAN"}]}` + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, spool.Close())
+
+	pages.pageThreeStatus = http.StatusOK
+	client = newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", true))
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"entityId":"HOST-1"}`, `{"entityId":"HOST-2"}`, `{"entityId": "HOST-3"}`}, entityList.Entities)
+}
+
+func TestWritePage_TruncatesSpoolToCheckpoint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	store := NewCheckpointStore(fs, "checkpoints", true)
+
+	cp := checkpoint{UrlPath: pathEntitiesObjects}
+	store.savePage(pathEntitiesObjects, "HOST", &cp, rest.Response{Body: []byte(`{"page": 1}`), NextPageKey: "2"}, 1)
+	saved := cp
+
+	store.savePage(pathEntitiesObjects, "HOST", &cp, rest.Response{Body: []byte(`{"page": 2}`), NextPageKey: "3"}, 2)
+
+	// resuming from the first checkpoint overwrites the second page
+	store.savePage(pathEntitiesObjects, "HOST", &saved, rest.Response{Body: []byte(`{"page": 3}`), NextPageKey: "4"}, 2)
+
+	data, err := afero.ReadFile(fs, store.spoolPath(pathEntitiesObjects, "HOST"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"page\":1}\n{\"page\":3}\n", string(data))
+	assert.Equal(t, int64(len(data)), saved.SpoolSize)
+	assert.Equal(t, 2, saved.Pages)
+}
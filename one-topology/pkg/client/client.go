@@ -89,7 +89,13 @@ type SettingsClient interface {
 	// ListSchemas returns all schemas that the Dynatrace environment reports
 	ListSchemas() (SchemaList, error)
 
-	// ListSettings returns all settings objects for a given schema in a flat unformatted file.
+	// GetSchema returns the full definition of the given schema, in its latest version
+	GetSchema(string) ([]byte, error)
+
+	// ListSettings returns all settings objects for a given schema.
+	ListSettings(string, ListSettingsOptions) ([]DownloadSettingsObject, error)
+
+	// ListSettingsFlat returns all settings objects for a given schema in a flat unformatted file.
 	ListSettingsFlat(string, ListSettingsOptions) ([]string, error)
 }
 
@@ -114,6 +120,9 @@ const DefaultEntityMinutesTimeframeFrom = DefaultEntityWeeksTimeframeFrom * 7 *
 const DefaultEntityMinutesTimeframeTo = 0
 const DefaultEntityDurationTimeframeTo = DefaultEntityMinutesTimeframeTo * time.Minute
 
+// DefaultEntitiesShardThreshold is the count of entities of a type above which its download is split into timeframe shards
+const DefaultEntitiesShardThreshold = 50000
+
 // ListSettingsOptions are additional options for the ListSettings method
 // of the Settings client
 type ListSettingsOptions struct {
@@ -122,6 +131,32 @@ type ListSettingsOptions struct {
 	DiscardValue bool
 	// ListSettingsFilter can be set to pre-filter the result given a special logic
 	Filter ListSettingsFilter
+	// Scopes restricts the result to settings objects of the given scopes, e.g. environment or HOST-1234.
+	// Entries ending with a dash are scope type prefixes, e.g. HOST_GROUP- matches all host group scopes
+	Scopes []string
+}
+
+// ScopeMatches returns true if the given scope matches any of the exact scopes or scope type prefixes.
+// An empty list of scopes matches every scope
+func ScopeMatches(scope string, scopes []string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+
+	for _, s := range scopes {
+		if isScopePrefix(s) && strings.HasPrefix(scope, s) {
+			return true
+		}
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isScopePrefix(scope string) bool {
+	return strings.HasSuffix(scope, "-")
 }
 
 // ListSettingsFilter can be used to filter fetched settings objects with custom criteria, e.g. o.ExternalId == ""
@@ -142,6 +177,20 @@ type ListEntitiesOptions struct {
 	TimeFromMinutes int
 	TimeToMinutes   int
 	EntityPageSize  int
+	// TimeFrom is the start of the timeframe in unix milliseconds, it replaces TimeFromMinutes when set
+	TimeFrom string
+	// TimeTo is the end of the timeframe in unix milliseconds, it replaces TimeToMinutes when set
+	TimeTo string
+	// EntitySelector is appended to the type clause of the entitySelector, e.g. tag("env:prod")
+	EntitySelector string
+	// Fields are requested in addition to the computed fields, e.g. +tags,+managementZones
+	Fields string
+	// ShardThreshold stops the listing with a ShardingRequiredError when the first page reports a larger totalCount,
+	// so that the timeframe can be split into shards. 0 disables sharding
+	ShardThreshold int
+	// Shard is the 1-based index of the timeframe shard that is listed, 0 if the timeframe is not sharded.
+	// Each shard of a type has its own checkpoint
+	Shard int
 }
 
 type EntitiesClient interface {
@@ -151,6 +200,9 @@ type EntitiesClient interface {
 
 	// ListEntities returns all entities objects for a given type.
 	ListEntities(EntitiesType, ListEntitiesOptions) (EntitiesList, error)
+
+	// RemoveShardPlan removes the persisted shards of an entities type, once all of its shards were listed.
+	RemoveShardPlan(EntitiesType)
 }
 
 //go:generate mockgen -source=client.go -destination=client_mock.go -package=client DynatraceClient
@@ -197,6 +249,9 @@ type DynatraceClient struct {
 	settingsObjectAPIPath string
 
 	retrySettings rest.RetrySettings
+
+	// checkpoints persist the progress of paginated downloads, nil if they are not persisted
+	checkpoints *CheckpointStore
 }
 
 // OauthCredentials holds information for authenticating to Dynatrace
@@ -454,7 +509,8 @@ type SchemaListResponse struct {
 	TotalCount int        `json:"totalCount"`
 }
 type SchemaList []struct {
-	SchemaId string `json:"schemaId"`
+	SchemaId            string `json:"schemaId"`
+	LatestSchemaVersion string `json:"latestSchemaVersion"`
 }
 
 func (d *DynatraceClient) ListSchemas() (SchemaList, error) {
@@ -486,35 +542,104 @@ func (d *DynatraceClient) ListSchemas() (SchemaList, error) {
 	return result.Items, nil
 }
 
+func (d *DynatraceClient) GetSchema(schemaId string) ([]byte, error) {
+	u, err := url.Parse(d.environmentURL + d.settingsSchemaAPIPath + "/" + url.PathEscape(schemaId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	resp, err := rest.Get(d.client, u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET schema %s: %w", schemaId, err)
+	}
+
+	if !success(resp) {
+		return nil, fmt.Errorf("request failed with HTTP (%d).\n\tResponse content: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	return resp.Body, nil
+}
+
+func (d *DynatraceClient) ListSettings(schemaId string, opts ListSettingsOptions) ([]DownloadSettingsObject, error) {
+	log.Debug("Downloading all settings for schema %s", schemaId)
+
+	result := make([]DownloadSettingsObject, 0)
+	receivedCount := 0
+
+	addToResult := func(body []byte) (int, int, error) {
+		var parsed struct {
+			Items []DownloadSettingsObject `json:"items"`
+		}
+
+		if err1 := json.Unmarshal(body, &parsed); err1 != nil {
+			return 0, receivedCount, fmt.Errorf("failed to unmarshal response: %w", err1)
+		}
+
+		receivedCount += len(parsed.Items)
+
+		// eventually apply filter
+		if opts.Filter == nil && len(opts.Scopes) == 0 {
+			result = append(result, parsed.Items...)
+		} else {
+			for _, item := range parsed.Items {
+				if !ScopeMatches(item.Scope, opts.Scopes) {
+					continue
+				}
+				if opts.Filter == nil || opts.Filter(item) {
+					result = append(result, item)
+				}
+			}
+		}
+
+		return len(parsed.Items), receivedCount, nil
+	}
+
+	params := genSettingsParams(opts, schemaId)
+	_, err := d.listPaginated(d.settingsObjectAPIPath, params, schemaId, addToResult)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (d *DynatraceClient) ListSettingsFlat(schemaId string, opts ListSettingsOptions) ([]string, error) {
 	log.Debug("Downloading all settings -flat-dump- for schema %s", schemaId)
 
 	resultStrings := make([]string, 0)
+	receivedCount := 0
 
 	addToResultStrings := func(body []byte) (int, int, error) {
 		var parsedRaw SettingsResponseRaw
 
 		if err1 := json.Unmarshal(body, &parsedRaw); err1 != nil {
-			return 0, len(resultStrings), fmt.Errorf("failed to unmarshal response: %w", err1)
+			return 0, receivedCount, fmt.Errorf("failed to unmarshal response: %w", err1)
 		}
 
-		contentList := make([]string, len(parsedRaw.Settings))
+		receivedCount += len(parsedRaw.Settings)
+
+		contentList := make([]string, 0, len(parsedRaw.Settings))
+
+		for _, settingMap := range parsedRaw.Settings {
+			if scope, ok := settingMap["scope"].(string); ok && !ScopeMatches(scope, opts.Scopes) {
+				continue
+			}
 
-		for idx, settingMap := range parsedRaw.Settings {
 			toSaveData := make(map[string]interface{}, 2)
 			toSaveData[rules.DownloadedKey] = settingMap
 
 			rawJson, err := json.Marshal(toSaveData)
 			if err != nil {
-				return 0, len(resultStrings), err
+				return 0, receivedCount, err
 			}
 
-			contentList[idx] = string(rawJson)
+			contentList = append(contentList, string(rawJson))
 		}
 
 		resultStrings = append(resultStrings, contentList...)
 
-		return len(parsedRaw.Settings), len(resultStrings), nil
+		return len(parsedRaw.Settings), receivedCount, nil
 	}
 
 	params := genSettingsParams(opts, schemaId)
@@ -537,9 +662,29 @@ func genSettingsParams(opts ListSettingsOptions, schemaId string) url.Values {
 		"pageSize":  []string{defaultPageSize},
 		"fields":    []string{listSettingsFields},
 	}
+	if scopes, ok := genScopesParam(opts.Scopes); ok {
+		params.Add("scopes", scopes)
+	}
 	return params
 }
 
+// genScopesParam joins the scopes for the scopes query parameter.
+// The API only filters by exact scopes, so as soon as a scope type prefix is requested, all scopes are fetched
+// and the objects are filtered after download
+func genScopesParam(scopes []string) (string, bool) {
+	if len(scopes) == 0 {
+		return "", false
+	}
+
+	for _, s := range scopes {
+		if isScopePrefix(s) {
+			return "", false
+		}
+	}
+
+	return strings.Join(scopes, ","), true
+}
+
 type EntitiesTypeListResponse struct {
 	Types []EntitiesType `json:"types"`
 }
@@ -609,7 +754,8 @@ func (d *DynatraceClient) ListEntitiesTypes() ([]EntitiesType, *EntitiesList, er
 }
 
 type EntityListResponseRaw struct {
-	Entities []json.RawMessage `json:"entities"`
+	TotalCount int               `json:"totalCount"`
+	Entities   []json.RawMessage `json:"entities"`
 }
 
 type EntitiesList struct {
@@ -627,6 +773,11 @@ func (d *DynatraceClient) ListEntities(entitiesType EntitiesType, opts ListEntit
 	entityType := entitiesType.EntitiesTypeId
 	log.Debug("Downloading all entities for entities Type %s", entityType)
 
+	logLabel := entityType
+	if opts.Shard > 0 {
+		logLabel = fmt.Sprintf("%s_shard%d", entityType, opts.Shard)
+	}
+
 	entityList := EntitiesList{
 		From:     "",
 		To:       "",
@@ -646,6 +797,17 @@ func (d *DynatraceClient) ListEntities(entitiesType EntitiesType, opts ListEntit
 			entitiesContentList[idx] = string(str)
 		}
 
+		if opts.ShardThreshold > 0 && len(entityList.Entities) == 0 && parsedRaw.TotalCount > opts.ShardThreshold {
+			return 0, 0, ShardingRequiredError{
+				EntitiesType: entityType,
+				TotalCount:   parsedRaw.TotalCount,
+				Threshold:    opts.ShardThreshold,
+				From:         entityList.From,
+				To:           entityList.To,
+				Entities:     entitiesContentList,
+			}
+		}
+
 		entityList.Entities = append(entityList.Entities, entitiesContentList...)
 
 		return len(parsedRaw.Entities), len(entityList.Entities), nil
@@ -655,10 +817,24 @@ func (d *DynatraceClient) ListEntities(entitiesType EntitiesType, opts ListEntit
 	var ignoreProperties []string
 
 	for runExtraction {
-		params, from, to := genListEntitiesParams(entityType, entitiesType, opts, ignoreProperties)
-		entityList.From = from
-		entityList.To = to
-		resp, err := d.listPaginated(pathEntitiesObjects, params, entityType, addToResult)
+		params, _, _ := genListEntitiesParams(entityType, entitiesType, opts, ignoreProperties)
+		// an explicit timeframe, e.g. of a shard, is kept, only the timeframe computed from now is taken from a checkpoint
+		if opts.TimeTo == "" {
+			if plan, found := d.checkpoints.loadShardPlan(entityType, params, opts.ShardThreshold); found {
+				log.Info("Resuming the shards of entities Type %s from the timeframe %s-%s of the checkpoint", entityType, plan.Params.Get("from"), plan.Params.Get("to"))
+				return EntitiesList{}, plan.shardingRequiredError(entityType)
+			}
+			params = d.checkpoints.resumeTimeframe(pathEntitiesObjects, logLabel, params)
+		}
+		entityList.From = params.Get("from")
+		entityList.To = params.Get("to")
+		resp, err := d.listPaginated(pathEntitiesObjects, params, logLabel, addToResult)
+
+		var shardingErr ShardingRequiredError
+		if errors.As(err, &shardingErr) {
+			d.checkpoints.saveShardPlan(entityType, params, shardingErr)
+			return EntitiesList{}, shardingErr
+		}
 
 		runExtraction, ignoreProperties, err = handleListEntitiesError(entityType, resp, runExtraction, ignoreProperties, err)
 
@@ -670,6 +846,10 @@ func (d *DynatraceClient) ListEntities(entitiesType EntitiesType, opts ListEntit
 	return entityList, nil
 }
 
+func (d *DynatraceClient) RemoveShardPlan(entitiesType EntitiesType) {
+	d.checkpoints.removeShardPlan(entitiesType.EntitiesTypeId)
+}
+
 func (d *DynatraceClient) listPaginated(urlPath string, params url.Values, logLabel string,
 	addToResult func(body []byte) (int, int, error)) (rest.Response, error) {
 
@@ -686,17 +866,39 @@ func (d *DynatraceClient) listPaginated(urlPath string, params url.Values, logLa
 		}
 	}
 
-	resp, receivedCount, totalReceivedCount, _, err = d.runAndProcessResponse(false, u, addToResult, receivedCount, totalReceivedCount, urlPath)
-	if err != nil {
-		return resp, RespError{
-			Err:        err,
-			StatusCode: resp.StatusCode,
+	cp, resumed := d.checkpoints.load(urlPath, logLabel, params)
+	if resumed {
+		resp, receivedCount, totalReceivedCount, err = d.resumeFromCheckpoint(u, cp, urlPath, logLabel, addToResult)
+		if errors.Is(err, errCheckpointRestart) {
+			log.Warn("Restarting the download of %s: %v", logLabel, err)
+			resumed = false
+		} else if err != nil {
+			return resp, RespError{
+				Err:        err,
+				StatusCode: resp.StatusCode,
+			}
+		}
+	}
+
+	if !resumed {
+		resp, receivedCount, totalReceivedCount, _, err = d.runAndProcessResponse(false, u, addToResult, receivedCount, totalReceivedCount, urlPath)
+		if err != nil {
+			return resp, RespError{
+				Err:        err,
+				StatusCode: resp.StatusCode,
+			}
+		}
+		cp = checkpoint{
+			UrlPath:            urlPath,
+			Params:             params,
+			ExpectedTotalCount: resp.TotalCount,
 		}
 	}
+	d.checkpoints.savePage(urlPath, logLabel, &cp, resp, totalReceivedCount)
 
-	callCount := 1
+	callCount := cp.Pages + 1
 	lastLogTime := time.Now()
-	expectedTotalCount := resp.TotalCount
+	expectedTotalCount := cp.ExpectedTotalCount
 	nextPageKey := resp.NextPageKey
 	emptyResponseRetryCount := 0
 
@@ -733,6 +935,7 @@ func (d *DynatraceClient) listPaginated(urlPath string, params url.Values, logLa
 			} else {
 				validateWrongCountExtracted(resp, totalReceivedCount, expectedTotalCount, urlPath, logLabel, nextPageKey, params)
 
+				d.checkpoints.savePage(urlPath, logLabel, &cp, resp, totalReceivedCount)
 				nextPageKey = resp.NextPageKey
 				callCount++
 				emptyResponseRetryCount = 0
@@ -744,10 +947,50 @@ func (d *DynatraceClient) listPaginated(urlPath string, params url.Values, logLa
 		}
 	}
 
+	d.checkpoints.remove(urlPath, logLabel)
+
 	return resp, nil
 
 }
 
+// resumeFromCheckpoint requests the next page of the checkpoint, and adds the pages of the spool file to the result before it.
+// errCheckpointRestart is returned if the spool file can not be read or the page key has expired.
+func (d *DynatraceClient) resumeFromCheckpoint(u *url.URL, cp checkpoint, urlPath string, logLabel string,
+	addToResult func(body []byte) (int, int, error)) (rest.Response, int, int, error) {
+
+	pages, err := d.checkpoints.readPages(urlPath, logLabel, cp)
+	if err != nil {
+		return rest.Response{}, 0, 0, fmt.Errorf("%w: %v", errCheckpointRestart, err)
+	}
+
+	// the first page is requested again if the download restarts, so u is left unchanged
+	nextPageUrl := *u
+	resp, err := rest.GetWithRetry(d.client, rest.AddNextPageQueryParams(&nextPageUrl, cp.NextPageKey).String(), d.retrySettings.Normal)
+	if resp.StatusCode == http.StatusBadRequest {
+		return resp, 0, 0, fmt.Errorf("%w: the page key has expired (HTTP %d)", errCheckpointRestart, resp.StatusCode)
+	}
+	_, err = validateRespErrors(true, err, resp, urlPath)
+	if err != nil {
+		return resp, 0, 0, err
+	}
+
+	for _, page := range pages {
+		_, _, err = addToResult(page)
+		if err != nil {
+			return resp, 0, 0, fmt.Errorf("failed to read the spool file of %s: %w", logLabel, err)
+		}
+	}
+
+	receivedCount, totalReceivedCount, err := addToResult(resp.Body)
+	if err != nil {
+		return resp, 0, 0, err
+	}
+
+	log.Info("Resumed the download of %s after %d pages and %d items", logLabel, cp.Pages, cp.ReceivedCount)
+
+	return resp, receivedCount, totalReceivedCount, nil
+}
+
 const emptyResponseRetryMax = 10
 
 func isRetryOnEmptyResponse(receivedCount int, emptyResponseRetryCount int, resp rest.Response) (bool, int, error) {
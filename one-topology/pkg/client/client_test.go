@@ -566,6 +566,54 @@ func TestListKnownSettings(t *testing.T) {
 	}
 }
 
+func TestGenSettingsParamsScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantScopes string
+		wantFound  bool
+	}{
+		{"no scopes", nil, "", false},
+		{"exact scopes", []string{"environment", "HOST-1234"}, "environment,HOST-1234", true},
+		{"scope type prefix", []string{"environment", "HOST_GROUP-"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := genSettingsParams(ListSettingsOptions{Scopes: tt.scopes}, "builtin:alerting.profile")
+			assert.Equal(t, tt.wantFound, params.Has("scopes"))
+			assert.Equal(t, tt.wantScopes, params.Get("scopes"))
+			assert.Equal(t, "builtin:alerting.profile", params.Get("schemaIds"))
+		})
+	}
+}
+
+func TestScopeMatches(t *testing.T) {
+	assert.True(t, ScopeMatches("HOST-1234", nil))
+	assert.True(t, ScopeMatches("environment", []string{"environment"}))
+	assert.True(t, ScopeMatches("HOST_GROUP-1234", []string{"environment", "HOST_GROUP-"}))
+	assert.False(t, ScopeMatches("HOST-1234", []string{"environment", "HOST_GROUP-"}))
+	assert.False(t, ScopeMatches("HOST-12345", []string{"HOST-1234"}))
+}
+
+func TestListSettingsFlatFiltersScopePrefixes(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.False(t, req.URL.Query().Has("scopes"))
+		rw.Write([]byte(`{"totalCount": 3, "items": [{"objectId": "1", "scope": "environment"}, {"objectId": "2", "scope": "HOST_GROUP-1234"}, {"objectId": "3", "scope": "HOST-1234"}]}`))
+	}))
+	defer server.Close()
+
+	client := DynatraceClient{
+		environmentURL:        server.URL,
+		client:                server.Client(),
+		retrySettings:         testRetrySettings,
+		settingsObjectAPIPath: settingsObjectAPIPathClassic,
+	}
+
+	res, err := client.ListSettingsFlat("builtin:alerting.profile", ListSettingsOptions{Scopes: []string{"environment", "HOST_GROUP-"}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+}
+
 func TestGetSettingById(t *testing.T) {
 	type fields struct {
 		environmentURL string
@@ -252,6 +252,10 @@ func (c *DummyClient) ListSchemas() (SchemaList, error) {
 	return make(SchemaList, 0), nil
 }
 
+func (c *DummyClient) GetSchema(_ string) ([]byte, error) {
+	return []byte("{}"), nil
+}
+
 func (c *DummyClient) GetSettingById(_ string) (*DownloadSettingsObject, error) {
 	return &DownloadSettingsObject{}, nil
 }
@@ -275,3 +279,5 @@ func (c *DummyClient) ListEntitiesTypes() ([]EntitiesType, *EntitiesList, error)
 func (c *DummyClient) ListEntities(_ EntitiesType, _ ListEntitiesOptions) (EntitiesList, error) {
 	return EntitiesList{}, nil
 }
+
+func (c *DummyClient) RemoveShardPlan(_ EntitiesType) {}
@@ -20,6 +20,7 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"strings"
 	"time"
 	"unicode"
 
@@ -76,6 +77,84 @@ func addFields(extraEntitiesFields map[string][]string, ignoreProperties []strin
 	return typeFields
 }
 
+// addExtraFields appends the requested fields that are not already part of the computed fields
+func addExtraFields(typeFields string, extraFields string, ignoreProperties []string) string {
+	computedFields := SplitFields(typeFields)
+
+	for _, field := range SplitFields(extraFields) {
+		if contains(computedFields, field) {
+			continue
+		}
+
+		name := strings.TrimPrefix(field, "+")
+		if contains(ignoreProperties, name) || contains(ignoreProperties, name[strings.LastIndex(name, ".")+1:]) {
+			continue
+		}
+
+		typeFields = typeFields + "," + field
+		computedFields = append(computedFields, field)
+	}
+
+	return typeFields
+}
+
+// SplitFields splits a comma separated fields list, adding the leading + where it is missing
+func SplitFields(fields string) []string {
+	fieldList := []string{}
+
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.HasPrefix(field, "+") {
+			field = "+" + field
+		}
+		fieldList = append(fieldList, field)
+	}
+
+	return fieldList
+}
+
+// topLevelEntityFields are the optional fields of an entity that are not described by the entities type
+var topLevelEntityFields = []string{"lastSeenTms", "firstSeenTms", "tags", "managementZones", "toRelationships", "fromRelationships", "icon", "properties"}
+
+// l2EntityFields are the fields whose sub fields are described by the entities type
+var l2EntityFields = []string{"toRelationships", "fromRelationships", "properties"}
+
+// InvalidEntitiesTypeFields returns the fields that can not be requested for the entities type.
+// Top level fields must be known entity fields, and sub fields of toRelationships, fromRelationships and properties
+// must be described by the entities type
+func InvalidEntitiesTypeFields(entitiesType EntitiesType, fields string) []string {
+	invalidFields := []string{}
+
+	for _, field := range SplitFields(fields) {
+		name := strings.TrimPrefix(field, "+")
+		topField, subField, isL2 := strings.Cut(name, ".")
+
+		if !contains(topLevelEntityFields, topField) {
+			invalidFields = append(invalidFields, field)
+			continue
+		}
+
+		if !isL2 {
+			continue
+		}
+
+		if !contains(l2EntityFields, topField) {
+			invalidFields = append(invalidFields, field)
+			continue
+		}
+
+		_, exists := L2FieldWithIdExists(entitiesType, topField, subField)
+		if !exists {
+			invalidFields = append(invalidFields, field)
+		}
+	}
+
+	return invalidFields
+}
+
 func L2FieldWithIdExists(entitiesType EntitiesType, topField string, subField string) (reflect.Value, bool) {
 
 	fieldSliceObject := GetDynamicFieldFromObject(entitiesType, topField)
@@ -168,17 +247,28 @@ func IsInvalidReflectionValue(value reflect.Value) bool {
 
 func genListEntitiesParams(entityType string, entitiesType EntitiesType, opts ListEntitiesOptions, ignoreProperties []string) (url.Values, string, string) {
 	from := genTimeframeUnixMilliString(-1 * time.Duration(opts.TimeFromMinutes) * time.Minute)
+	if opts.TimeFrom != "" {
+		from = opts.TimeFrom
+	}
 	to := genTimeframeUnixMilliString(-1 * time.Duration(opts.TimeToMinutes) * time.Minute)
+	if opts.TimeTo != "" {
+		to = opts.TimeTo
+	}
 
 	pageSize := DefaultPageSizeEntities
 	if opts.EntityPageSize > 0 {
 		pageSize = fmt.Sprintf("%d", opts.EntityPageSize)
 	}
 
+	entitySelector := "type(\"" + entityType + "\")"
+	if opts.EntitySelector != "" {
+		entitySelector = entitySelector + "," + opts.EntitySelector
+	}
+
 	params := url.Values{
-		"entitySelector": []string{"type(\"" + entityType + "\")"},
+		"entitySelector": []string{entitySelector},
 		"pageSize":       []string{pageSize},
-		"fields":         []string{getEntitiesTypeFields(entitiesType, ignoreProperties)},
+		"fields":         []string{addExtraFields(getEntitiesTypeFields(entitiesType, ignoreProperties), opts.Fields, ignoreProperties)},
 		"from":           []string{from},
 		"to":             []string{to},
 	}
@@ -77,3 +77,74 @@ func Test_fields(t *testing.T) {
 		})
 	}
 }
+
+func Test_genListEntitiesParamsSelection(t *testing.T) {
+	entitiesTypeJSON := `{
+		"type": "HOST",
+		"properties": [
+			{"id": "osType", "type": "String", "displayName": "osType"}
+		],
+		"toRelationships": [
+			{"id": "isProcessOf", "fromTypes": ["PROCESS_GROUP_INSTANCE"]}
+		]
+	}`
+
+	tests := []struct {
+		name               string
+		opts               ListEntitiesOptions
+		ignoreProperties   []string
+		wantEntitySelector string
+		wantFields         string
+	}{
+		{
+			"Defaults",
+			ListEntitiesOptions{},
+			[]string{},
+			`type("HOST")`,
+			"+lastSeenTms,+firstSeenTms",
+		},
+		{
+			"Entity selector and fields",
+			ListEntitiesOptions{EntitySelector: `tag("env:prod")`, Fields: "+tags, managementZones,+lastSeenTms"},
+			[]string{},
+			`type("HOST"),tag("env:prod")`,
+			"+lastSeenTms,+firstSeenTms,+tags,+managementZones",
+		},
+		{
+			"Ignored property",
+			ListEntitiesOptions{Fields: "+tags,+properties.osType"},
+			[]string{"osType"},
+			`type("HOST")`,
+			"+lastSeenTms,+firstSeenTms,+tags",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			entitiesType := EntitiesType{}
+			json.Unmarshal([]byte(entitiesTypeJSON), &entitiesType)
+			params, _, _ := genListEntitiesParams("HOST", entitiesType, tt.opts, tt.ignoreProperties)
+			assert.Equal(t, params.Get("entitySelector"), tt.wantEntitySelector)
+			assert.Equal(t, params.Get("fields"), tt.wantFields)
+		})
+	}
+}
+
+func Test_InvalidEntitiesTypeFields(t *testing.T) {
+	entitiesTypeJSON := `{
+		"type": "HOST",
+		"properties": [
+			{"id": "osType", "type": "String", "displayName": "osType"}
+		],
+		"toRelationships": [
+			{"id": "isProcessOf", "fromTypes": ["PROCESS_GROUP_INSTANCE"]}
+		]
+	}`
+
+	entitiesType := EntitiesType{}
+	json.Unmarshal([]byte(entitiesTypeJSON), &entitiesType)
+
+	assert.DeepEqual(t, InvalidEntitiesTypeFields(entitiesType, "+tags,+managementZones,+properties.osType,toRelationships.isProcessOf"), []string{})
+	assert.DeepEqual(t, InvalidEntitiesTypeFields(entitiesType, "+tags.key,+unknown,+properties.unknown,+fromRelationships.isProcessOf"),
+		[]string{"+tags.key", "+unknown", "+properties.unknown", "+fromRelationships.isProcessOf"})
+}
@@ -44,3 +44,21 @@ func (e RespError) ConcurrentError() string {
 
 	return e.Error()
 }
+
+// ShardingRequiredError is returned when listing the entities of a type whose first page reports more entities
+// than the shard threshold of the options
+type ShardingRequiredError struct {
+	EntitiesType string
+	TotalCount   int
+	Threshold    int
+	// From and To are the timeframe of the listing, in unix milliseconds
+	From string
+	To   string
+	// Entities are the entities of the first page, they are listed again by the shards of the timeframe.
+	// They are empty if the timeframe was taken from a checkpoint without listing the first page
+	Entities []string
+}
+
+func (e ShardingRequiredError) Error() string {
+	return fmt.Sprintf("entities Type %s holds %d entities, more than the shard threshold of %d", e.EntitiesType, e.TotalCount, e.Threshold)
+}
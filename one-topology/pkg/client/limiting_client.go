@@ -65,6 +65,22 @@ func (l limitingClient) ListSchemas() (s SchemaList, err error) {
 	return
 }
 
+func (l limitingClient) GetSchema(schemaId string) (b []byte, err error) {
+	l.limiter.ExecuteBlocking(func() {
+		b, err = l.client.GetSchema(schemaId)
+	})
+
+	return
+}
+
+func (l limitingClient) ListSettings(schemaId string, opts ListSettingsOptions) (o []DownloadSettingsObject, err error) {
+	l.limiter.ExecuteBlocking(func() {
+		o, err = l.client.ListSettings(schemaId, opts)
+	})
+
+	return
+}
+
 func (l limitingClient) ListSettingsFlat(schemaId string, opts ListSettingsOptions) (o []string, err error) {
 	l.limiter.ExecuteBlocking(func() {
 		o, err = l.client.ListSettingsFlat(schemaId, opts)
@@ -87,3 +103,7 @@ func (l limitingClient) ListEntities(entitiesType EntitiesType, opts ListEntitie
 
 	return
 }
+
+func (l limitingClient) RemoveShardPlan(entitiesType EntitiesType) {
+	l.client.RemoveShardPlan(entitiesType)
+}
@@ -0,0 +1,74 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSettingsPaginates(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v2/settings/objects", req.URL.Path)
+		nextPageKey := req.URL.Query().Get("nextPageKey")
+		calls = append(calls, nextPageKey)
+
+		switch nextPageKey {
+		case "":
+			assert.Equal(t, "builtin:alerting.profile", req.URL.Query().Get("schemaIds"))
+			_, _ = rw.Write([]byte(`{"totalCount": 3, "nextPageKey": "page2", "items": [{"objectId": "oid1", "schemaId": "builtin:alerting.profile", "value": {"name": "one"}}]}`))
+		case "page2":
+			assert.False(t, req.URL.Query().Has("schemaIds"))
+			_, _ = rw.Write([]byte(`{"totalCount": 3, "items": [{"objectId": "oid2", "schemaId": "builtin:alerting.profile", "value": {"name": "two"}}, {"objectId": "oid3", "schemaId": "builtin:alerting.profile", "value": {"name": "three"}}]}`))
+		default:
+			http.Error(rw, "{}", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := DynatraceClient{
+		environmentURL:        server.URL,
+		client:                server.Client(),
+		retrySettings:         testRetrySettings,
+		settingsObjectAPIPath: settingsObjectAPIPathClassic,
+	}
+
+	objects, err := client.ListSettings("builtin:alerting.profile", ListSettingsOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "page2"}, calls)
+	assert.Len(t, objects, 3)
+	for i, id := range []string{"oid1", "oid2", "oid3"} {
+		assert.Equal(t, id, objects[i].ObjectId)
+	}
+	assert.JSONEq(t, `{"name": "two"}`, string(objects[1].Value))
+
+	calls = nil
+	objects, err = client.ListSettings("builtin:alerting.profile", ListSettingsOptions{Filter: func(o DownloadSettingsObject) bool {
+		var v struct{ Name string }
+		_ = json.Unmarshal(o.Value, &v)
+		return v.Name != "two"
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "page2"}, calls)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "oid3", objects[1].ObjectId)
+}
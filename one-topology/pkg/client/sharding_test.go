@@ -0,0 +1,115 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTotalCountServer(t *testing.T, totalCount int, queries *[]string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		*queries = append(*queries, req.URL.Query().Get("from")+"-"+req.URL.Query().Get("to"))
+		_, _ = rw.Write([]byte(fmt.Sprintf(`{"totalCount": %d, "entities": [{"entityId": "HOST-1"}]}`, totalCount)))
+	}))
+}
+
+func TestListEntities_ShardingRequired(t *testing.T) {
+	var queries []string
+	server := newTotalCountServer(t, 10, &queries)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, nil)
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{TimeFrom: "1000", TimeTo: "2000", ShardThreshold: 5})
+
+	var shardingErr ShardingRequiredError
+	assert.True(t, errors.As(err, &shardingErr))
+	assert.Equal(t, ShardingRequiredError{EntitiesType: "HOST", TotalCount: 10, Threshold: 5, From: "1000", To: "2000", Entities: []string{`{"entityId": "HOST-1"}`}}, shardingErr)
+	assert.Equal(t, []string{"1000-2000"}, queries)
+}
+
+func TestListEntities_BelowShardThreshold(t *testing.T) {
+	var queries []string
+	server := newTotalCountServer(t, 10, &queries)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, nil)
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{TimeFrom: "1000", TimeTo: "2000", ShardThreshold: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"entityId": "HOST-1"}`}, entityList.Entities)
+	assert.Equal(t, "1000", entityList.From)
+	assert.Equal(t, "2000", entityList.To)
+}
+
+func TestListEntities_ResumeShardPlan(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var queries []string
+	server := newTotalCountServer(t, 10, &queries)
+	defer server.Close()
+
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{TimeFromMinutes: 60, ShardThreshold: 5})
+	var planned ShardingRequiredError
+	assert.True(t, errors.As(err, &planned))
+	assert.Len(t, queries, 1)
+
+	client = newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", true))
+	_, err = client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{TimeFromMinutes: 60, ShardThreshold: 5})
+	var resumed ShardingRequiredError
+	assert.True(t, errors.As(err, &resumed))
+	assert.Len(t, queries, 1, "the timeframe of the shards is taken from the checkpoint")
+	assert.Equal(t, ShardingRequiredError{EntitiesType: "HOST", TotalCount: 10, Threshold: 5, From: planned.From, To: planned.To}, resumed)
+
+	_, err = client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, ListEntitiesOptions{TimeFromMinutes: 60, ShardThreshold: 8})
+	assert.True(t, errors.As(err, &resumed))
+	assert.Len(t, queries, 2, "shards of another threshold are not resumed")
+
+	client.RemoveShardPlan(EntitiesType{EntitiesTypeId: "HOST"})
+	pending, err := client.checkpoints.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending, "the shards are removed once all of them were listed")
+}
+
+func TestListEntities_ShardCheckpointKeyedByIndex(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pages := pagesServer{generation: 1, pageThreeStatus: http.StatusNotFound}
+	server := newPagesServer(t, &pages)
+	defer server.Close()
+
+	opts := ListEntitiesOptions{TimeFrom: "1000", TimeTo: "2000", Shard: 2}
+	client := newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", false))
+	_, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, opts)
+	assert.Error(t, err)
+
+	cp, found := client.checkpoints.read(pathEntitiesObjects, "HOST_shard2")
+	assert.True(t, found)
+	assert.Equal(t, "1000", cp.Params.Get("from"))
+
+	pages.pageThreeStatus = http.StatusOK
+	pages.calls = []string{}
+	client = newCheckpointTestClient(server, NewCheckpointStore(fs, "checkpoints", true))
+	entityList, err := client.ListEntities(EntitiesType{EntitiesTypeId: "HOST"}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"page3-1"}, pages.calls)
+	assert.Len(t, entityList.Entities, 3)
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/json"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
@@ -48,10 +49,15 @@ const (
 
 	// SkipParameter is special in that config should be deployed or not
 	SkipParameter = "skip"
+
+	// DownloadScopesParameter holds the scopes a settings-config was downloaded for.
+	// It is only set iff the download was restricted to some scopes, i.e. the config is a partial download.
+	// It is persisted with the settings type, a user must not set it as a parameter in the config.
+	DownloadScopesParameter = "downloadScopes"
 )
 
 // ReservedParameterNames holds all parameter names that may not be specified by a user in a config.
-var ReservedParameterNames = []string{IdParameter, NameParameter, ScopeParameter, SkipParameter}
+var ReservedParameterNames = []string{IdParameter, NameParameter, ScopeParameter, SkipParameter, DownloadScopesParameter}
 
 // Parameters defines a map of name to parameter
 type Parameters map[string]parameter.Parameter
@@ -141,6 +147,12 @@ func (c *Config) LoadPaddedTemplateBytes(prefix []byte, suffix []byte) ([]byte,
 	}
 	defer file.Close()
 
+	header := make([]byte, compression.MagicLength)
+	headerLength, _ := file.ReadAt(header, 0)
+	if format := compression.Detect(header[:headerLength]); format != compression.None {
+		return loadCompressedTemplateBytes(file, format, prefix, suffix)
+	}
+
 	fileInfo, err := file.Stat()
 	if err != nil {
 		fmt.Println("Error getting file info:", err)
@@ -185,6 +197,21 @@ func (c *Config) LoadPaddedTemplateBytes(prefix []byte, suffix []byte) ([]byte,
 	return templateBytes, err
 }
 
+// loadCompressedTemplateBytes decompresses a template written by a compressed download and pads it
+func loadCompressedTemplateBytes(file io.Reader, format compression.Format, prefix []byte, suffix []byte) ([]byte, error) {
+	content, err := compression.DecompressReader(format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	templateBytes := make([]byte, 0, len(prefix)+len(content)+len(suffix))
+	templateBytes = append(templateBytes, prefix...)
+	templateBytes = append(templateBytes, content...)
+	templateBytes = append(templateBytes, suffix...)
+
+	return templateBytes, nil
+}
+
 func (c *Config) Render(properties map[string]interface{}) (string, error) {
 	renderedConfig, err := template.Render(c.Template, properties)
 	if err != nil {
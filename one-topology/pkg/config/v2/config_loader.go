@@ -399,6 +399,10 @@ func getConfigFromDefinition(
 		}
 
 		parameters[ScopeParameter] = scopeParam
+
+		if len(configType.Settings.DownloadScopes) > 0 {
+			parameters[DownloadScopesParameter] = &valueParam.ValueParameter{Value: configType.Settings.DownloadScopes}
+		}
 	}
 
 	t, err := getType(configType)
@@ -347,6 +347,63 @@ configs:
 			},
 			nil,
 		},
+		{
+			"loads settings 2.0 config with download scopes",
+			"test-file.yaml",
+			"test-file.yaml",
+			`
+configs:
+- id: profile-id
+  config:
+    name: 'Star Trek > Star Wars'
+    template: 'profile.json'
+  type:
+    settings:
+      schema: 'builtin:profile.test'
+      scope: 'HOST-1'
+      downloadScopes: ['HOST-1', 'HOST_GROUP-']`,
+			[]Config{
+				{
+					TemplatePath: "profile.json",
+					Coordinate: coordinate.Coordinate{
+						Project:  "project",
+						Type:     "builtin:profile.test",
+						ConfigId: "profile-id",
+					},
+					Type: SettingsType{
+						SchemaId: "builtin:profile.test",
+					},
+					Parameters: Parameters{
+						"name":                  &value.ValueParameter{Value: "Star Trek > Star Wars"},
+						ScopeParameter:          &value.ValueParameter{Value: "HOST-1"},
+						DownloadScopesParameter: &value.ValueParameter{Value: []string{"HOST-1", "HOST_GROUP-"}},
+					},
+					Skip:        false,
+					Environment: "env name",
+					Group:       "default",
+				},
+			},
+			nil,
+		},
+		{
+			"reports error for download scopes set as parameter",
+			"test-file.yaml",
+			"test-file.yaml",
+			`
+configs:
+- id: profile-id
+  config:
+    name: 'Star Trek > Star Wars'
+    template: 'profile.json'
+    parameters:
+      downloadScopes: 'HOST-1'
+  type:
+    settings:
+      schema: 'builtin:profile.test'
+      scope: 'HOST-1'`,
+			nil,
+			[]string{"parameter name `downloadScopes` is not allowed (reserved)"},
+		},
 		{
 			"loads settings 2.0 config with full value parameter as scope",
 			"test-file.yaml",
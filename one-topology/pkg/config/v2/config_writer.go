@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"reflect"
 
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
@@ -33,6 +34,8 @@ type WriterContext struct {
 	OutputFolder    string
 	ProjectFolder   string
 	ParametersSerde map[string]parameter.ParameterSerDe
+	// Compression of the newly written templates. Templates loaded from files keep their path and are written as is
+	Compression compression.Format
 }
 
 type serializerContext struct {
@@ -62,6 +65,9 @@ type configTemplate struct {
 
 	// content of the template
 	content string
+
+	// compression the template is written with
+	compression compression.Format
 }
 
 func WriteConfigs(context *WriterContext, configs []Config) []error {
@@ -102,7 +108,13 @@ func writeTemplates(context *WriterContext, templates []configTemplate) (errors
 			continue
 		}
 
-		err = afero.WriteFile(context.Fs, fullTemplatePath, []byte(t.content), 0664)
+		content, err := compression.Compress(t.compression, []byte(t.content))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to write template %s: %w", fullTemplatePath, err))
+			continue
+		}
+
+		err = afero.WriteFile(context.Fs, fullTemplatePath, content, 0664)
 
 		if err != nil {
 			errors = append(errors, err)
@@ -272,9 +284,10 @@ func extractConfigType(context *serializerContext, config Config) (typeDefinitio
 
 		return typeDefinition{
 			Settings: settingsDefinition{
-				Schema:        t.SchemaId,
-				SchemaVersion: t.SchemaVersion,
-				Scope:         serializedScope,
+				Schema:         t.SchemaId,
+				SchemaVersion:  t.SchemaVersion,
+				Scope:          serializedScope,
+				DownloadScopes: getDownloadScopes(config),
 			},
 		}, nil
 
@@ -308,6 +321,27 @@ func getScope(context *serializerContext, config Config) (configParameter, error
 	return serializedScope, nil
 }
 
+// getDownloadScopes returns the scopes the settings-config was downloaded for, they are written with its type
+func getDownloadScopes(config Config) []string {
+	valueParameter, ok := config.Parameters[DownloadScopesParameter].(*value.ValueParameter)
+	if !ok {
+		return nil
+	}
+
+	switch scopes := valueParameter.Value.(type) {
+	case []string:
+		return scopes
+	case []interface{}:
+		result := make([]string, len(scopes))
+		for i, scope := range scopes {
+			result[i] = fmt.Sprint(scope)
+		}
+		return result
+	}
+
+	return nil
+}
+
 func groupByGroups(configs []extendedConfigDefinition) map[string][]extendedConfigDefinition {
 
 	result := make(map[string][]extendedConfigDefinition)
@@ -600,11 +634,12 @@ func extractTemplate(context *detailedSerializerContext, config Config) (string,
 			content:      templ.Content(),
 		}, nil
 	case template.Template:
-		sanitizedName := Sanitize(templ.Id()) + ".json"
+		sanitizedName := Sanitize(templ.Id()) + ".json" + context.Compression.Extension()
 
 		return sanitizedName, configTemplate{
 			templatePath: filepath.Join(context.configFolder, sanitizedName),
 			content:      templ.Content(),
+			compression:  context.Compression,
 		}, nil
 	}
 
@@ -617,8 +652,8 @@ func convertParameters(context *detailedSerializerContext, parameters Parameters
 	result := make(map[string]configParameter)
 
 	for name, param := range parameters {
-		// ignore NameParameter and ScopeParameter as it is handled in a special way
-		if name == NameParameter || name == ScopeParameter {
+		// ignore NameParameter, ScopeParameter and DownloadScopesParameter as they are handled in a special way
+		if name == NameParameter || name == ScopeParameter || name == DownloadScopesParameter {
 			continue
 		}
 
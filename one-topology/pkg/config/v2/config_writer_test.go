@@ -17,6 +17,7 @@
 package v2
 
 import (
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/testutils"
@@ -954,3 +955,53 @@ func TestWriteConfigs(t *testing.T) {
 	}
 
 }
+
+func TestWriteConfigsCompressedTemplatesAreLoadedTransparently(t *testing.T) {
+	content := `[{"entityId": "HOST-1234"}]`
+
+	for _, format := range []compression.Format{compression.None, compression.Gzip, compression.Zstd} {
+		t.Run(string(format), func(t *testing.T) {
+			tempDir := t.TempDir()
+			fs := afero.NewBasePathFs(afero.NewOsFs(), tempDir)
+
+			errs := WriteConfigs(&WriterContext{
+				Fs:              fs,
+				OutputFolder:    "test",
+				ProjectFolder:   "project",
+				ParametersSerde: DefaultParameterParsers,
+				Compression:     format,
+			}, []Config{
+				{
+					Template: template.NewDownloadTemplate("HOST", "HOST", content),
+					Coordinate: coordinate.Coordinate{
+						Project:  "project",
+						Type:     "HOST",
+						ConfigId: "configId",
+					},
+					Type: EntityType{
+						EntitiesType: "HOST",
+					},
+					Parameters: map[string]parameter.Parameter{
+						NameParameter: &value.ValueParameter{Value: "name"},
+					},
+				},
+			})
+			assert.Equal(t, len(errs), 0, "Writing configs should not produce an error")
+
+			templatePath := filepath.Join(tempDir, "test", "project", "HOST", "HOST.json"+format.Extension())
+			raw, err := afero.ReadFile(afero.NewOsFs(), templatePath)
+			assert.NilError(t, err)
+			assert.Equal(t, compression.Detect(raw), format)
+
+			c := Config{TemplatePath: templatePath}
+
+			templateBytes, err := c.LoadTemplateBytes()
+			assert.NilError(t, err)
+			assert.Equal(t, string(templateBytes), content)
+
+			templateBytes, err = c.LoadPaddedTemplateBytes([]byte(`{"list":`), []byte(`}`))
+			assert.NilError(t, err)
+			assert.Equal(t, string(templateBytes), `{"list":`+content+`}`)
+		})
+	}
+}
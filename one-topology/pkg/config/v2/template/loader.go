@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 
 	"github.com/spf13/afero"
@@ -115,6 +116,11 @@ func LoadTemplate(fs afero.Fs, path string) (Template, error) {
 		return nil, fmt.Errorf("failed to load template: %w", err)
 	}
 
+	data, err = compression.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
 	content := string(data)
 
 	template := fileBasedTemplate{
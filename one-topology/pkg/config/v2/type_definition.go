@@ -31,6 +31,8 @@ type settingsDefinition struct {
 	Schema        string          `yaml:"schema,omitempty"`
 	SchemaVersion string          `yaml:"schemaVersion,omitempty"`
 	Scope         configParameter `yaml:"scope,omitempty"`
+	// DownloadScopes are the scopes the download of the config was restricted to
+	DownloadScopes []string `yaml:"downloadScopes,omitempty"`
 }
 
 type entitiesDefinition struct {
@@ -107,7 +109,7 @@ func (c *typeDefinition) isSound(knownApis map[string]struct{}) (bool, error) {
 
 // isSettings returns true iff one of fields from typeDefinition are filed up
 func (c *typeDefinition) isSettings() bool {
-	return c.Settings != settingsDefinition{}
+	return c.Settings.Schema != "" || c.Settings.SchemaVersion != "" || c.Settings.Scope != nil || len(c.Settings.DownloadScopes) > 0
 }
 func (t *settingsDefinition) isSettingsSound() (bool, error) {
 	var s []string
@@ -41,7 +41,9 @@ var apiSanitizeFunctions = map[string]func(properties map[string]interface{}) ma
 	},
 }
 
-func sanitizeProperties(properties map[string]interface{}, apiId string) map[string]interface{} {
+// SanitizeProperties removes the properties that identify a config or can't be uploaded,
+// and replaces the name by the name parameter of the template
+func SanitizeProperties(properties map[string]interface{}, apiId string) map[string]interface{} {
 	properties = removeIdentifyingProperties(properties)
 	properties = removePropertiesNotAllowedOnUpload(properties, apiId)
 	return replaceTemplateProperties(properties)
@@ -199,7 +199,7 @@ func TestRemoveIdentifiers(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result := sanitizeProperties(unmarshal(t, test.json), test.api)
+			result := SanitizeProperties(unmarshal(t, test.json), test.api)
 
 			expected := unmarshal(t, test.expectedJson)
 
@@ -33,7 +33,9 @@ import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
 	valueParam "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/template"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
 )
@@ -54,6 +56,10 @@ type Downloader struct {
 	// client is the actual rest client used to call
 	// the dynatrace APIs
 	client client.Client
+
+	// redactor replaces the secrets of the downloaded configs by references to environment variable parameters.
+	// Secrets are downloaded as is if no redactor is set
+	redactor *secrets.Redactor
 }
 
 // WithAPIFilters sets the api filters for the Downloader
@@ -63,6 +69,20 @@ func WithAPIFilters(apiFilters map[string]apiFilter) func(*Downloader) {
 	}
 }
 
+// WithDiscardConditions adds user defined discard conditions to the api filters of the Downloader
+func WithDiscardConditions(file discard.File) func(*Downloader) {
+	return func(d *Downloader) {
+		d.apiFilters = mergeAPIFilters(d.apiFilters, file)
+	}
+}
+
+// WithRedactor sets the redactor used to replace secrets in the downloaded configs
+func WithRedactor(redactor *secrets.Redactor) func(*Downloader) {
+	return func(d *Downloader) {
+		d.redactor = redactor
+	}
+}
+
 // NewDownloader creates a new Downloader
 func NewDownloader(client client.Client, opts ...func(*Downloader)) *Downloader {
 	c := &Downloader{
@@ -169,6 +189,8 @@ func (d *Downloader) downloadConfigsOfAPI(api api.API, values []client.Value, pr
 func (d *Downloader) downloadConfigsOfAPIFlat(theApi api.API, values []client.Value, projectName string) []config.Config {
 	resultsString := make([]string, 0, len(values))
 	resultsString2 := make([]string, 0, len(values))
+	secretParams := map[string]parameter.Parameter{}
+	secretParams2 := map[string]parameter.Parameter{}
 	hasResult2 := false
 	mutex := sync.Mutex{}
 	wg := sync.WaitGroup{}
@@ -179,11 +201,15 @@ func (d *Downloader) downloadConfigsOfAPIFlat(theApi api.API, values []client.Va
 		go func() {
 			defer wg.Done()
 			log.Info("Downloading API: %s, Key: %s", theApi.ID, value.Id)
-			downloadedJsonString, err := d.downloadConfigFlat(theApi, value)
+			downloadedJsonString, params, err := d.downloadConfigFlat(theApi, value)
 			if err != nil {
 				log.Error("Error fetching config '%v' in api '%v': %v", value.Id, theApi.ID, err)
 				return
 			}
+			if downloadedJsonString == "" {
+				log.Debug("\tSkipping persisting config %v (%v) in API %v", value.Id, value.Name, theApi.ID)
+				return
+			}
 
 			if theApi.ID == "application-mobile-user-actions-and-session-properties" {
 				presp := struct {
@@ -217,29 +243,40 @@ func (d *Downloader) downloadConfigsOfAPIFlat(theApi api.API, values []client.Va
 						Owner: value.Owner,
 						Type:  value.Type,
 					}
-					downloadedJsonString2, err := d.downloadConfigFlat(api.MobileRemoteProperties, value2)
+					downloadedJsonString2, params2, err := d.downloadConfigFlat(api.MobileRemoteProperties, value2)
 					if err != nil {
 						log.Error("Error fetching config '%v':'%v' in api '%v': %v", value2.Id, value2.SubId, api.MobileRemoteProperties.ID, err)
 						return
 					}
+					if downloadedJsonString2 == "" {
+						log.Debug("\tSkipping persisting config %v:%v in API %v", value2.Id, value2.SubId, api.MobileRemoteProperties.ID)
+						continue
+					}
 
+					mutex.Lock()
 					hasResult2 = true
 					resultsString2 = append(resultsString2, downloadedJsonString2)
+					withSecrets(secretParams2, params2)
+					mutex.Unlock()
 				}
 
 			}
 
 			mutex.Lock()
 			resultsString = append(resultsString, downloadedJsonString)
+			withSecrets(secretParams, params)
 			mutex.Unlock()
 
 		}()
 	}
 	wg.Wait()
 
-	results := d.convertAllObjectsFlat(resultsString, theApi, projectName)
+	var results []config.Config
+	if len(resultsString) > 0 {
+		results = d.convertAllObjectsFlat(resultsString, theApi, projectName, secretParams)
+	}
 	if hasResult2 {
-		results2 := d.convertAllObjectsFlat(resultsString2, api.MobileRemoteProperties, projectName)
+		results2 := d.convertAllObjectsFlat(resultsString2, api.MobileRemoteProperties, projectName, secretParams2)
 		results = append(results, results2...)
 	}
 
@@ -262,10 +299,16 @@ func (d *Downloader) downloadAndUnmarshalConfig(theApi api.API, value client.Val
 	return data, nil
 }
 
-func (d *Downloader) downloadConfigFlat(theApi api.API, value client.Value) (string, error) {
+// downloadConfigFlat returns the flat dump of the config, and the parameters of its redacted secrets.
+// An empty flat dump is returned if the config should not be persisted
+func (d *Downloader) downloadConfigFlat(theApi api.API, value client.Value) (string, map[string]parameter.Parameter, error) {
 	data, err := d.downloadAndUnmarshalConfig(theApi, value)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	if !d.skipPersist(theApi, data) {
+		return "", nil, nil
 	}
 
 	toSaveDownloaded := make(map[string]interface{}, 3)
@@ -273,6 +316,7 @@ func (d *Downloader) downloadConfigFlat(theApi api.API, value client.Value) (str
 	if value.SubId != "" {
 		toSaveDownloaded[ClassicIdKey] = toSaveDownloaded[ClassicIdKey].(string) + ":" + value.SubId
 	}
+	secretParams := d.redactor.Redact(theApi.ID, toSaveDownloaded[ClassicIdKey].(string), data)
 	toSaveDownloaded[URLPathKey] = theApi.URLPath
 	if theApi.URLSuffix != "" {
 		toSaveDownloaded[URLPathKey] = toSaveDownloaded[URLPathKey].(string) + "/%s/" + theApi.URLSuffix
@@ -287,13 +331,13 @@ func (d *Downloader) downloadConfigFlat(theApi api.API, value client.Value) (str
 
 	rawJson, err := json.Marshal(toSaveData)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return string(rawJson), nil
+	return string(rawJson), secretParams, nil
 }
 
-func (d *Downloader) convertAllObjectsFlat(objects []string, theApi api.API, projectName string) []config.Config {
+func (d *Downloader) convertAllObjectsFlat(objects []string, theApi api.API, projectName string, secretParams map[string]parameter.Parameter) []config.Config {
 
 	content := entities.JoinJsonElementsToArray(objects)
 
@@ -311,22 +355,23 @@ func (d *Downloader) convertAllObjectsFlat(objects []string, theApi api.API, pro
 		Type: config.ClassicApiType{
 			Api: theApi.ID,
 		},
-		Parameters: map[string]parameter.Parameter{
+		Parameters: withSecrets(map[string]parameter.Parameter{
 			config.NameParameter: &value.ValueParameter{Value: configId},
-		},
+		}, secretParams),
 		Skip: false,
 	}}
 
 }
 
 func (d *Downloader) createConfigForDownloadedJson(mappedJson map[string]interface{}, theApi api.API, value client.Value, projectId string) (config.Config, error) {
-	templ, err := d.createTemplate(mappedJson, value, theApi.ID)
+	templ, secretParams, err := d.createTemplate(mappedJson, value, theApi.ID)
 	if err != nil {
 		return config.Config{}, err
 	}
 
 	params := map[string]parameter.Parameter{}
 	params["name"] = &valueParam.ValueParameter{Value: templ.Name()}
+	withSecrets(params, secretParams)
 
 	coord := coordinate.Coordinate{
 		Project:  projectId,
@@ -343,14 +388,24 @@ func (d *Downloader) createConfigForDownloadedJson(mappedJson map[string]interfa
 	}, nil
 }
 
-func (d *Downloader) createTemplate(mappedJson map[string]interface{}, value client.Value, apiId string) (tmpl template.Template, err error) {
-	mappedJson = sanitizeProperties(mappedJson, apiId)
+// createTemplate creates the template of the config, and returns the parameters of its redacted secrets
+func (d *Downloader) createTemplate(mappedJson map[string]interface{}, value client.Value, apiId string) (template.Template, map[string]parameter.Parameter, error) {
+	mappedJson = SanitizeProperties(mappedJson, apiId)
+	secretParams := d.redactor.Redact(apiId, value.Id, mappedJson)
 	bytes, err := json.MarshalIndent(mappedJson, "", "  ")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	templ := template.NewDownloadTemplate(value.Id, value.Name, string(bytes))
-	return templ, nil
+	return templ, secretParams, nil
+}
+
+// withSecrets adds the parameters of the redacted secrets to the parameters of a config
+func withSecrets(parameters map[string]parameter.Parameter, secretParams map[string]parameter.Parameter) map[string]parameter.Parameter {
+	for name, param := range secretParams {
+		parameters[name] = param
+	}
+	return parameters
 }
 
 func (d *Downloader) findConfigsToDownload(currentApi api.API) ([]client.Value, error) {
@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/api"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/environment"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -238,3 +241,55 @@ func TestDownloadAll_MalformedResponseFromAnAPI(t *testing.T) {
 	configurations := downloader.DownloadAll(apiMap, "project", false)
 	assert.Len(t, configurations, 1)
 }
+
+func TestCreateConfigForDownloadedJson_AddsParametersOfRedactedSecrets(t *testing.T) {
+	redactor := secrets.NewRedactor(secrets.Paths{"notification": {"password"}})
+	downloader := NewDownloader(nil, WithRedactor(redactor))
+	testAPI := api.API{ID: "notification", URLPath: "API_PATH"}
+
+	c, err := downloader.createConfigForDownloadedJson(map[string]interface{}{"name": "webhook", "password": "p4ssw0rd"}, testAPI, client.Value{Id: "id", Name: "webhook"}, "project")
+	assert.NoError(t, err)
+
+	redactions := redactor.Redactions()
+	assert.Len(t, redactions, 1)
+	assert.Contains(t, c.Template.Content(), "{{ ."+redactions[0].Parameter+" }}")
+	assert.NotContains(t, c.Template.Content(), "p4ssw0rd")
+	assert.Equal(t, environment.New(redactions[0].EnvironmentVariable), c.Parameters[redactions[0].Parameter])
+}
+
+func TestDownloadAll_FlatDumpSkipsConfigsThatShouldNotBePersisted(t *testing.T) {
+	c := client.NewMockClient(gomock.NewController(t))
+	c.EXPECT().ListConfigs(gomock.Any()).Return([]client.Value{{Id: "id-1", Name: "keep"}, {Id: "id-2", Name: "discard"}}, nil)
+	c.EXPECT().ReadConfigByIdSubId(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ api.API, id string, _ string) ([]byte, error) {
+		if id == "id-1" {
+			return []byte(`{"name": "keep"}`), nil
+		}
+		return []byte(`{"name": "discard"}`), nil
+	}).Times(2)
+
+	downloader := NewDownloader(c, WithDiscardConditions(discard.File{
+		"API_ID": {PostDownload: []discard.Condition{{Path: "name", Equals: "discard"}}},
+	}))
+	apiMap := api.APIs{"API_ID": api.API{ID: "API_ID", URLPath: "API_PATH", NonUniqueName: true}}
+
+	configurations := downloader.DownloadAll(apiMap, "project", true)
+	assert.Len(t, configurations["API_ID"], 1)
+	assert.Contains(t, configurations["API_ID"][0].Template.Content(), `"keep"`)
+	assert.NotContains(t, configurations["API_ID"][0].Template.Content(), `"discard"`)
+}
+
+func TestDownloadAll_FlatDumpWithoutConfigsToPersist(t *testing.T) {
+	c := client.NewMockClient(gomock.NewController(t))
+	c.EXPECT().ListConfigs(gomock.Any()).Return([]client.Value{{Id: "id-1", Name: "discard"}}, nil)
+	c.EXPECT().ReadConfigByIdSubId(gomock.Any(), gomock.Any(), gomock.Any()).Return([]byte(`{"name": "discard"}`), nil)
+
+	apiFilters := map[string]apiFilter{"API_ID": {
+		shouldConfigBePersisted: func(_ map[string]interface{}) bool {
+			return false
+		},
+	}}
+	downloader := NewDownloader(c, WithAPIFilters(apiFilters))
+	apiMap := api.APIs{"API_ID": api.API{ID: "API_ID", URLPath: "API_PATH", NonUniqueName: true}}
+
+	assert.Len(t, downloader.DownloadAll(apiMap, "project", true), 0)
+}
@@ -17,8 +17,12 @@
 package classic
 
 import (
-	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"encoding/json"
 	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 )
 
 type apiFilter struct {
@@ -73,3 +77,54 @@ var apiFilters = map[string]apiFilter{
 		},
 	},
 }
+
+// mergeAPIFilters combines the api filters with user defined discard conditions.
+// A config is skipped as soon as either the api filter or one of the conditions discards it
+func mergeAPIFilters(filters map[string]apiFilter, file discard.File) map[string]apiFilter {
+	result := make(map[string]apiFilter, len(filters)+len(file))
+	for apiID, filter := range filters {
+		result[apiID] = filter
+	}
+
+	for apiID, conditions := range file {
+		apiID, conditions, builtin := apiID, conditions, result[apiID]
+
+		result[apiID] = apiFilter{
+			shouldBeSkippedPreDownload: func(value client.Value) bool {
+				if builtin.shouldBeSkippedPreDownload != nil && builtin.shouldBeSkippedPreDownload(value) {
+					return true
+				}
+				if shouldDiscard, reason := conditions.ShouldDiscardPreDownload(valueMetadata(value)); shouldDiscard {
+					log.Debug("Config %q of API %q will be discarded. Reason: %s", value.Id, apiID, reason)
+					return true
+				}
+				return false
+			},
+			shouldConfigBePersisted: func(json map[string]interface{}) bool {
+				if builtin.shouldConfigBePersisted != nil && !builtin.shouldConfigBePersisted(json) {
+					return false
+				}
+				if shouldDiscard, reason := conditions.ShouldDiscardPostDownload(json); shouldDiscard {
+					log.Debug("Downloaded config of API %q will be discarded. Reason: %s", apiID, reason)
+					return false
+				}
+				return true
+			},
+		}
+	}
+
+	return result
+}
+
+// valueMetadata converts a listed config to the map the pre download conditions are evaluated on, e.g. id, name and owner
+func valueMetadata(value client.Value) map[string]interface{} {
+	metadata := map[string]interface{}{}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return metadata
+	}
+	_ = json.Unmarshal(data, &metadata)
+
+	return metadata
+}
@@ -21,6 +21,7 @@ package classic
 import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/api"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -136,3 +137,48 @@ func TestShouldConfigBeSkipped(t *testing.T) {
 		}))
 	})
 }
+
+func Test_MergeAPIFilters(t *testing.T) {
+	file := discard.File{
+		"dashboard": {
+			PreDownload:  []discard.Condition{{Path: "owner", In: []interface{}{"test@example.com"}}},
+			PostDownload: []discard.Condition{{Path: "dashboardMetadata.name", Regex: `^\[Test\]`}},
+		},
+		"alerting-profile": {
+			PostDownload: []discard.Condition{{Path: "displayName", Equals: "Team A"}},
+		},
+	}
+
+	filters := mergeAPIFilters(apiFilters, file)
+
+	t.Run("built-in filters still apply", func(t *testing.T) {
+		owner := "Dynatrace"
+		assert.True(t, filters["dashboard"].shouldBeSkippedPreDownload(client.Value{Owner: &owner}))
+		assert.False(t, filters["dashboard"].shouldConfigBePersisted(map[string]interface{}{
+			"dashboardMetadata": map[string]interface{}{"preset": true},
+		}))
+		assert.False(t, filters["synthetic-location"].shouldConfigBePersisted(map[string]interface{}{"type": "PUBLIC"}))
+	})
+
+	t.Run("pre download conditions discard listed configs", func(t *testing.T) {
+		owner := "test@example.com"
+		assert.True(t, filters["dashboard"].shouldBeSkippedPreDownload(client.Value{Owner: &owner}))
+
+		owner = "other@example.com"
+		assert.False(t, filters["dashboard"].shouldBeSkippedPreDownload(client.Value{Owner: &owner}))
+	})
+
+	t.Run("post download conditions discard downloaded configs", func(t *testing.T) {
+		assert.False(t, filters["dashboard"].shouldConfigBePersisted(map[string]interface{}{
+			"dashboardMetadata": map[string]interface{}{"name": "[Test] Dashboard"},
+		}))
+		assert.True(t, filters["dashboard"].shouldConfigBePersisted(map[string]interface{}{
+			"dashboardMetadata": map[string]interface{}{"name": "Dashboard"},
+		}))
+		assert.False(t, filters["alerting-profile"].shouldConfigBePersisted(map[string]interface{}{"displayName": "Team A"}))
+		assert.False(t, filters["alerting-profile"].shouldBeSkippedPreDownload(client.Value{Id: "id"}))
+	})
+
+	_, found := apiFilters["alerting-profile"]
+	assert.False(t, found, "built-in filters are not modified")
+}
@@ -0,0 +1,310 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discard
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// File holds the user defined discard conditions per config type, i.e. per classic API ID or settings 2.0 schema ID
+type File map[string]TypeConditions
+
+// TypeConditions are the discard conditions of one config type. A config is discarded as soon as one condition matches.
+//
+// PreDownload conditions are evaluated on the listed metadata of a config before it is downloaded, e.g. id, name and
+// owner of classic configs or objectId, scope and externalId of settings 2.0 objects.
+// PostDownload conditions are evaluated on the downloaded payload
+type TypeConditions struct {
+	PreDownload  []Condition `yaml:"preDownload"`
+	PostDownload []Condition `yaml:"postDownload"`
+}
+
+// Condition is a predicate on the value found at a JSON path, e.g. dashboardMetadata.owner or rules[*].enabled.
+// Exactly one of Equals, Regex, Exists or In has to be set
+type Condition struct {
+	Path   string        `yaml:"path"`
+	Equals interface{}   `yaml:"equals"`
+	Regex  string        `yaml:"regex"`
+	Exists *bool         `yaml:"exists"`
+	In     []interface{} `yaml:"in"`
+
+	regex *regexp.Regexp
+}
+
+// LoadFile reads the user defined discard conditions, e.g.:
+//
+//	dashboard:
+//	  preDownload:
+//	    - path: owner
+//	      in: [ "nobody@example.com", "test@example.com" ]
+//	  postDownload:
+//	    - path: dashboardMetadata.name
+//	      regex: "^\\[Test\\]"
+//	builtin:alerting.profile:
+//	  postDownload:
+//	    - path: name
+//	      equals: "Team A"
+func LoadFile(fs afero.Fs, filterFile string) (File, error) {
+	data, err := afero.ReadFile(fs, filterFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("file `%s` is empty", filterFile)
+	}
+
+	var file File
+
+	err = yaml.UnmarshalStrict(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter file `%s`: %w", filterFile, err)
+	}
+
+	for configType, conditions := range file {
+		for _, c := range append(conditions.PreDownload, conditions.PostDownload...) {
+			if err := c.validate(); err != nil {
+				return nil, fmt.Errorf("invalid condition on path `%s` of `%s` in filter file `%s`: %w", c.Path, configType, filterFile, err)
+			}
+		}
+		conditions.compile()
+	}
+
+	return file, nil
+}
+
+func (c Condition) validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("path is missing")
+	}
+
+	if _, err := parsePath(c.Path); err != nil {
+		return err
+	}
+
+	predicates := 0
+	for _, isSet := range []bool{c.Equals != nil, c.Regex != "", c.Exists != nil, c.In != nil} {
+		if isSet {
+			predicates++
+		}
+	}
+	if predicates != 1 {
+		return fmt.Errorf("exactly one of equals, regex, exists or in has to be defined, found %d", predicates)
+	}
+
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t TypeConditions) compile() {
+	for _, conditions := range [][]Condition{t.PreDownload, t.PostDownload} {
+		for i := range conditions {
+			if conditions[i].Regex != "" {
+				conditions[i].regex = regexp.MustCompile(conditions[i].Regex)
+			}
+		}
+	}
+}
+
+// ShouldDiscardPreDownload evaluates the pre download conditions on the metadata of a listed config
+func (t TypeConditions) ShouldDiscardPreDownload(metadata map[string]interface{}) (bool, string) {
+	return shouldDiscard(t.PreDownload, metadata)
+}
+
+// ShouldDiscardPostDownload evaluates the post download conditions on the payload of a downloaded config
+func (t TypeConditions) ShouldDiscardPostDownload(payload map[string]interface{}) (bool, string) {
+	return shouldDiscard(t.PostDownload, payload)
+}
+
+func shouldDiscard(conditions []Condition, value map[string]interface{}) (bool, string) {
+	for _, c := range conditions {
+		if matches, reason := c.Matches(value); matches {
+			return true, reason
+		}
+	}
+	return false, ""
+}
+
+// Matches returns true and the reason if the condition holds for any value found at the path
+func (c Condition) Matches(value map[string]interface{}) (bool, string) {
+	segments, err := parsePath(c.Path)
+	if err != nil {
+		return false, ""
+	}
+
+	found := resolve(value, segments)
+
+	if c.Exists != nil {
+		if (len(found) > 0) == *c.Exists {
+			if *c.Exists {
+				return true, formatDiscardReasonMsg(c.Path, "is set")
+			}
+			return true, formatDiscardReasonMsg(c.Path, "is not set")
+		}
+		return false, ""
+	}
+
+	for _, v := range found {
+		if c.matchesValue(v) {
+			return true, formatDiscardReasonMsg(v, c.describe())
+		}
+	}
+	return false, ""
+}
+
+func (c Condition) matchesValue(v interface{}) bool {
+	switch {
+	case c.Equals != nil:
+		return equal(c.Equals, v)
+	case c.Regex != "":
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if c.regex == nil {
+			c.regex = regexp.MustCompile(c.Regex)
+		}
+		return c.regex.MatchString(s)
+	case c.In != nil:
+		for _, candidate := range c.In {
+			if equal(candidate, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c Condition) describe() string {
+	switch {
+	case c.Equals != nil:
+		return fmt.Sprintf("%s equals %v", c.Path, c.Equals)
+	case c.Regex != "":
+		return fmt.Sprintf("%s matches %q", c.Path, c.Regex)
+	default:
+		return fmt.Sprintf("%s is in %v", c.Path, c.In)
+	}
+}
+
+// formatDiscardReasonMsg matches the reasons of the built-in filters, e.g. "Default" cannot be managed via configuration as code
+func formatDiscardReasonMsg(entityName interface{}, condition string) string {
+	return fmt.Sprintf("%q is discarded by the filter file, %s", fmt.Sprint(entityName), condition)
+}
+
+// equal compares values of the filter file with values of the JSON payload. Numbers are compared as float64, as
+// YAML parses integers while JSON unmarshals all numbers to float64
+func equal(expected interface{}, actual interface{}) bool {
+	if e, ok := toFloat(expected); ok {
+		a, ok := toFloat(actual)
+		return ok && e == a
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// segment is one property of a path, optionally addressing all or one element of a list
+type segment struct {
+	key     string
+	isList  bool
+	allList bool
+	index   int
+}
+
+var segmentPattern = regexp.MustCompile(`^([^\[\]]+)(?:\[(\*|\d+)\])?$`)
+
+// parsePath parses a JSON path like $.dashboardMetadata.tags[*] into its segments. The leading $. is optional
+func parsePath(path string) ([]segment, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	parts := strings.Split(path, ".")
+	segments := make([]segment, 0, len(parts))
+	for _, part := range parts {
+		matches := segmentPattern.FindStringSubmatch(part)
+		if matches == nil {
+			return nil, fmt.Errorf("unexpected path element `%s`", part)
+		}
+
+		s := segment{key: matches[1]}
+		if matches[2] == "*" {
+			s.isList, s.allList = true, true
+		} else if matches[2] != "" {
+			s.isList = true
+			s.index, _ = strconv.Atoi(matches[2])
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// resolve returns all non-null values found at the path
+func resolve(value interface{}, segments []segment) []interface{} {
+	if len(segments) == 0 {
+		if value == nil {
+			return nil
+		}
+		return []interface{}{value}
+	}
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	s := segments[0]
+	child, found := m[s.key]
+	if !found {
+		return nil
+	}
+
+	if !s.isList {
+		return resolve(child, segments[1:])
+	}
+
+	list, ok := child.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var result []interface{}
+	for i, element := range list {
+		if s.allList || s.index == i {
+			result = append(result, resolve(element, segments[1:])...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discard
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionMatches(t *testing.T) {
+	payload := map[string]interface{}{
+		"name":    "Default",
+		"enabled": true,
+		"count":   float64(3),
+		"metadata": map[string]interface{}{
+			"owner": "test@example.com",
+			"tags":  []interface{}{"prod", "team-a"},
+		},
+		"rules": []interface{}{
+			map[string]interface{}{"key": "first"},
+			map[string]interface{}{"key": "second"},
+		},
+	}
+
+	yes, no := true, false
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"equals string", Condition{Path: "name", Equals: "Default"}, true},
+		{"equals string mismatch", Condition{Path: "name", Equals: "Other"}, false},
+		{"equals bool", Condition{Path: "enabled", Equals: true}, true},
+		{"equals yaml integer", Condition{Path: "count", Equals: 3}, true},
+		{"equals with json path prefix", Condition{Path: "$.metadata.owner", Equals: "test@example.com"}, true},
+		{"regex", Condition{Path: "metadata.owner", Regex: "@example\\.com$"}, true},
+		{"regex on non string", Condition{Path: "count", Regex: "3"}, false},
+		{"exists", Condition{Path: "metadata.owner", Exists: &yes}, true},
+		{"exists missing", Condition{Path: "metadata.missing", Exists: &yes}, false},
+		{"not exists", Condition{Path: "metadata.missing", Exists: &no}, true},
+		{"not exists present", Condition{Path: "name", Exists: &no}, false},
+		{"in list", Condition{Path: "name", In: []interface{}{"Default", "Other"}}, true},
+		{"in list mismatch", Condition{Path: "name", In: []interface{}{"Other"}}, false},
+		{"any list element", Condition{Path: "metadata.tags[*]", Equals: "team-a"}, true},
+		{"list index", Condition{Path: "rules[1].key", Equals: "first"}, false},
+		{"all list elements", Condition{Path: "rules[*].key", Equals: "second"}, true},
+		{"missing path", Condition{Path: "missing.key", Equals: "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, reason := tt.condition.Matches(payload)
+			assert.Equal(t, tt.want, matches)
+			if matches {
+				assert.NotEmpty(t, reason)
+			} else {
+				assert.Empty(t, reason)
+			}
+		})
+	}
+}
+
+func TestTypeConditions(t *testing.T) {
+	conditions := TypeConditions{
+		PreDownload:  []Condition{{Path: "owner", Equals: "Dynatrace"}},
+		PostDownload: []Condition{{Path: "name", Equals: "a"}, {Path: "name", Equals: "b"}},
+	}
+
+	shouldDiscard, reason := conditions.ShouldDiscardPreDownload(map[string]interface{}{"owner": "Dynatrace"})
+	assert.True(t, shouldDiscard)
+	assert.Equal(t, `"Dynatrace" is discarded by the filter file, owner equals Dynatrace`, reason)
+
+	shouldDiscard, _ = conditions.ShouldDiscardPostDownload(map[string]interface{}{"name": "b"})
+	assert.True(t, shouldDiscard)
+
+	shouldDiscard, _ = conditions.ShouldDiscardPostDownload(map[string]interface{}{"name": "c"})
+	assert.False(t, shouldDiscard)
+}
+
+func TestLoadFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "filters.yaml", []byte(`
+dashboard:
+  preDownload:
+    - path: owner
+      in: [ "test@example.com" ]
+  postDownload:
+    - path: dashboardMetadata.name
+      regex: "^\\[Test\\]"
+builtin:alerting.profile:
+  postDownload:
+    - path: name
+      equals: Team A
+`), 0644)
+
+	file, err := LoadFile(fs, "filters.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, file, 2)
+
+	shouldDiscard, _ := file["dashboard"].ShouldDiscardPostDownload(map[string]interface{}{
+		"dashboardMetadata": map[string]interface{}{"name": "[Test] dashboard"},
+	})
+	assert.True(t, shouldDiscard)
+
+	shouldDiscard, _ = file["builtin:alerting.profile"].ShouldDiscardPostDownload(map[string]interface{}{"name": "Team A"})
+	assert.True(t, shouldDiscard)
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"unknown field", "dashboard:\n  postDownload:\n    - path: name\n      contains: x\n"},
+		{"missing path", "dashboard:\n  postDownload:\n    - equals: x\n"},
+		{"no predicate", "dashboard:\n  postDownload:\n    - path: name\n"},
+		{"several predicates", "dashboard:\n  postDownload:\n    - path: name\n      equals: x\n      regex: y\n"},
+		{"invalid regex", "dashboard:\n  postDownload:\n    - path: name\n      regex: \"[\"\n"},
+		{"invalid path", "dashboard:\n  postDownload:\n    - path: rules[x].key\n      equals: x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "filters.yaml", []byte(tt.content), 0644)
+
+			_, err := LoadFile(fs, "filters.yaml")
+			assert.Error(t, err)
+		})
+	}
+}
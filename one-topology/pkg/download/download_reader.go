@@ -0,0 +1,63 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/api"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+)
+
+// ReadFromDisk loads a project written by a previous download into outputFolder.
+// The manifest is rebuilt like the written one, so that a renamed manifest_{timestamp}.yaml does not matter.
+// An empty result is returned when the project does not exist yet.
+func ReadFromDisk(fs afero.Fs, outputFolder string, projectName string) (project.ConfigsPerType, error) {
+
+	projectFolder := filepath.Join(outputFolder, projectName)
+	exists, err := afero.DirExists(fs, projectFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if the previous download '%s' exists: %w", projectFolder, err)
+	}
+	if !exists {
+		log.Info("No previous download found in '%s'", projectFolder)
+		return project.ConfigsPerType{}, nil
+	}
+
+	context := project.ProjectLoaderContext{
+		KnownApis:       api.NewAPIs().GetApiNameLookup(),
+		WorkingDir:      outputFolder,
+		Manifest:        createManifest(WriterContext{ProjectToWrite: project.Project{Id: projectName}}),
+		ParametersSerde: config.DefaultParameterParsers,
+	}
+
+	projects, errs := project.LoadProjectsSpecific(fs, context, []string{projectName}, []string{projectName})
+	if errs != nil {
+		return nil, errutils.PrintAndFormatErrors(errs, "could not load the previous download '%s'", projectFolder)
+	}
+
+	if len(projects) != 1 {
+		return nil, fmt.Errorf("loaded %d projects from the previous download '%s', expected 1", len(projects), projectFolder)
+	}
+
+	return projects[0].Configs[projectName], nil
+}
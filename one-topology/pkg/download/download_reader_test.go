@@ -0,0 +1,153 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package download
+
+import (
+	"encoding/json"
+	"testing"
+
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/template"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	v2 "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestReadFromDisk(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	downloadedConfigs := v2.ConfigsPerType{
+		"HOST": []config.Config{
+			{
+				Type: config.EntityType{
+					EntitiesType: "HOST",
+					From:         "1000",
+					To:           "2000",
+				},
+				Template: template.NewDownloadTemplate("HOST", "HOST", `[{"entityId":"HOST-1"}]`),
+				Coordinate: coordinate.Coordinate{
+					Project:  "test-project",
+					Type:     "HOST",
+					ConfigId: "host-id",
+				},
+				Parameters: config.Parameters{
+					"name": value.New("host-id"),
+				},
+			},
+		},
+	}
+
+	_, err := WriteToDisk(fs, WriterContext{
+		ProjectToWrite: CreateProjectData(downloadedConfigs, "test-project"),
+		Auth: manifest.Auth{Token: manifest.AuthSecret{
+			Name: "TEST_ENV_TOKEN",
+		}},
+		EnvironmentUrl: "env.url.com",
+		OutputFolder:   "test-output",
+	})
+	assert.NilError(t, err)
+
+	previousConfigs, err := ReadFromDisk(fs, "test-output", "test-project")
+	assert.NilError(t, err)
+	assert.Equal(t, len(previousConfigs["HOST"]), 1)
+	assert.DeepEqual(t, previousConfigs["HOST"][0].Type, config.EntityType{EntitiesType: "HOST", From: "1000", To: "2000"})
+}
+
+func TestReadFromDisk_NoPreviousDownload(t *testing.T) {
+	previousConfigs, err := ReadFromDisk(afero.NewMemMapFs(), "test-output", "test-project")
+	assert.NilError(t, err)
+	assert.Equal(t, len(previousConfigs), 0)
+}
+
+func TestReadFromDisk_RendersRedactedConfig(t *testing.T) {
+	// the templates of loaded configs are read from the OS file system
+	fs := afero.NewOsFs()
+	outputFolder := t.TempDir()
+
+	redactor := secrets.NewRedactor(secrets.Paths{"notification": {"password", "headers[*].value"}})
+	notification := map[string]interface{}{
+		"name":     "webhook",
+		"password": "p4ssw0rd",
+		"headers":  []interface{}{map[string]interface{}{"name": "Authorization", "value": "Bearer abc"}},
+	}
+	secretParams := redactor.Redact("notification", "notification-id", notification)
+	content, err := json.MarshalIndent(notification, "", "  ")
+	assert.NilError(t, err)
+
+	params := config.Parameters{"name": value.New("webhook")}
+	for name, param := range secretParams {
+		params[name] = param
+	}
+
+	downloadedConfigs := v2.ConfigsPerType{
+		"notification": []config.Config{
+			{
+				Type:     config.ClassicApiType{Api: "notification"},
+				Template: template.NewDownloadTemplate("notification-id", "webhook", string(content)),
+				Coordinate: coordinate.Coordinate{
+					Project:  "test-project",
+					Type:     "notification",
+					ConfigId: "notification-id",
+				},
+				Parameters: params,
+			},
+		},
+	}
+
+	_, err = WriteToDisk(fs, WriterContext{
+		ProjectToWrite: CreateProjectData(downloadedConfigs, "test-project"),
+		Auth: manifest.Auth{Token: manifest.AuthSecret{
+			Name: "TEST_ENV_TOKEN",
+		}},
+		EnvironmentUrl: "env.url.com",
+		OutputFolder:   outputFolder,
+	})
+	assert.NilError(t, err)
+
+	for _, redaction := range redactor.Redactions() {
+		t.Setenv(redaction.EnvironmentVariable, "secret of "+redaction.Path)
+	}
+
+	previousConfigs, err := ReadFromDisk(fs, outputFolder, "test-project")
+	assert.NilError(t, err)
+	assert.Equal(t, len(previousConfigs["notification"]), 1)
+
+	loaded := previousConfigs["notification"][0]
+	properties := map[string]interface{}{}
+	for name, param := range loaded.Parameters {
+		resolved, err := param.ResolveValue(parameter.ResolveContext{ParameterName: name})
+		assert.NilError(t, err)
+		properties[name] = resolved
+	}
+
+	templateBytes, err := loaded.LoadTemplateBytes()
+	assert.NilError(t, err)
+	loaded.Template = template.NewDownloadTemplate(loaded.Coordinate.ConfigId, loaded.Coordinate.ConfigId, string(templateBytes))
+
+	rendered, err := loaded.Render(properties)
+	assert.NilError(t, err)
+
+	var renderedNotification map[string]interface{}
+	assert.NilError(t, json.Unmarshal([]byte(rendered), &renderedNotification))
+	assert.Equal(t, renderedNotification["password"], "secret of password")
+	assert.Equal(t, renderedNotification["headers"].([]interface{})[0].(map[string]interface{})["value"], "secret of headers[0].value")
+}
@@ -18,6 +18,7 @@ package download
 
 import (
 	"fmt"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
@@ -36,6 +37,7 @@ type WriterContext struct {
 	EnvironmentType        manifest.EnvironmentType
 	OutputFolder           string
 	ForceOverwriteManifest bool
+	Compression            compression.Format
 	timestampString        string
 }
 
@@ -46,11 +48,11 @@ func (c WriterContext) GetOutputFolderFilePath() string {
 	return c.OutputFolder
 }
 
-// WriteToDisk writes all projects to the disk
-func WriteToDisk(fs afero.Fs, writerContext WriterContext) error {
+// WriteToDisk writes all projects to the disk, and returns the output folder they were written to
+func WriteToDisk(fs afero.Fs, writerContext WriterContext) (string, error) {
 	writerContext.timestampString = time.Now().Format("2006-01-02-150405")
 
-	return writeToDisk(fs, writerContext)
+	return writerContext.GetOutputFolderFilePath(), writeToDisk(fs, writerContext)
 }
 
 func writeToDisk(fs afero.Fs, writerContext WriterContext) error {
@@ -68,6 +70,7 @@ func writeToDisk(fs afero.Fs, writerContext WriterContext) error {
 		OutputDir:       outputFolder,
 		ManifestName:    manifestName,
 		ParametersSerde: config.DefaultParameterParsers,
+		Compression:     writerContext.Compression,
 	}, m, []project.Project{writerContext.ProjectToWrite})
 
 	if len(errs) > 0 {
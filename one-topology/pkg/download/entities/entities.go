@@ -36,13 +36,29 @@ import (
 // Downloader is responsible for downloading Settings 2.0 objects
 type Downloader struct {
 	client client.EntitiesClient
+	// previous holds the entities of a previous download to merge into, nil for a full download
+	previous v2.ConfigsPerType
+	// overrides replace the entity selector and the additional fields of specific entities types
+	overrides TypeOverrides
 }
 
 // NewEntitiesDownloader creates a new downloader for Settings 2.0 objects
-func NewEntitiesDownloader(c client.EntitiesClient) *Downloader {
-	return &Downloader{
+func NewEntitiesDownloader(c client.EntitiesClient, opts ...func(*Downloader)) *Downloader {
+	d := &Downloader{
 		client: c,
 	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// NewIncrementalEntitiesDownloader creates a new downloader that only fetches the entities seen since a previous download,
+// and merges them into it
+func NewIncrementalEntitiesDownloader(c client.EntitiesClient, previous v2.ConfigsPerType, opts ...func(*Downloader)) *Downloader {
+	d := NewEntitiesDownloader(c, opts...)
+	d.previous = previous
+	return d
 }
 
 // Download downloads all entities objects for the given entities Types
@@ -120,6 +136,14 @@ func (d *Downloader) DownloadAll(opts client.ListEntitiesOptions, projectName st
 
 func (d *Downloader) download(entitiesTypes []client.EntitiesType, typesAsEntitiesListPtr *client.EntitiesList, opts client.ListEntitiesOptions, projectName string) v2.ConfigsPerType {
 
+	if errs := d.overrides.validate(entitiesTypes); len(errs) > 0 {
+		for _, err := range errs {
+			log.Error("Invalid entities Type override: %v", err)
+		}
+		log.Error("Skipping entities download, %d invalid entities Type overrides", len(errs))
+		return nil
+	}
+
 	results := make(v2.ConfigsPerType, len(entitiesTypes))
 	downloadMutex := sync.Mutex{}
 	wg := sync.WaitGroup{}
@@ -130,7 +154,7 @@ func (d *Downloader) download(entitiesTypes []client.EntitiesType, typesAsEntiti
 		go func(entityType client.EntitiesType) {
 			defer wg.Done()
 
-			entityList, err := d.client.ListEntities(entityType, opts)
+			entityList, err := d.listEntities(entityType, d.genTypeOptions(entityType, opts))
 			if err != nil {
 				var errMsg string
 				var respErr client.RespError
@@ -142,10 +166,19 @@ func (d *Downloader) download(entitiesTypes []client.EntitiesType, typesAsEntiti
 				log.Error("Failed to fetch all entities for entities Type %s: %v", entityType.EntitiesTypeId, errMsg)
 				return
 			}
+			log.Debug("Downloaded %d entities for entities Type %s", len(entityList.Entities), entityType.EntitiesTypeId)
+
+			if d.previous != nil {
+				entityList, err = d.mergePrevious(entityType.EntitiesTypeId, entityList)
+				if err != nil {
+					log.Error("Failed to merge the entities for entities Type %s into the previous download: %v", entityType.EntitiesTypeId, err)
+					return
+				}
+			}
 			if len(entityList.Entities) == 0 {
 				return
 			}
-			log.Debug("Downloaded %d entities for entities Type %s", len(entityList.Entities), entityType.EntitiesTypeId)
+
 			configs := d.convertObject(entityList, entityType.EntitiesTypeId, projectName)
 			downloadMutex.Lock()
 			results[entityType.EntitiesTypeId] = configs
@@ -157,16 +190,29 @@ func (d *Downloader) download(entitiesTypes []client.EntitiesType, typesAsEntiti
 
 	wg.Wait()
 
+	d.keepPrevious(results, projectName)
+
 	configs := d.convertObject(*typesAsEntitiesListPtr, client.TypesAsEntitiesType, projectName)
 	results[client.TypesAsEntitiesType] = configs
 
 	return results
 }
 
+// genTypeOptions applies the overrides and the previous download of the entities type to the list options
+func (d *Downloader) genTypeOptions(entitiesType client.EntitiesType, opts client.ListEntitiesOptions) client.ListEntitiesOptions {
+	typeOpts := d.applyOverrides(entitiesType, opts)
+	return d.applyPrevious(entitiesType.EntitiesTypeId, typeOpts)
+}
+
 func (d *Downloader) convertObject(entitiesList client.EntitiesList, entitiesType string, projectName string) []config.Config {
 
 	content := JoinJsonElementsToArray(entitiesList.Entities)
 
+	return d.convertContent(content, entitiesType, entitiesList.From, entitiesList.To, projectName)
+}
+
+func (d *Downloader) convertContent(content string, entitiesType string, from string, to string, projectName string) []config.Config {
+
 	templ := template.NewDownloadTemplate(entitiesType, entitiesType, content)
 
 	configId := idutils.GenerateUuidFromName(entitiesType)
@@ -180,8 +226,8 @@ func (d *Downloader) convertObject(entitiesList client.EntitiesList, entitiesTyp
 		},
 		Type: config.EntityType{
 			EntitiesType: entitiesType,
-			From:         entitiesList.From,
-			To:           entitiesList.To,
+			From:         from,
+			To:           to,
 		},
 		Parameters: map[string]parameter.Parameter{
 			config.NameParameter: &value.ValueParameter{Value: configId},
@@ -75,7 +75,7 @@ func TestDownloadAll(t *testing.T) {
 				},
 				EntitiesListCalls: 2,
 			},
-			want: v2.ConfigsPerType{},
+			want: v2.ConfigsPerType{client.TypesAsEntitiesType: typesAsEntitiesConfigs()},
 		},
 		{
 			name: "DownloadEntities",
@@ -91,7 +91,7 @@ func TestDownloadAll(t *testing.T) {
 				},
 				EntitiesListCalls: 1,
 			},
-			want: v2.ConfigsPerType{testType: {
+			want: v2.ConfigsPerType{client.TypesAsEntitiesType: typesAsEntitiesConfigs(), testType: {
 				{
 					Template: template.NewDownloadTemplate(testType, testType, "[]"),
 					Coordinate: coordinate.Coordinate{
@@ -115,10 +115,10 @@ func TestDownloadAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c := client.NewMockClient(gomock.NewController(t))
 			entityTypeList, err := tt.mockValues.EntitiesTypeList()
-			c.EXPECT().ListEntitiesTypes().Times(tt.mockValues.EntitiesTypeListCalls).Return(entityTypeList, err)
+			c.EXPECT().ListEntitiesTypes().Times(tt.mockValues.EntitiesTypeListCalls).Return(entityTypeList, &client.EntitiesList{}, err)
 			entities, err := tt.mockValues.EntitiesList()
-			c.EXPECT().ListEntities(gomock.Any()).Times(tt.mockValues.EntitiesListCalls).Return(entities, err)
-			res := NewEntitiesDownloader(c).DownloadAll("projectName")
+			c.EXPECT().ListEntities(gomock.Any(), gomock.Any()).Times(tt.mockValues.EntitiesListCalls).Return(entities, err)
+			res := NewEntitiesDownloader(c).DownloadAll(client.ListEntitiesOptions{}, "projectName")
 			assert.Equal(t, tt.want, res)
 		})
 	}
@@ -167,7 +167,7 @@ func TestDownload(t *testing.T) {
 				},
 				EntitiesListCalls: 1,
 			},
-			want: v2.ConfigsPerType{},
+			want: v2.ConfigsPerType{client.TypesAsEntitiesType: typesAsEntitiesConfigs()},
 		},
 		{
 			name:          "DownloadEntities - Not all entities found",
@@ -205,7 +205,7 @@ func TestDownload(t *testing.T) {
 				},
 				EntitiesListCalls: 1,
 			},
-			want: v2.ConfigsPerType{testType: {
+			want: v2.ConfigsPerType{client.TypesAsEntitiesType: typesAsEntitiesConfigs(), testType: {
 				{
 					Template: template.NewDownloadTemplate(testType, testType, "[]"),
 					Coordinate: coordinate.Coordinate{
@@ -228,11 +228,30 @@ func TestDownload(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c := client.NewMockClient(gomock.NewController(t))
 			entityTypeList, err := tt.mockValues.EntitiesTypeList()
-			c.EXPECT().ListEntitiesTypes().Times(tt.mockValues.EntitiesTypeListCalls).Return(entityTypeList, err)
+			c.EXPECT().ListEntitiesTypes().Times(tt.mockValues.EntitiesTypeListCalls).Return(entityTypeList, &client.EntitiesList{}, err)
 			entities, err := tt.mockValues.EntitiesList()
-			c.EXPECT().ListEntities(gomock.Any()).Times(tt.mockValues.EntitiesListCalls).Return(entities, err)
-			res := NewEntitiesDownloader(c).Download(tt.EntitiesTypes, "projectName")
+			c.EXPECT().ListEntities(gomock.Any(), gomock.Any()).Times(tt.mockValues.EntitiesListCalls).Return(entities, err)
+			res := NewEntitiesDownloader(c).Download(tt.EntitiesTypes, client.ListEntitiesOptions{}, "projectName")
 			assert.Equal(t, tt.want, res)
 		})
 	}
 }
+
+func typesAsEntitiesConfigs() []config.Config {
+	uuid := idutils.GenerateUuidFromName(client.TypesAsEntitiesType)
+
+	return []config.Config{{
+		Template: template.NewDownloadTemplate(client.TypesAsEntitiesType, client.TypesAsEntitiesType, ""),
+		Coordinate: coordinate.Coordinate{
+			Project:  "projectName",
+			Type:     client.TypesAsEntitiesType,
+			ConfigId: uuid,
+		},
+		Type: config.EntityType{
+			EntitiesType: client.TypesAsEntitiesType,
+		},
+		Parameters: map[string]parameter.Parameter{
+			config.NameParameter: &value.ValueParameter{Value: uuid},
+		},
+	}}
+}
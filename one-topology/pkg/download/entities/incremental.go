@@ -0,0 +1,158 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	v2 "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+)
+
+type entityIdOnly struct {
+	EntityId string `json:"entityId"`
+}
+
+// getPreviousType returns the entity type of the previous download of a type, if any
+func (d *Downloader) getPreviousType(entitiesType string) (config.Config, config.EntityType, bool) {
+	previousConfigs, found := d.previous[entitiesType]
+	if !found || len(previousConfigs) == 0 {
+		return config.Config{}, config.EntityType{}, false
+	}
+
+	entityType, ok := previousConfigs[0].Type.(config.EntityType)
+	if !ok || entityType.To == "" {
+		return config.Config{}, config.EntityType{}, false
+	}
+
+	return previousConfigs[0], entityType, true
+}
+
+// applyPrevious only requests the entities seen since the end of the previous download of a type
+func (d *Downloader) applyPrevious(entitiesType string, opts client.ListEntitiesOptions) client.ListEntitiesOptions {
+	_, previousType, found := d.getPreviousType(entitiesType)
+	if !found {
+		return opts
+	}
+
+	typeOpts := opts
+	typeOpts.TimeFrom = previousType.To
+	log.Debug("Downloading entities for entities Type %s seen since %s", entitiesType, previousType.To)
+
+	return typeOpts
+}
+
+// mergePrevious merges the downloaded entities into the previous download of their type by entityId.
+// Updated entities replace the previous ones in place, new entities are appended, and the timeframe covers both downloads,
+// also when no entity was seen since the previous download.
+func (d *Downloader) mergePrevious(entitiesType string, entityList client.EntitiesList) (client.EntitiesList, error) {
+	previousConfig, previousType, found := d.getPreviousType(entitiesType)
+	if !found {
+		return entityList, nil
+	}
+
+	templateBytes, err := previousConfig.LoadTemplateBytes()
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+
+	merged, updatedCount, err := mergeEntities(templateBytes, entityList.Entities)
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+
+	log.Info("Merged entities Type %s: %d updated and %d new entities, %d in total", entitiesType, updatedCount, len(entityList.Entities)-updatedCount, len(merged))
+
+	return client.EntitiesList{
+		From:     previousType.From,
+		To:       entityList.To,
+		Entities: merged,
+	}, nil
+}
+
+func mergeEntities(previousJson []byte, entities []string) ([]string, int, error) {
+	var previousEntities []json.RawMessage
+	if len(previousJson) > 0 {
+		err := json.Unmarshal(previousJson, &previousEntities)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal the previous entities: %w", err)
+		}
+	}
+
+	merged := make([]string, len(previousEntities), len(previousEntities)+len(entities))
+	idxById := make(map[string]int, len(previousEntities))
+
+	for idx, previousEntity := range previousEntities {
+		var entity entityIdOnly
+		err := json.Unmarshal(previousEntity, &entity)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal a previous entity: %w", err)
+		}
+		merged[idx] = string(previousEntity)
+		idxById[entity.EntityId] = idx
+	}
+
+	updatedCount := 0
+	for _, entityJson := range entities {
+		var entity entityIdOnly
+		err := json.Unmarshal([]byte(entityJson), &entity)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal a downloaded entity: %w", err)
+		}
+
+		if idx, found := idxById[entity.EntityId]; found {
+			merged[idx] = entityJson
+			updatedCount++
+			continue
+		}
+
+		idxById[entity.EntityId] = len(merged)
+		merged = append(merged, entityJson)
+	}
+
+	return merged, updatedCount, nil
+}
+
+// keepPrevious keeps the previous download of the types that failed or were not downloaded this time
+func (d *Downloader) keepPrevious(results v2.ConfigsPerType, projectName string) {
+	for entitiesType := range d.previous {
+		if entitiesType == client.TypesAsEntitiesType {
+			continue
+		}
+
+		if _, found := results[entitiesType]; found {
+			continue
+		}
+
+		previousConfig, previousType, found := d.getPreviousType(entitiesType)
+		if !found {
+			continue
+		}
+
+		templateBytes, err := previousConfig.LoadTemplateBytes()
+		if err != nil {
+			log.Error("Failed to keep the previous download of entities Type %s: %v", entitiesType, err)
+			continue
+		}
+
+		log.Debug("Keeping the previous download of entities Type %s", entitiesType)
+		results[entitiesType] = d.convertContent(string(templateBytes), entitiesType, previousType.From, previousType.To, projectName)
+	}
+}
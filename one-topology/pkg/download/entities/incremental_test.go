@@ -0,0 +1,98 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2020 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	v2 "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeEntities(t *testing.T) {
+	previous := `[{"entityId":"HOST-1","displayName":"one"},{"entityId":"HOST-2","displayName":"two"}]`
+	downloaded := []string{
+		`{"entityId":"HOST-2","displayName":"two renamed"}`,
+		`{"entityId":"HOST-3","displayName":"three"}`,
+	}
+
+	merged, updatedCount, err := mergeEntities([]byte(previous), downloaded)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updatedCount)
+	assert.Equal(t, []string{
+		`{"entityId":"HOST-1","displayName":"one"}`,
+		`{"entityId":"HOST-2","displayName":"two renamed"}`,
+		`{"entityId":"HOST-3","displayName":"three"}`,
+	}, merged)
+
+	merged, updatedCount, err = mergeEntities([]byte{}, downloaded)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, updatedCount)
+	assert.Equal(t, downloaded, merged)
+
+	_, _, err = mergeEntities([]byte(`{}`), downloaded)
+	assert.Error(t, err)
+}
+
+func genPreviousDownload(t *testing.T, content string) v2.ConfigsPerType {
+	templatePath := filepath.Join(t.TempDir(), "HOST.json")
+	assert.NoError(t, os.WriteFile(templatePath, []byte(content), 0644))
+
+	return v2.ConfigsPerType{
+		"HOST": []config.Config{{
+			TemplatePath: templatePath,
+			Type:         config.EntityType{EntitiesType: "HOST", From: "1000", To: "2000"},
+		}},
+	}
+}
+
+func TestIncrementalDownloader(t *testing.T) {
+	downloader := NewIncrementalEntitiesDownloader(nil, genPreviousDownload(t, `[{"entityId":"HOST-1"}]`))
+
+	opts := client.ListEntitiesOptions{TimeFromMinutes: 60}
+	assert.Equal(t, "2000", downloader.genTypeOptions(client.EntitiesType{EntitiesTypeId: "HOST"}, opts).TimeFrom)
+	assert.Equal(t, "", downloader.genTypeOptions(client.EntitiesType{EntitiesTypeId: "SERVICE"}, opts).TimeFrom)
+
+	entityList, err := downloader.mergePrevious("HOST", client.EntitiesList{From: "2000", To: "3000", Entities: []string{`{"entityId":"HOST-2"}`}})
+	assert.NoError(t, err)
+	assert.Equal(t, client.EntitiesList{From: "1000", To: "3000", Entities: []string{`{"entityId":"HOST-1"}`, `{"entityId":"HOST-2"}`}}, entityList)
+
+	results := v2.ConfigsPerType{}
+	downloader.keepPrevious(results, "project")
+	assert.Len(t, results["HOST"], 1)
+	assert.Equal(t, config.EntityType{EntitiesType: "HOST", From: "1000", To: "2000"}, results["HOST"][0].Type)
+	assert.Equal(t, `[{"entityId":"HOST-1"}]`, results["HOST"][0].Template.Content())
+}
+
+func TestIncrementalDownloaderAdvancesWithoutNewEntities(t *testing.T) {
+	c := client.NewMockClient(gomock.NewController(t))
+	c.EXPECT().ListEntities(client.EntitiesType{EntitiesTypeId: "HOST"}, gomock.Any()).Return(client.EntitiesList{From: "2000", To: "3000", Entities: []string{}}, nil)
+
+	downloader := NewIncrementalEntitiesDownloader(c, genPreviousDownload(t, `[{"entityId":"HOST-1"}]`))
+	results := downloader.download([]client.EntitiesType{{EntitiesTypeId: "HOST"}}, &client.EntitiesList{}, client.ListEntitiesOptions{}, "project")
+
+	assert.Len(t, results["HOST"], 1)
+	assert.Equal(t, config.EntityType{EntitiesType: "HOST", From: "1000", To: "3000"}, results["HOST"][0].Type)
+	assert.Equal(t, `[{"entityId":"HOST-1"}]`, results["HOST"][0].Template.Content())
+}
@@ -0,0 +1,132 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/slices"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// TypeOverride replaces the entity selector and the additional fields of the download of one entities type
+type TypeOverride struct {
+	EntitySelector string `yaml:"entitySelector"`
+	Fields         string `yaml:"fields"`
+}
+
+// TypeOverrides are the overrides per entities type id
+type TypeOverrides map[string]TypeOverride
+
+// LoadTypeOverrides reads the per type overrides file, e.g.:
+//
+//	HOST:
+//	  entitySelector: tag("env:prod")
+//	  fields: +tags,+managementZones,+properties.osType
+func LoadTypeOverrides(fs afero.Fs, overridesFile string) (TypeOverrides, error) {
+	data, err := afero.ReadFile(fs, overridesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("file `%s` is empty", overridesFile)
+	}
+
+	var overrides TypeOverrides
+
+	err = yaml.UnmarshalStrict(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse entity type overrides file `%s`: %w", overridesFile, err)
+	}
+
+	return overrides, nil
+}
+
+// WithTypeOverrides sets the per type overrides of the entity selector and the additional fields
+func WithTypeOverrides(overrides TypeOverrides) func(*Downloader) {
+	return func(d *Downloader) {
+		d.overrides = overrides
+	}
+}
+
+// ValidateEntitySelector makes sure the entity selector can be appended to the type clause
+func ValidateEntitySelector(entitySelector string) error {
+	if strings.Contains(entitySelector, "type(") {
+		return fmt.Errorf("the entity selector `%s` can not contain a type clause, it is added per entities type", entitySelector)
+	}
+	return nil
+}
+
+// validate checks every override against the property metadata of its entities type
+func (o TypeOverrides) validate(entitiesTypes []client.EntitiesType) []error {
+	entitiesTypeById := make(map[string]client.EntitiesType, len(entitiesTypes))
+	for _, entitiesType := range entitiesTypes {
+		entitiesTypeById[entitiesType.EntitiesTypeId] = entitiesType
+	}
+
+	var errs []error
+
+	for entitiesTypeId, override := range o {
+		entitiesType, found := entitiesTypeById[entitiesTypeId]
+		if !found {
+			errs = append(errs, fmt.Errorf("override of unknown entities Type %s", entitiesTypeId))
+			continue
+		}
+
+		err := ValidateEntitySelector(override.EntitySelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("override of entities Type %s: %w", entitiesTypeId, err))
+		}
+
+		invalidFields := client.InvalidEntitiesTypeFields(entitiesType, override.Fields)
+		if len(invalidFields) > 0 {
+			errs = append(errs, fmt.Errorf("override of entities Type %s: fields not available for the type: %s", entitiesTypeId, strings.Join(invalidFields, ",")))
+		}
+	}
+
+	return errs
+}
+
+// applyOverrides uses the override of the entities type when there is one,
+// and only keeps the additional fields of the command line that are available for the type
+func (d *Downloader) applyOverrides(entitiesType client.EntitiesType, opts client.ListEntitiesOptions) client.ListEntitiesOptions {
+	typeOpts := opts
+
+	override := d.overrides[entitiesType.EntitiesTypeId]
+	if override.EntitySelector != "" {
+		typeOpts.EntitySelector = override.EntitySelector
+	}
+	if override.Fields != "" {
+		typeOpts.Fields = override.Fields
+		return typeOpts
+	}
+
+	invalidFields := client.InvalidEntitiesTypeFields(entitiesType, opts.Fields)
+	if len(invalidFields) == 0 {
+		return typeOpts
+	}
+
+	log.Debug("Fields not available for entities Type %s, will not extract: %s", entitiesType.EntitiesTypeId, strings.Join(invalidFields, ","))
+	typeOpts.Fields = strings.Join(slices.Difference(client.SplitFields(opts.Fields), invalidFields), ",")
+
+	return typeOpts
+}
@@ -0,0 +1,86 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+var hostEntitiesType = client.EntitiesType{
+	EntitiesTypeId: "HOST",
+	Properties: []map[string]interface{}{
+		{"id": "osType", "type": "String"},
+	},
+	ToRelationships: []map[string]interface{}{
+		{"id": "isProcessOf", "fromTypes": []interface{}{"PROCESS_GROUP_INSTANCE"}},
+	},
+}
+
+func TestLoadTypeOverrides(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "overrides.yaml", []byte(`HOST:
+  entitySelector: tag("env:prod")
+  fields: +tags,+properties.osType
+`), 0644)
+
+	overrides, err := LoadTypeOverrides(fs, "overrides.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, TypeOverrides{"HOST": {EntitySelector: `tag("env:prod")`, Fields: "+tags,+properties.osType"}}, overrides)
+
+	_ = afero.WriteFile(fs, "unknown.yaml", []byte(`HOST:
+  selector: tag("env:prod")
+`), 0644)
+
+	_, err = LoadTypeOverrides(fs, "unknown.yaml")
+	assert.Error(t, err)
+}
+
+func TestTypeOverridesValidate(t *testing.T) {
+	valid := TypeOverrides{"HOST": {EntitySelector: `tag("env:prod")`, Fields: "+tags,+properties.osType,+toRelationships.isProcessOf"}}
+	assert.Empty(t, valid.validate([]client.EntitiesType{hostEntitiesType}))
+
+	invalid := TypeOverrides{
+		"HOST":    {EntitySelector: `type("SERVICE")`, Fields: "+properties.unknown"},
+		"SERVICE": {Fields: "+tags"},
+	}
+	assert.Len(t, invalid.validate([]client.EntitiesType{hostEntitiesType}), 3)
+}
+
+func TestApplyOverrides(t *testing.T) {
+	opts := client.ListEntitiesOptions{EntitySelector: `mzName("prod")`, Fields: "+tags,+properties.osType,+properties.unknown"}
+
+	downloader := NewEntitiesDownloader(nil)
+	typeOpts := downloader.applyOverrides(hostEntitiesType, opts)
+	assert.Equal(t, `mzName("prod")`, typeOpts.EntitySelector)
+	assert.Equal(t, "+tags,+properties.osType", typeOpts.Fields)
+
+	downloader = NewEntitiesDownloader(nil, WithTypeOverrides(TypeOverrides{"HOST": {EntitySelector: `tag("env:prod")`}}))
+	typeOpts = downloader.applyOverrides(hostEntitiesType, opts)
+	assert.Equal(t, `tag("env:prod")`, typeOpts.EntitySelector)
+	assert.Equal(t, "+tags,+properties.osType", typeOpts.Fields)
+
+	downloader = NewEntitiesDownloader(nil, WithTypeOverrides(TypeOverrides{"HOST": {Fields: "+managementZones"}}))
+	typeOpts = downloader.applyOverrides(hostEntitiesType, opts)
+	assert.Equal(t, `mzName("prod")`, typeOpts.EntitySelector)
+	assert.Equal(t, "+managementZones", typeOpts.Fields)
+}
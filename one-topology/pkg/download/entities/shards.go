@@ -0,0 +1,235 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+)
+
+// MaxShards is the maximum count of timeframe shards the download of an entities Type is split into
+const MaxShards = 32
+
+// shard is a timeframe in unix milliseconds
+type shard struct {
+	from int64
+	to   int64
+}
+
+// maxShardOverlap is the share of the entities of a shard that may also be listed by the next shard.
+// The entities API lists the entities seen in a timeframe, so a long-lived entity is listed by every shard.
+// Above it, the shards multiply the requests instead of splitting them, and the timeframe is listed at once
+const maxShardOverlap = 0.5
+
+// listEntities lists the entities of a type, and splits its timeframe into shards listed in parallel
+// when the type holds more entities than the shard threshold of the options.
+// The first two shards are listed before the others, if they overlap by more than maxShardOverlap the
+// timeframe is listed at once
+func (d *Downloader) listEntities(entityType client.EntitiesType, opts client.ListEntitiesOptions) (client.EntitiesList, error) {
+	entityList, err := d.client.ListEntities(entityType, opts)
+
+	var shardingErr client.ShardingRequiredError
+	if !errors.As(err, &shardingErr) {
+		return entityList, err
+	}
+
+	from, errFrom := strconv.ParseInt(shardingErr.From, 10, 64)
+	to, errTo := strconv.ParseInt(shardingErr.To, 10, 64)
+	if errFrom != nil || errTo != nil {
+		return client.EntitiesList{}, fmt.Errorf("invalid timeframe %s-%s to shard: %w", shardingErr.From, shardingErr.To, err)
+	}
+
+	shards := splitTimeframe(from, to, shardCount(shardingErr.TotalCount, shardingErr.Threshold))
+	if len(shards) < 2 {
+		log.Warn("Cannot shard the timeframe %s-%s of entities Type %s, downloading it at once", shardingErr.From, shardingErr.To, entityType.EntitiesTypeId)
+		return d.listUnsharded(entityType, opts, shardingErr)
+	}
+
+	log.Info("Splitting the download of entities Type %s with %d entities into %d shards", entityType.EntitiesTypeId, shardingErr.TotalCount, len(shards))
+	for i, s := range shards {
+		log.Info("Shard %d of entities Type %s: from %d (%s) to %d (%s)", i+1, entityType.EntitiesTypeId,
+			s.from, time.UnixMilli(s.from).UTC().Format(time.RFC3339), s.to, time.UnixMilli(s.to).UTC().Format(time.RFC3339))
+	}
+
+	probed, err := d.listShards(entityType, opts, shards[:2], 0)
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+
+	overlap, err := shardOverlap(probed[0], probed[1])
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+	if overlap > maxShardOverlap {
+		log.Warn("Shards 1 and 2 of entities Type %s overlap by %.0f%%, it holds long-lived entities that every shard lists again. Downloading the timeframe %s-%s at once",
+			entityType.EntitiesTypeId, overlap*100, shardingErr.From, shardingErr.To)
+		return d.listUnsharded(entityType, opts, shardingErr)
+	}
+
+	remaining, err := d.listShards(entityType, opts, shards[2:], 2)
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+
+	all := shardingErr.Entities
+	for _, shardEntities := range append(probed, remaining...) {
+		all = append(all, shardEntities...)
+	}
+
+	merged, duplicateCount, err := mergeEntities(nil, all)
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+	log.Debug("Merged %d shards of entities Type %s: %d duplicate and %d distinct entities", len(shards), entityType.EntitiesTypeId, duplicateCount, len(merged))
+
+	d.client.RemoveShardPlan(entityType)
+
+	return client.EntitiesList{
+		From:     shardingErr.From,
+		To:       shardingErr.To,
+		Entities: merged,
+	}, nil
+}
+
+// listUnsharded lists the timeframe of the sharding error at once
+func (d *Downloader) listUnsharded(entityType client.EntitiesType, opts client.ListEntitiesOptions, shardingErr client.ShardingRequiredError) (client.EntitiesList, error) {
+	opts.ShardThreshold = 0
+	opts.TimeFrom = shardingErr.From
+	opts.TimeTo = shardingErr.To
+
+	entityList, err := d.client.ListEntities(entityType, opts)
+	if err != nil {
+		return client.EntitiesList{}, err
+	}
+
+	d.client.RemoveShardPlan(entityType)
+	return entityList, nil
+}
+
+// listShards lists the shards in parallel, the limiting client bounding the count of concurrent requests,
+// and returns the entities of each shard. first is the index of the first of the shards among all shards of the type
+func (d *Downloader) listShards(entityType client.EntitiesType, opts client.ListEntitiesOptions, shards []shard, first int) ([][]string, error) {
+	shardEntities := make([][]string, len(shards))
+	shardErrs := make([]error, len(shards))
+	wg := sync.WaitGroup{}
+	wg.Add(len(shards))
+
+	for i, s := range shards {
+		go func(i int, s shard) {
+			defer wg.Done()
+
+			shardOpts := opts
+			shardOpts.ShardThreshold = 0
+			shardOpts.Shard = first + i + 1
+			shardOpts.TimeFrom = strconv.FormatInt(s.from, 10)
+			shardOpts.TimeTo = strconv.FormatInt(s.to, 10)
+
+			entityList, err := d.client.ListEntities(entityType, shardOpts)
+			if err != nil {
+				shardErrs[i] = fmt.Errorf("shard %s-%s: %w", shardOpts.TimeFrom, shardOpts.TimeTo, err)
+				return
+			}
+			shardEntities[i] = entityList.Entities
+		}(i, s)
+	}
+	wg.Wait()
+
+	for _, err := range shardErrs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return shardEntities, nil
+}
+
+// shardOverlap is the share of the entities of the smaller shard that are also listed by the other one
+func shardOverlap(first []string, second []string) (float64, error) {
+	if len(first) == 0 || len(second) == 0 {
+		return 0, nil
+	}
+
+	firstIds := make(map[string]struct{}, len(first))
+	for _, entityJson := range first {
+		var entity entityIdOnly
+		err := json.Unmarshal([]byte(entityJson), &entity)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal a downloaded entity: %w", err)
+		}
+		firstIds[entity.EntityId] = struct{}{}
+	}
+
+	sharedCount := 0
+	for _, entityJson := range second {
+		var entity entityIdOnly
+		err := json.Unmarshal([]byte(entityJson), &entity)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal a downloaded entity: %w", err)
+		}
+		if _, found := firstIds[entity.EntityId]; found {
+			sharedCount++
+		}
+	}
+
+	smallerCount := len(first)
+	if len(second) < smallerCount {
+		smallerCount = len(second)
+	}
+	return float64(sharedCount) / float64(smallerCount), nil
+}
+
+// shardCount is the count of shards needed for each to hold about threshold entities, at most MaxShards
+func shardCount(totalCount int, threshold int) int {
+	if threshold <= 0 {
+		return 1
+	}
+
+	count := (totalCount + threshold - 1) / threshold
+	if count > MaxShards {
+		return MaxShards
+	}
+	return count
+}
+
+// splitTimeframe splits the timeframe into count contiguous shards of about the same duration.
+// Fewer shards are returned if the timeframe is too short
+func splitTimeframe(from int64, to int64, count int) []shard {
+	duration := to - from
+	if int64(count) > duration {
+		count = int(duration)
+	}
+	if count < 1 {
+		return nil
+	}
+
+	shards := make([]shard, 0, count)
+	start := from
+	for i := 1; i <= count; i++ {
+		end := from + duration*int64(i)/int64(count)
+		shards = append(shards, shard{from: start, to: end})
+		start = end
+	}
+
+	return shards
+}
@@ -0,0 +1,161 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// shardingClient requires sharding above totalCount entities, and lists the entities seen in a shard.
+// HOST-SHARED is seen in every shard, and the long-lived HOST-LONG-LIVED-1 and 2 in every shard if longLived is set
+type shardingClient struct {
+	totalCount       int
+	longLived        bool
+	mutex            sync.Mutex
+	shards           []string
+	indexes          []int
+	removedShardPlan []string
+}
+
+func (c *shardingClient) ListEntitiesTypes() ([]client.EntitiesType, *client.EntitiesList, error) {
+	return nil, nil, nil
+}
+
+func (c *shardingClient) ListEntities(entitiesType client.EntitiesType, opts client.ListEntitiesOptions) (client.EntitiesList, error) {
+	if opts.ShardThreshold > 0 && c.totalCount > opts.ShardThreshold {
+		return client.EntitiesList{}, client.ShardingRequiredError{EntitiesType: entitiesType.EntitiesTypeId, TotalCount: c.totalCount, Threshold: opts.ShardThreshold, From: "0", To: "3000",
+			Entities: []string{`{"entityId":"HOST-FIRST-PAGE"}`}}
+	}
+
+	c.mutex.Lock()
+	c.shards = append(c.shards, opts.TimeFrom+"-"+opts.TimeTo)
+	c.indexes = append(c.indexes, opts.Shard)
+	c.mutex.Unlock()
+
+	if c.longLived {
+		return client.EntitiesList{
+			From: opts.TimeFrom,
+			To:   opts.TimeTo,
+			Entities: []string{
+				`{"entityId":"HOST-LONG-LIVED-1"}`,
+				`{"entityId":"HOST-LONG-LIVED-2"}`,
+			},
+		}, nil
+	}
+
+	return client.EntitiesList{
+		From: opts.TimeFrom,
+		To:   opts.TimeTo,
+		Entities: []string{
+			fmt.Sprintf(`{"entityId":"HOST-%s"}`, opts.TimeFrom),
+			`{"entityId":"HOST-SHARED"}`,
+		},
+	}, nil
+}
+
+func (c *shardingClient) RemoveShardPlan(entitiesType client.EntitiesType) {
+	c.removedShardPlan = append(c.removedShardPlan, entitiesType.EntitiesTypeId)
+}
+
+func TestListEntitiesSharded(t *testing.T) {
+	c := &shardingClient{totalCount: 25}
+	downloader := NewEntitiesDownloader(c)
+
+	entityList, err := downloader.listEntities(client.EntitiesType{EntitiesTypeId: "HOST"}, client.ListEntitiesOptions{ShardThreshold: 10})
+	assert.NoError(t, err)
+
+	sort.Strings(c.shards)
+	assert.Equal(t, []string{"0-1000", "1000-2000", "2000-3000"}, c.shards)
+	sort.Ints(c.indexes)
+	assert.Equal(t, []int{1, 2, 3}, c.indexes)
+	assert.Equal(t, client.EntitiesList{
+		From: "0",
+		To:   "3000",
+		Entities: []string{
+			`{"entityId":"HOST-FIRST-PAGE"}`,
+			`{"entityId":"HOST-0"}`,
+			`{"entityId":"HOST-SHARED"}`,
+			`{"entityId":"HOST-1000"}`,
+			`{"entityId":"HOST-2000"}`,
+		},
+	}, entityList)
+	assert.Equal(t, []string{"HOST"}, c.removedShardPlan)
+}
+
+func TestListEntitiesShardsOverlap(t *testing.T) {
+	c := &shardingClient{totalCount: 25, longLived: true}
+	downloader := NewEntitiesDownloader(c)
+
+	entityList, err := downloader.listEntities(client.EntitiesType{EntitiesTypeId: "HOST"}, client.ListEntitiesOptions{ShardThreshold: 10})
+	assert.NoError(t, err)
+
+	sort.Strings(c.shards)
+	assert.Equal(t, []string{"0-1000", "0-3000", "1000-2000"}, c.shards, "the timeframe is listed at once after the first two shards overlap")
+	sort.Ints(c.indexes)
+	assert.Equal(t, []int{0, 1, 2}, c.indexes)
+	assert.Equal(t, client.EntitiesList{
+		From: "0",
+		To:   "3000",
+		Entities: []string{
+			`{"entityId":"HOST-LONG-LIVED-1"}`,
+			`{"entityId":"HOST-LONG-LIVED-2"}`,
+		},
+	}, entityList)
+	assert.Equal(t, []string{"HOST"}, c.removedShardPlan)
+}
+
+func TestListEntitiesBelowShardThreshold(t *testing.T) {
+	c := &shardingClient{totalCount: 5}
+	downloader := NewEntitiesDownloader(c)
+
+	entityList, err := downloader.listEntities(client.EntitiesType{EntitiesTypeId: "HOST"}, client.ListEntitiesOptions{TimeFrom: "10", TimeTo: "20", ShardThreshold: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10-20"}, c.shards)
+	assert.Equal(t, []int{0}, c.indexes)
+	assert.Len(t, entityList.Entities, 2)
+}
+
+func TestShardCount(t *testing.T) {
+	assert.Equal(t, 1, shardCount(10, 0))
+	assert.Equal(t, 2, shardCount(11, 10))
+	assert.Equal(t, 3, shardCount(30, 10))
+	assert.Equal(t, MaxShards, shardCount(1000000, 10))
+}
+
+func TestShardOverlap(t *testing.T) {
+	overlap, err := shardOverlap([]string{`{"entityId":"HOST-1"}`, `{"entityId":"HOST-2"}`}, []string{`{"entityId":"HOST-2"}`, `{"entityId":"HOST-3"}`, `{"entityId":"HOST-4"}`})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, overlap)
+
+	overlap, err = shardOverlap(nil, []string{`{"entityId":"HOST-1"}`})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, overlap)
+}
+
+func TestSplitTimeframe(t *testing.T) {
+	assert.Equal(t, []shard{{from: 0, to: 3}, {from: 3, to: 6}, {from: 6, to: 10}}, splitTimeframe(0, 10, 3))
+	assert.Equal(t, []shard{{from: 0, to: 1}, {from: 1, to: 2}}, splitTimeframe(0, 2, 5))
+	assert.Nil(t, splitTimeframe(5, 5, 3))
+}
@@ -0,0 +1,333 @@
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/regex"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/environment"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// ReportFileName is the file of a downloaded project listing all redacted secrets
+const ReportFileName = "redacted_secrets.json"
+
+// Paths maps config types, i.e. classic API IDs and settings 2.0 schema IDs, to the paths of their secret properties.
+// Nested properties are separated by dots and [*] addresses all elements of a list, e.g. headers[*].value
+type Paths map[string][]string
+
+// defaultPaths are the known secret properties of the downloaded configs
+var defaultPaths = Paths{
+	"aws-credentials":          {"authenticationData.keyBasedAuthentication.secretKey"},
+	"azure-credentials":        {"key"},
+	"credential-vault":         {"password", "token", "certificate", "certificatePassword"},
+	"credentials-vault":        {"password", "token", "certificate", "certificatePassword"},
+	"kubernetes-credentials":   {"authToken"},
+	"notification":             {"password", "apiKey", "apiToken", "authorizationToken", "routingKey", "serviceApiKey", "headers[*].value"},
+	"synthetic-monitor":        {"script.requests[*].requestHeaders.addHeaders[*].value"},
+	"builtin:cloud.kubernetes": {"authToken"},
+	"builtin:problem.notifications": {
+		"ansibleTowerNotification.password",
+		"jiraNotification.apiToken",
+		"opsGenieNotification.apiKey",
+		"pagerDutyNotification.serviceApiKey",
+		"serviceNowNotification.password",
+		"trelloNotification.authorizationToken",
+		"victorOpsNotification.apiKey",
+		"webHookNotification.headers[*].value",
+		"xMattersNotification.headers[*].value",
+	},
+}
+
+// DefaultPaths returns a copy of the known secret properties of the downloaded configs
+func DefaultPaths() Paths {
+	return Paths{}.With(defaultPaths)
+}
+
+// LoadPaths reads a file of additional secret properties per config type, e.g.:
+//
+//	notification:
+//	  - customProperties.token
+//	builtin:my.extension:
+//	  - endpoints[*].password
+func LoadPaths(fs afero.Fs, pathsFile string) (Paths, error) {
+	data, err := afero.ReadFile(fs, pathsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("file `%s` is empty", pathsFile)
+	}
+
+	var paths Paths
+
+	err = yaml.UnmarshalStrict(data, &paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secret paths file `%s`: %w", pathsFile, err)
+	}
+
+	for configType, typePaths := range paths {
+		for _, p := range typePaths {
+			if _, err := parsePath(p); err != nil {
+				return nil, fmt.Errorf("invalid secret path `%s` of `%s` in file `%s`: %w", p, configType, pathsFile, err)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// With returns the paths extended by the other paths
+func (p Paths) With(other Paths) Paths {
+	result := make(Paths, len(p)+len(other))
+	for configType, typePaths := range p {
+		result[configType] = append(result[configType], typePaths...)
+	}
+	for configType, typePaths := range other {
+		result[configType] = append(result[configType], typePaths...)
+	}
+	return result
+}
+
+// Redaction is one secret value that was replaced by a reference to an environment variable parameter
+type Redaction struct {
+	Type                string `json:"type"`
+	Id                  string `json:"id"`
+	Path                string `json:"path"`
+	Parameter           string `json:"parameter"`
+	EnvironmentVariable string `json:"environmentVariable"`
+}
+
+// Redactor replaces the values of secret properties by references to parameters, e.g. {{ .secret_name }},
+// that are read from an environment variable, and keeps track of all redactions. It is safe for concurrent use
+type Redactor struct {
+	paths      Paths
+	mutex      sync.Mutex
+	redactions []Redaction
+}
+
+// NewRedactor creates a Redactor for the given secret paths
+func NewRedactor(paths Paths) *Redactor {
+	return &Redactor{
+		paths: paths,
+	}
+}
+
+// Redact replaces the secrets of a config value of the given type in place and returns the environment variable parameters
+// referenced by the replaced values. They have to be added to the parameters of the config, for its template to be rendered.
+// Next to the known paths of the type, additional concrete paths, e.g. derived from a settings 2.0 schema, are redacted.
+// A nil Redactor does not redact anything
+func (r *Redactor) Redact(configType string, id string, value map[string]interface{}, additionalPaths ...string) map[string]parameter.Parameter {
+	if r == nil {
+		return nil
+	}
+
+	redacted := map[string]Redaction{}
+	for _, p := range append(r.paths[configType], additionalPaths...) {
+		segments, err := parsePath(p)
+		if err != nil {
+			continue
+		}
+		redactPath(value, segments, "", func(concretePath string, secret string) (string, bool) {
+			if secret == "" || regex.IsEnvVariable(secret) {
+				return secret, false
+			}
+			envVar := environmentVariableName(configType, id, concretePath)
+			paramName := parameterName(envVar)
+			redacted[concretePath] = Redaction{
+				Type:                configType,
+				Id:                  id,
+				Path:                concretePath,
+				Parameter:           paramName,
+				EnvironmentVariable: envVar,
+			}
+			return "{{ ." + paramName + " }}", true
+		})
+	}
+
+	if len(redacted) == 0 {
+		return nil
+	}
+
+	params := make(map[string]parameter.Parameter, len(redacted))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, redaction := range redacted {
+		r.redactions = append(r.redactions, redaction)
+		params[redaction.Parameter] = environment.New(redaction.EnvironmentVariable)
+	}
+
+	return params
+}
+
+// Redactions returns all redactions so far, sorted by type, id and path
+func (r *Redactor) Redactions() []Redaction {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := make([]Redaction, len(r.redactions))
+	copy(result, r.redactions)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].Id != result[j].Id {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// WriteReport writes all redactions into the report file of the project folder.
+// Nothing is written if no secret was redacted
+func (r *Redactor) WriteReport(fs afero.Fs, projectFolder string) (string, error) {
+	redactions := r.Redactions()
+	if len(redactions) == 0 {
+		return "", nil
+	}
+
+	err := fs.MkdirAll(projectFolder, 0777)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.MarshalIndent(redactions, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	reportFile := filepath.Join(projectFolder, ReportFileName)
+	err = afero.WriteFile(fs, reportFile, content, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write the redacted secrets report %s, see error: %w", reportFile, err)
+	}
+
+	return reportFile, nil
+}
+
+// segment is one property of a secret path, optionally addressing all (index -1) or one element of a list
+type segment struct {
+	key    string
+	isList bool
+	index  int
+}
+
+var segmentPattern = regexp.MustCompile(`^([^\[\]]+)(?:\[(\*|\d+)\])?$`)
+
+func parsePath(path string) ([]segment, error) {
+	parts := strings.Split(path, ".")
+	segments := make([]segment, 0, len(parts))
+	for _, part := range parts {
+		matches := segmentPattern.FindStringSubmatch(part)
+		if matches == nil {
+			return nil, fmt.Errorf("unexpected path element `%s`", part)
+		}
+
+		s := segment{key: matches[1], index: -1}
+		if matches[2] != "" {
+			s.isList = true
+			if matches[2] != "*" {
+				s.index, _ = strconv.Atoi(matches[2])
+			}
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// redactPath walks the segments through the value and calls replace for every string found at the end of the path
+func redactPath(value map[string]interface{}, segments []segment, parentPath string, replace func(string, string) (string, bool)) {
+	s := segments[0]
+	child, found := value[s.key]
+	if !found {
+		return
+	}
+
+	currentPath := s.key
+	if parentPath != "" {
+		currentPath = parentPath + "." + s.key
+	}
+
+	if !s.isList {
+		redactValue(child, segments[1:], currentPath, replace, func(v interface{}) { value[s.key] = v })
+		return
+	}
+
+	list, ok := child.([]interface{})
+	if !ok {
+		return
+	}
+	for i := range list {
+		if s.index >= 0 && s.index != i {
+			continue
+		}
+		i := i
+		redactValue(list[i], segments[1:], fmt.Sprintf("%s[%d]", currentPath, i), replace, func(v interface{}) { list[i] = v })
+	}
+}
+
+func redactValue(value interface{}, remaining []segment, currentPath string, replace func(string, string) (string, bool), set func(interface{})) {
+	if len(remaining) > 0 {
+		if m, ok := value.(map[string]interface{}); ok {
+			redactPath(m, remaining, currentPath, replace)
+		}
+		return
+	}
+
+	if secret, ok := value.(string); ok {
+		if replaced, ok := replace(currentPath, secret); ok {
+			set(replaced)
+		}
+	}
+}
+
+var nonAlphanumericPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// environmentVariableName generates a stable name for the environment variable of a secret, e.g.
+// NOTIFICATION_1A2B3C4D_HEADERS_0_VALUE. The id is hashed to keep the name short
+func environmentVariableName(configType string, id string, path string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+
+	name := fmt.Sprintf("%s_%08X_%s", configType, h.Sum32(), path)
+	name = nonAlphanumericPattern.ReplaceAllString(name, "_")
+	return strings.ToUpper(strings.Trim(name, "_"))
+}
+
+// parameterName derives the name of the parameter of a secret from its environment variable.
+// As the environment variable, it is unique over all configs, so that the parameters of a flat dump do not collide
+func parameterName(envVar string) string {
+	return "secret_" + strings.ToLower(envVar)
+}
@@ -0,0 +1,123 @@
+//go:build unit
+
+/**
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/environment"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	value := map[string]interface{}{
+		"name":     "webhook",
+		"password": "p4ssw0rd",
+		"apiKey":   "{{ .Env.EXISTING_KEY }}",
+		"headers": []interface{}{
+			map[string]interface{}{"name": "Authorization", "value": "Bearer abc"},
+			map[string]interface{}{"name": "X-Empty", "value": ""},
+		},
+		"nested": map[string]interface{}{
+			"list": []interface{}{"first", "second"},
+		},
+	}
+
+	redactor := NewRedactor(Paths{
+		"notification": {"password", "apiKey", "headers[*].value", "unknown.property"},
+	})
+
+	params := redactor.Redact("notification", "id-1", value, "nested.list[1]")
+	assert.Len(t, params, 3)
+
+	passwordEnvVar := environmentVariableName("notification", "id-1", "password")
+	passwordParam := parameterName(passwordEnvVar)
+	assert.Equal(t, "{{ ."+passwordParam+" }}", value["password"])
+	assert.Equal(t, environment.New(passwordEnvVar), params[passwordParam])
+	assert.Equal(t, "{{ .Env.EXISTING_KEY }}", value["apiKey"], "existing environment variable references are kept")
+	assert.Equal(t, "", value["headers"].([]interface{})[1].(map[string]interface{})["value"], "empty values are kept")
+	assert.Equal(t, "first", value["nested"].(map[string]interface{})["list"].([]interface{})[0])
+	assert.Contains(t, value["nested"].(map[string]interface{})["list"].([]interface{})[1], "{{ .secret_notification_")
+
+	redactions := redactor.Redactions()
+	assert.Len(t, redactions, 3)
+	assert.Equal(t, Redaction{Type: "notification", Id: "id-1", Path: "headers[0].value",
+		Parameter: parameterName(environmentVariableName("notification", "id-1", "headers[0].value")), EnvironmentVariable: environmentVariableName("notification", "id-1", "headers[0].value")}, redactions[0])
+	assert.Equal(t, "nested.list[1]", redactions[1].Path)
+	assert.Equal(t, "password", redactions[2].Path)
+}
+
+func TestRedact_NilRedactorDoesNothing(t *testing.T) {
+	var redactor *Redactor
+	value := map[string]interface{}{"password": "p4ssw0rd"}
+
+	assert.Empty(t, redactor.Redact("notification", "id", value, "password"))
+	assert.Equal(t, "p4ssw0rd", value["password"])
+	assert.Empty(t, redactor.Redactions())
+}
+
+func TestEnvironmentVariableName(t *testing.T) {
+	name := environmentVariableName("builtin:problem.notifications", "vu9U3hXa3q0AAAABAB", "webHookNotification.headers[0].value")
+	assert.Regexp(t, "^BUILTIN_PROBLEM_NOTIFICATIONS_[0-9A-F]{8}_WEBHOOKNOTIFICATION_HEADERS_0_VALUE$", name)
+	assert.Equal(t, name, environmentVariableName("builtin:problem.notifications", "vu9U3hXa3q0AAAABAB", "webHookNotification.headers[0].value"))
+	assert.NotEqual(t, name, environmentVariableName("builtin:problem.notifications", "other", "webHookNotification.headers[0].value"))
+}
+
+func TestLoadPaths(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "secrets.yaml", []byte("notification:\n  - customProperties.token\nbuiltin:my.extension:\n  - endpoints[*].password\n"), 0644)
+	_ = afero.WriteFile(fs, "invalid.yaml", []byte("notification:\n  - headers[x].value\n"), 0644)
+
+	paths, err := LoadPaths(fs, "secrets.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, Paths{"notification": {"customProperties.token"}, "builtin:my.extension": {"endpoints[*].password"}}, paths)
+
+	merged := DefaultPaths().With(paths)
+	assert.Contains(t, merged["notification"], "password")
+	assert.Contains(t, merged["notification"], "customProperties.token")
+	assert.NotContains(t, defaultPaths["notification"], "customProperties.token", "the default paths are not modified")
+
+	_, err = LoadPaths(fs, "invalid.yaml")
+	assert.Error(t, err)
+
+	_, err = LoadPaths(fs, "missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestWriteReport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	redactor := NewRedactor(Paths{"credentials-vault": {"token"}})
+
+	reportFile, err := redactor.WriteReport(fs, "project")
+	assert.NoError(t, err)
+	assert.Empty(t, reportFile, "no report is written without redactions")
+
+	redactor.Redact("credentials-vault", "CREDENTIALS_VAULT-1", map[string]interface{}{"token": "t0ken"})
+	reportFile, err = redactor.WriteReport(fs, "project")
+	assert.NoError(t, err)
+
+	content, err := afero.ReadFile(fs, reportFile)
+	assert.NoError(t, err)
+
+	var report []Redaction
+	assert.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, redactor.Redactions(), report)
+}
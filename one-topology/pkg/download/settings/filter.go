@@ -18,6 +18,9 @@ package settings
 
 import (
 	"fmt"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 )
 
 // noOpFilter is a settings 2.0 filter that does nothing
@@ -34,6 +37,23 @@ type Filter struct {
 	// with a value "bar" in their payload would be implemented like:
 	// func (json map[string]interface{}) (bool, string) { return json["foo"] == "bar",  "foo is set to bar" }
 	ShouldDiscard func(map[string]interface{}) (discard bool, reason string)
+
+	// ShouldDiscardPreDownload optionally contains logic whether a settings object should be discarded
+	// based on its metadata, i.e. objectId, scope, externalId and schemaVersion, before its value is processed
+	ShouldDiscardPreDownload func(map[string]interface{}) (discard bool, reason string)
+}
+
+// shouldDiscardObject evaluates ShouldDiscardPreDownload on the metadata of a settings object, if defined
+func (f Filter) shouldDiscardObject(o client.DownloadSettingsObject) (bool, string) {
+	if f.ShouldDiscardPreDownload == nil {
+		return false, ""
+	}
+	return f.ShouldDiscardPreDownload(map[string]interface{}{
+		"objectId":      o.ObjectId,
+		"scope":         o.Scope,
+		"externalId":    o.ExternalId,
+		"schemaVersion": o.SchemaVersion,
+	})
 }
 
 // Filters represents a map of settings 2.0 Filters
@@ -47,6 +67,38 @@ func (f Filters) Get(schemaID string) Filter {
 	return noOpFilter
 }
 
+// With returns the filters combined with user defined discard conditions.
+// A settings object is discarded as soon as either the filter or one of the conditions discards it
+func (f Filters) With(file discard.File) Filters {
+	result := make(Filters, len(f)+len(file))
+	for schemaID, filter := range f {
+		result[schemaID] = filter
+	}
+
+	for schemaID, conditions := range file {
+		conditions, builtin := conditions, result.Get(schemaID)
+
+		result[schemaID] = Filter{
+			ShouldDiscard: func(settingsValue map[string]interface{}) (bool, string) {
+				if shouldDiscard, reason := builtin.ShouldDiscard(settingsValue); shouldDiscard {
+					return true, reason
+				}
+				return conditions.ShouldDiscardPostDownload(settingsValue)
+			},
+			ShouldDiscardPreDownload: func(metadata map[string]interface{}) (bool, string) {
+				if builtin.ShouldDiscardPreDownload != nil {
+					if shouldDiscard, reason := builtin.ShouldDiscardPreDownload(metadata); shouldDiscard {
+						return true, reason
+					}
+				}
+				return conditions.ShouldDiscardPreDownload(metadata)
+			},
+		}
+	}
+
+	return result
+}
+
 func formatDefaultDiscardReasonMsg(entityName interface{}) string {
 	return fmt.Sprintf("%q cannot be managed via configuration as code", entityName)
 }
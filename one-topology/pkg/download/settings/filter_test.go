@@ -17,6 +17,8 @@
 package settings
 
 import (
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -120,3 +122,38 @@ func TestNoOpFilterDoesNothing(t *testing.T) {
 	assert.False(t, shouldDiscard)
 	assert.Empty(t, reason)
 }
+
+func TestFiltersWithDiscardConditions(t *testing.T) {
+	file := discard.File{
+		"builtin:alerting.profile": {
+			PostDownload: []discard.Condition{{Path: "name", Equals: "Team A"}},
+		},
+		"builtin:my.schema": {
+			PreDownload: []discard.Condition{{Path: "scope", Regex: "^HOST-"}},
+		},
+	}
+
+	filters := defaultSettingsFilters.With(file)
+
+	shouldDiscard, _ := filters.Get("builtin:alerting.profile").ShouldDiscard(map[string]interface{}{"name": "Default"})
+	assert.True(t, shouldDiscard, "built-in filter still applies")
+
+	shouldDiscard, reason := filters.Get("builtin:alerting.profile").ShouldDiscard(map[string]interface{}{"name": "Team A"})
+	assert.True(t, shouldDiscard)
+	assert.Contains(t, reason, `"Team A"`)
+
+	shouldDiscard, _ = filters.Get("builtin:alerting.profile").ShouldDiscard(map[string]interface{}{"name": "Team B"})
+	assert.False(t, shouldDiscard)
+
+	shouldDiscard, _ = filters.Get("builtin:my.schema").shouldDiscardObject(client.DownloadSettingsObject{Scope: "HOST-1234"})
+	assert.True(t, shouldDiscard)
+
+	shouldDiscard, _ = filters.Get("builtin:my.schema").shouldDiscardObject(client.DownloadSettingsObject{Scope: "environment"})
+	assert.False(t, shouldDiscard)
+
+	shouldDiscard, _ = noOpFilter.shouldDiscardObject(client.DownloadSettingsObject{Scope: "HOST-1234"})
+	assert.False(t, shouldDiscard)
+
+	_, found := defaultSettingsFilters["builtin:my.schema"]
+	assert.False(t, found, "default filters are not modified")
+}
@@ -0,0 +1,316 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/spf13/afero"
+)
+
+// SchemaDefinitionsFolder is the folder of a downloaded project holding the full definitions of the settings 2.0 schemas
+const SchemaDefinitionsFolder = "schemas"
+
+// SchemaDefinition holds the properties of a settings 2.0 schema, as returned by the schemas API
+type SchemaDefinition struct {
+	SchemaId   string                    `json:"schemaId"`
+	Version    string                    `json:"version"`
+	Properties PropertyDefinitions       `json:"properties"`
+	Types      map[string]TypeDefinition `json:"types"`
+}
+
+// TypeDefinition is a complex type of a schema, referenced by properties as #/types/<name>
+type TypeDefinition struct {
+	Properties PropertyDefinitions `json:"properties"`
+}
+
+// PropertyDefinitions maps the property names to their definition
+type PropertyDefinitions map[string]PropertyDefinition
+
+// PropertyDefinition is the definition of a property of a schema or of one of its types
+type PropertyDefinition struct {
+	Type         PropertyType        `json:"type"`
+	Nullable     bool                `json:"nullable"`
+	Default      interface{}         `json:"default"`
+	Precondition interface{}         `json:"precondition"`
+	Items        *PropertyDefinition `json:"items"`
+}
+
+// PropertyType is either a primitive type, e.g. text, secret or list, or a reference to a type or an enum of the schema
+type PropertyType struct {
+	Primitive string
+	Ref       string
+}
+
+const (
+	typeRefPrefix = "#/types/"
+	enumRefPrefix = "#/enums/"
+)
+
+func (t *PropertyType) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Primitive); err == nil {
+		return nil
+	}
+
+	var ref struct {
+		Ref string `json:"$ref"`
+	}
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return fmt.Errorf("property type is neither a primitive type nor a reference: %s", string(data))
+	}
+	t.Ref = ref.Ref
+
+	return nil
+}
+
+// required returns true if the property must be set, i.e. it is not nullable, has no default and no precondition
+func (p PropertyDefinition) required() bool {
+	return !p.Nullable && p.Default == nil && p.Precondition == nil
+}
+
+// ParseSchemaDefinition parses a schema definition as returned by the schemas API
+func ParseSchemaDefinition(data []byte) (SchemaDefinition, error) {
+	var definition SchemaDefinition
+	err := json.Unmarshal(data, &definition)
+	if err != nil {
+		return SchemaDefinition{}, err
+	}
+	if definition.SchemaId == "" {
+		return SchemaDefinition{}, fmt.Errorf("schema definition has no schemaId")
+	}
+
+	return definition, nil
+}
+
+// WriteSchemaDefinition writes the raw definition of a schema into the schemas folder of the project folder
+func WriteSchemaDefinition(fs afero.Fs, projectFolder string, schemaId string, definition []byte) error {
+	folder := filepath.Join(projectFolder, SchemaDefinitionsFolder)
+	err := fs.MkdirAll(folder, 0777)
+	if err != nil {
+		return err
+	}
+
+	definitionFile := filepath.Join(folder, config.Sanitize(schemaId)+".json")
+	err = afero.WriteFile(fs, definitionFile, definition, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the definition of schema %s to %s, see error: %w", schemaId, definitionFile, err)
+	}
+
+	return nil
+}
+
+// LoadSchemaDefinitions reads all schema definitions of the schemas folder of the project folder, by schema ID.
+// No definitions are returned if the schemas of the project were not downloaded
+func LoadSchemaDefinitions(fs afero.Fs, projectFolder string) (map[string]SchemaDefinition, error) {
+	folder := filepath.Join(projectFolder, SchemaDefinitionsFolder)
+	definitions := map[string]SchemaDefinition{}
+
+	exists, err := afero.DirExists(fs, folder)
+	if err != nil || !exists {
+		return definitions, err
+	}
+
+	files, err := afero.ReadDir(fs, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		definitionFile := filepath.Join(folder, file.Name())
+		data, err := afero.ReadFile(fs, definitionFile)
+		if err != nil {
+			return nil, err
+		}
+
+		definition, err := ParseSchemaDefinition(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the schema definition %s, see error: %w", definitionFile, err)
+		}
+		definitions[definition.SchemaId] = definition
+	}
+
+	return definitions, nil
+}
+
+// IsOrderedList returns true if the top level property is a list, whose order matters, rather than a set
+func (s SchemaDefinition) IsOrderedList(property string) bool {
+	return s.Properties[property].Type.Primitive == "list"
+}
+
+// SecretPaths returns the paths of the secret properties set in the value, e.g. authentication.password
+func (s SchemaDefinition) SecretPaths(value map[string]interface{}) []string {
+	secrets := []string{}
+	s.collectSecrets("", s.Properties, value, &secrets)
+	sort.Strings(secrets)
+	return secrets
+}
+
+func (s SchemaDefinition) collectSecrets(prefix string, properties PropertyDefinitions, value map[string]interface{}, secrets *[]string) {
+	for name, property := range properties {
+		v, found := value[name]
+		if !found || v == nil {
+			continue
+		}
+
+		path := prefix + name
+		if property.Type.Primitive == "secret" {
+			*secrets = append(*secrets, path)
+			continue
+		}
+
+		if typeDefinition, isType := s.typeDefinition(property.Type); isType {
+			if m, ok := v.(map[string]interface{}); ok {
+				s.collectSecrets(path+".", typeDefinition.Properties, m, secrets)
+			}
+			continue
+		}
+
+		if property.Items == nil {
+			continue
+		}
+		if typeDefinition, isType := s.typeDefinition(property.Items.Type); isType {
+			items, _ := v.([]interface{})
+			for i, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					s.collectSecrets(fmt.Sprintf("%s[%d].", path, i), typeDefinition.Properties, m, secrets)
+				}
+			}
+		}
+	}
+}
+
+// Validate checks the value of a settings object against the schema, and returns the problems found:
+// missing required properties, unknown properties and values of an unexpected type
+func (s SchemaDefinition) Validate(value map[string]interface{}) []string {
+	problems := []string{}
+	s.validateProperties("", s.Properties, value, &problems)
+	sort.Strings(problems)
+	return problems
+}
+
+func (s SchemaDefinition) validateProperties(prefix string, properties PropertyDefinitions, value map[string]interface{}, problems *[]string) {
+	for name, property := range properties {
+		v, found := value[name]
+		if !found || v == nil {
+			if property.required() {
+				*problems = append(*problems, fmt.Sprintf("missing required property %s%s", prefix, name))
+			}
+			continue
+		}
+
+		s.validateValue(prefix+name, property, v, problems)
+	}
+
+	for name := range value {
+		if _, found := properties[name]; !found {
+			*problems = append(*problems, fmt.Sprintf("unknown property %s%s", prefix, name))
+		}
+	}
+}
+
+func (s SchemaDefinition) validateValue(path string, property PropertyDefinition, v interface{}, problems *[]string) {
+	if typeDefinition, isType := s.typeDefinition(property.Type); isType {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("property %s is not an object", path))
+			return
+		}
+		s.validateProperties(path+".", typeDefinition.Properties, m, problems)
+		return
+	}
+
+	expected := ""
+	ok := true
+	switch {
+	case strings.HasPrefix(property.Type.Ref, enumRefPrefix):
+		_, ok = v.(string)
+		expected = "a string"
+	case property.Type.Primitive == "text" || property.Type.Primitive == "secret":
+		_, ok = v.(string)
+		expected = "a string"
+	case property.Type.Primitive == "boolean":
+		_, ok = v.(bool)
+		expected = "a boolean"
+	case property.Type.Primitive == "integer" || property.Type.Primitive == "float":
+		_, ok = v.(float64)
+		expected = "a number"
+	case property.Type.Primitive == "list" || property.Type.Primitive == "set":
+		var items []interface{}
+		items, ok = v.([]interface{})
+		expected = "an array"
+		if ok && property.Items != nil {
+			for i, item := range items {
+				s.validateValue(fmt.Sprintf("%s[%d]", path, i), *property.Items, item, problems)
+			}
+		}
+	}
+
+	if !ok {
+		*problems = append(*problems, fmt.Sprintf("property %s is not %s", path, expected))
+	}
+}
+
+func (s SchemaDefinition) typeDefinition(t PropertyType) (TypeDefinition, bool) {
+	if !strings.HasPrefix(t.Ref, typeRefPrefix) {
+		return TypeDefinition{}, false
+	}
+
+	typeDefinition, found := s.Types[strings.TrimPrefix(t.Ref, typeRefPrefix)]
+	return typeDefinition, found
+}
+
+// DownloadSchemaDefinitions downloads the full definitions of the given schemas, by schema ID.
+// Schemas whose definition fails to download or to parse are logged and skipped
+func DownloadSchemaDefinitions(c client.SettingsClient, schemaIDs []string) map[string][]byte {
+	results := make(map[string][]byte, len(schemaIDs))
+	downloadMutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(len(schemaIDs))
+	for _, schemaId := range schemaIDs {
+		go func(s string) {
+			defer wg.Done()
+
+			definition, err := c.GetSchema(s)
+			if err != nil {
+				log.Error("Failed to fetch the definition of schema %s: %v", s, err)
+				return
+			}
+
+			if _, err = ParseSchemaDefinition(definition); err != nil {
+				log.Error("Failed to parse the definition of schema %s: %v", s, err)
+				return
+			}
+
+			downloadMutex.Lock()
+			results[s] = definition
+			downloadMutex.Unlock()
+		}(schemaId)
+	}
+	wg.Wait()
+
+	return results
+}
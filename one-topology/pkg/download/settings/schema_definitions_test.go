@@ -0,0 +1,116 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchemaDefinition = `{
+	"schemaId": "builtin:test.schema",
+	"version": "1.2.3",
+	"properties": {
+		"name": {"type": "text", "nullable": false},
+		"enabled": {"type": "boolean", "nullable": false, "default": true},
+		"severity": {"type": {"$ref": "#/enums/Severity"}, "nullable": true},
+		"rules": {"type": "list", "nullable": false, "items": {"type": {"$ref": "#/types/Rule"}}},
+		"tags": {"type": "set", "nullable": true, "items": {"type": "text"}},
+		"authentication": {"type": {"$ref": "#/types/Authentication"}, "nullable": true}
+	},
+	"types": {
+		"Rule": {"properties": {"key": {"type": "text", "nullable": false}, "token": {"type": "secret", "nullable": true}}},
+		"Authentication": {"properties": {"user": {"type": "text", "nullable": false}, "password": {"type": "secret", "nullable": false}}}
+	}
+}`
+
+func TestParseSchemaDefinition(t *testing.T) {
+	definition, err := ParseSchemaDefinition([]byte(testSchemaDefinition))
+	assert.NoError(t, err)
+	assert.Equal(t, "builtin:test.schema", definition.SchemaId)
+	assert.Equal(t, PropertyType{Primitive: "text"}, definition.Properties["name"].Type)
+	assert.Equal(t, PropertyType{Ref: "#/types/Authentication"}, definition.Properties["authentication"].Type)
+
+	assert.True(t, definition.IsOrderedList("rules"))
+	assert.False(t, definition.IsOrderedList("tags"))
+	assert.False(t, definition.IsOrderedList("unknown"))
+
+	_, err = ParseSchemaDefinition([]byte(`{"properties": {}}`))
+	assert.Error(t, err)
+}
+
+func TestSchemaDefinition_Validate(t *testing.T) {
+	definition, err := ParseSchemaDefinition([]byte(testSchemaDefinition))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value map[string]interface{}
+		want  []string
+	}{
+		{
+			"valid",
+			map[string]interface{}{"name": "n", "rules": []interface{}{map[string]interface{}{"key": "k"}}, "tags": []interface{}{"a"}},
+			[]string{},
+		},
+		{
+			"missing required properties",
+			map[string]interface{}{"authentication": map[string]interface{}{"user": "u"}},
+			[]string{"missing required property authentication.password", "missing required property name", "missing required property rules"},
+		},
+		{
+			"unexpected types and unknown properties",
+			map[string]interface{}{"name": 1.0, "enabled": "yes", "rules": []interface{}{map[string]interface{}{"key": "k", "other": "o"}}, "tags": "a", "extra": true},
+			[]string{"property enabled is not a boolean", "property name is not a string", "property tags is not an array", "unknown property extra", "unknown property rules[0].other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, definition.Validate(tt.value))
+		})
+	}
+}
+
+func TestSchemaDefinition_SecretPaths(t *testing.T) {
+	definition, err := ParseSchemaDefinition([]byte(testSchemaDefinition))
+	assert.NoError(t, err)
+
+	value := map[string]interface{}{
+		"name":           "n",
+		"rules":          []interface{}{map[string]interface{}{"key": "k"}, map[string]interface{}{"key": "k", "token": "t"}},
+		"authentication": map[string]interface{}{"user": "u", "password": "p"},
+	}
+	assert.Equal(t, []string{"authentication.password", "rules[1].token"}, definition.SecretPaths(value))
+}
+
+func TestWriteAndLoadSchemaDefinitions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	definitions, err := LoadSchemaDefinitions(fs, "project")
+	assert.NoError(t, err)
+	assert.Empty(t, definitions)
+
+	err = WriteSchemaDefinition(fs, "project", "builtin:test.schema", []byte(testSchemaDefinition))
+	assert.NoError(t, err)
+
+	definitions, err = LoadSchemaDefinitions(fs, "project")
+	assert.NoError(t, err)
+	assert.Len(t, definitions, 1)
+	assert.Equal(t, "1.2.3", definitions["builtin:test.schema"].Version)
+}
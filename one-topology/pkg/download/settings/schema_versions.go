@@ -0,0 +1,90 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/spf13/afero"
+)
+
+// SchemaVersionsFileName is the file of a downloaded project holding the versions of the settings 2.0 schemas of its environment
+const SchemaVersionsFileName = "schemas.json"
+
+// SchemaVersions maps the settings 2.0 schema IDs of an environment to their latest schema version
+type SchemaVersions map[string]string
+
+// NewSchemaVersions creates the SchemaVersions of the schemas listed on an environment
+func NewSchemaVersions(schemas client.SchemaList) SchemaVersions {
+	versions := make(SchemaVersions, len(schemas))
+	for _, s := range schemas {
+		versions[s.SchemaId] = s.LatestSchemaVersion
+	}
+	return versions
+}
+
+// WriteSchemaVersions writes the schema versions into the project folder
+func WriteSchemaVersions(fs afero.Fs, projectFolder string, versions SchemaVersions) error {
+	err := fs.MkdirAll(projectFolder, 0777)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	versionsFile := filepath.Join(projectFolder, SchemaVersionsFileName)
+	err = afero.WriteFile(fs, versionsFile, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the schema versions %s, see error: %w", versionsFile, err)
+	}
+
+	return nil
+}
+
+// LoadSchemaVersions reads the schema versions of the project folder.
+// It returns false if the project was downloaded without schema versions
+func LoadSchemaVersions(fs afero.Fs, projectFolder string) (SchemaVersions, bool, error) {
+	versionsFile := filepath.Join(projectFolder, SchemaVersionsFileName)
+
+	content, err := afero.ReadFile(fs, versionsFile)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the schema versions %s, see error: %w", versionsFile, err)
+	}
+
+	versions := SchemaVersions{}
+	err = json.Unmarshal(content, &versions)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse the schema versions %s, see error: %w", versionsFile, err)
+	}
+
+	return versions, true, nil
+}
+
+// MajorVersion returns the major version of a schema version, e.g. 1 for 1.4.2
+func MajorVersion(schemaVersion string) string {
+	major, _, _ := strings.Cut(schemaVersion, ".")
+	return major
+}
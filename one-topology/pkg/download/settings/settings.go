@@ -17,13 +17,17 @@
 package settings
 
 import (
+	"encoding/json"
 	"errors"
 	"sync"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/idutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
 
 	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
@@ -41,6 +45,16 @@ type Downloader struct {
 	// filters specifies which settings 2.0 objects need special treatment under
 	// certain conditions and need to be skipped
 	filters Filters
+
+	// scopes restricts the download to settings objects of the given scopes or scope type prefixes
+	scopes []string
+
+	// redactor replaces the secrets of the downloaded settings objects by references to environment variable parameters.
+	// Secrets are downloaded as is if no redactor is set
+	redactor *secrets.Redactor
+
+	// schemaSecrets specifies whether the properties of type secret of the schema definitions are redacted as well
+	schemaSecrets bool
 }
 
 // WithFilters sets specific settings filters for settings 2.0 object that needs to be filtered following
@@ -51,6 +65,36 @@ func WithFilters(filters Filters) func(*Downloader) {
 	}
 }
 
+// WithDiscardConditions adds user defined discard conditions to the settings filters
+func WithDiscardConditions(file discard.File) func(*Downloader) {
+	return func(d *Downloader) {
+		d.filters = d.filters.With(file)
+	}
+}
+
+// WithScopes restricts the download to settings 2.0 objects of the given scopes. Exact scopes, e.g. environment,
+// and scope type prefixes, e.g. HOST_GROUP-, are supported
+func WithScopes(scopes []string) func(*Downloader) {
+	return func(d *Downloader) {
+		d.scopes = scopes
+	}
+}
+
+// WithRedactor sets the redactor used to replace secrets in the downloaded settings objects
+func WithRedactor(redactor *secrets.Redactor) func(*Downloader) {
+	return func(d *Downloader) {
+		d.redactor = redactor
+	}
+}
+
+// WithSchemaSecrets makes the redactor replace the values of all properties of type secret of the schema definitions,
+// next to its known secret paths
+func WithSchemaSecrets() func(*Downloader) {
+	return func(d *Downloader) {
+		d.schemaSecrets = true
+	}
+}
+
 // NewSettingsDownloader creates a new downloader for Settings 2.0 objects
 func NewSettingsDownloader(client client.SettingsClient, opts ...func(*Downloader)) *Downloader {
 	d := &Downloader{
@@ -112,8 +156,11 @@ func (d *Downloader) download(schemas []string, projectName string, flatDump boo
 
 			var configs []config.Config
 
+			definition := d.getSchemaDefinition(s)
 			if flatDump {
-				configs = d.downloadFlat(s, projectName)
+				configs = d.downloadFlat(s, projectName, definition)
+			} else {
+				configs = d.downloadObjects(s, projectName, definition)
 			}
 
 			if configs == nil {
@@ -132,8 +179,30 @@ func (d *Downloader) download(schemas []string, projectName string, flatDump boo
 	return results
 }
 
-func (d *Downloader) downloadFlat(schema string, projectName string) []config.Config {
-	objects, err := d.client.ListSettingsFlat(schema, client.ListSettingsOptions{})
+// getSchemaDefinition fetches the schema definition needed to redact the properties of type secret.
+// It returns nil if schema secrets are not redacted or the definition is not available
+func (d *Downloader) getSchemaDefinition(schema string) *SchemaDefinition {
+	if d.redactor == nil || !d.schemaSecrets {
+		return nil
+	}
+
+	data, err := d.client.GetSchema(schema)
+	if err != nil {
+		log.Warn("Failed to fetch the definition of schema %s, only its known secrets are redacted: %v", schema, err)
+		return nil
+	}
+
+	definition, err := ParseSchemaDefinition(data)
+	if err != nil {
+		log.Warn("Failed to parse the definition of schema %s, only its known secrets are redacted: %v", schema, err)
+		return nil
+	}
+
+	return &definition
+}
+
+func (d *Downloader) downloadFlat(schema string, projectName string, definition *SchemaDefinition) []config.Config {
+	objects, err := d.client.ListSettingsFlat(schema, client.ListSettingsOptions{Scopes: d.scopes})
 
 	if err != nil {
 		printDownloadError(err, schema)
@@ -146,11 +215,32 @@ func (d *Downloader) downloadFlat(schema string, projectName string) []config.Co
 
 	log.Info("Downloaded %d settings for schema %s", len(objects), schema)
 
-	configs := d.convertAllObjectsFlat(objects, schema, projectName)
+	objects, secretParams := d.filterAndRedactFlat(objects, schema, definition)
+	if len(objects) == 0 {
+		return nil
+	}
+	configs := d.convertAllObjectsFlat(objects, schema, projectName, secretParams)
 
 	return configs
 }
 
+func (d *Downloader) downloadObjects(schema string, projectName string, definition *SchemaDefinition) []config.Config {
+	objects, err := d.client.ListSettings(schema, client.ListSettingsOptions{Scopes: d.scopes})
+
+	if err != nil {
+		printDownloadError(err, schema)
+		return nil
+	}
+
+	if len(objects) == 0 {
+		return nil
+	}
+
+	log.Info("Downloaded %d settings for schema %s", len(objects), schema)
+
+	return d.convertAllObjects(objects, projectName, definition)
+}
+
 func printDownloadError(err error, schema string) {
 	var errMsg string
 	var respErr client.RespError
@@ -164,7 +254,73 @@ func printDownloadError(err error, schema string) {
 	log.Error("Failed to fetch all settings for schema %s: %v", schema, errMsg)
 }
 
-func (d *Downloader) convertAllObjectsFlat(objects []string, schemaId string, projectName string) []config.Config {
+// filterAndRedactFlat discards the flat dumped settings objects matching the filter of the schema, replaces the secrets
+// of the values of the remaining objects, and returns them together with the parameters of the secrets of all objects
+func (d *Downloader) filterAndRedactFlat(objects []string, schemaId string, definition *SchemaDefinition) ([]string, map[string]parameter.Parameter) {
+	result := make([]string, 0, len(objects))
+	secretParams := map[string]parameter.Parameter{}
+	filter := d.filters.Get(schemaId)
+
+	for _, o := range objects {
+		var toSaveData map[string]map[string]interface{}
+		if err := json.Unmarshal([]byte(o), &toSaveData); err != nil {
+			log.Error("Failed to unmarshal a settings object of schema %s, skipping it: %v", schemaId, err)
+			continue
+		}
+
+		downloaded := toSaveData[rules.DownloadedKey]
+		metadata := client.DownloadSettingsObject{SchemaId: schemaId}
+		metadata.ObjectId, _ = downloaded["objectId"].(string)
+		metadata.Scope, _ = downloaded["scope"].(string)
+		metadata.ExternalId, _ = downloaded["externalId"].(string)
+		metadata.SchemaVersion, _ = downloaded["schemaVersion"].(string)
+
+		if shouldDiscard, reason := filter.shouldDiscardObject(metadata); shouldDiscard {
+			log.Debug("Downloaded setting %s of schema %s will be discarded. Reason: %s", metadata.ObjectId, schemaId, reason)
+			continue
+		}
+
+		settingsValue, ok := downloaded[rules.ValueKey].(map[string]interface{})
+		if !ok {
+			result = append(result, o)
+			continue
+		}
+
+		if shouldDiscard, reason := filter.ShouldDiscard(settingsValue); shouldDiscard {
+			log.Debug("Downloaded setting %s of schema %s will be discarded. Reason: %s", metadata.ObjectId, schemaId, reason)
+			continue
+		}
+
+		params := d.redact(schemaId, metadata.ObjectId, settingsValue, definition)
+		if len(params) == 0 {
+			result = append(result, o)
+			continue
+		}
+
+		rawJson, err := json.Marshal(toSaveData)
+		if err != nil {
+			log.Error("Failed to marshal the redacted settings object %s of schema %s, skipping it: %v", metadata.ObjectId, schemaId, err)
+			continue
+		}
+		result = append(result, string(rawJson))
+		for name, param := range params {
+			secretParams[name] = param
+		}
+	}
+
+	return result, secretParams
+}
+
+// redact replaces the known secrets of a settings value and, if a schema definition is given, its properties of type secret
+func (d *Downloader) redact(schemaId string, objectId string, settingsValue map[string]interface{}, definition *SchemaDefinition) map[string]parameter.Parameter {
+	var schemaSecrets []string
+	if definition != nil {
+		schemaSecrets = definition.SecretPaths(settingsValue)
+	}
+	return d.redactor.Redact(schemaId, objectId, settingsValue, schemaSecrets...)
+}
+
+func (d *Downloader) convertAllObjectsFlat(objects []string, schemaId string, projectName string, secretParams map[string]parameter.Parameter) []config.Config {
 
 	content := entities.JoinJsonElementsToArray(objects)
 
@@ -182,11 +338,101 @@ func (d *Downloader) convertAllObjectsFlat(objects []string, schemaId string, pr
 		Type: config.SettingsType{
 			SchemaId: schemaId,
 		},
-		Parameters: map[string]parameter.Parameter{
+		Parameters: d.withDownloadScopes(withSecrets(map[string]parameter.Parameter{
 			config.NameParameter:  &value.ValueParameter{Value: configId},
 			config.ScopeParameter: &value.ValueParameter{Value: "flatDump"},
-		},
+		}, secretParams)),
 		Skip: false,
 	}}
 
 }
+
+// convertAllObjects creates one config per settings object, skipping the objects discarded by the filter of their schema
+func (d *Downloader) convertAllObjects(objects []client.DownloadSettingsObject, projectName string, definition *SchemaDefinition) []config.Config {
+	result := make([]config.Config, 0, len(objects))
+
+	for _, o := range objects {
+		if shouldDiscard, reason := d.filters.Get(o.SchemaId).shouldDiscardObject(o); shouldDiscard {
+			log.Debug("Downloaded setting %s of schema %s will be discarded. Reason: %s", o.ObjectId, o.SchemaId, reason)
+			continue
+		}
+
+		var settingsValue map[string]interface{}
+		err := json.Unmarshal(o.Value, &settingsValue)
+		if err != nil {
+			log.Error("Failed to unmarshal the value of settings object %s of schema %s, skipping it: %v", o.ObjectId, o.SchemaId, err)
+			continue
+		}
+
+		if shouldDiscard, reason := d.filters.Get(o.SchemaId).ShouldDiscard(settingsValue); shouldDiscard {
+			log.Debug("Downloaded setting %s of schema %s will be discarded. Reason: %s", o.ObjectId, o.SchemaId, reason)
+			continue
+		}
+
+		rawValue := o.Value
+		secretParams := d.redact(o.SchemaId, o.ObjectId, settingsValue, definition)
+		if len(secretParams) > 0 {
+			rawValue, err = json.Marshal(settingsValue)
+			if err != nil {
+				log.Error("Failed to marshal the redacted value of settings object %s of schema %s, skipping it: %v", o.ObjectId, o.SchemaId, err)
+				continue
+			}
+		}
+
+		content, err := json.MarshalIndent(rawValue, "", "  ")
+		if err != nil {
+			log.Error("Failed to format the value of settings object %s of schema %s, skipping it: %v", o.ObjectId, o.SchemaId, err)
+			continue
+		}
+
+		configId := getConfigId(o)
+		templ := template.NewDownloadTemplate(configId, configId, string(content))
+
+		result = append(result, config.Config{
+			Template: templ,
+			Coordinate: coordinate.Coordinate{
+				Project:  projectName,
+				Type:     o.SchemaId,
+				ConfigId: configId,
+			},
+			Type: config.SettingsType{
+				SchemaId:      o.SchemaId,
+				SchemaVersion: o.SchemaVersion,
+			},
+			Parameters: d.withDownloadScopes(withSecrets(map[string]parameter.Parameter{
+				config.NameParameter:  &value.ValueParameter{Value: configId},
+				config.ScopeParameter: &value.ValueParameter{Value: o.Scope},
+			}, secretParams)),
+			Skip:           false,
+			OriginObjectId: o.ObjectId,
+		})
+	}
+
+	return result
+}
+
+// withDownloadScopes records the scopes the download was restricted to, so that matching knows the download was partial
+func (d *Downloader) withDownloadScopes(parameters map[string]parameter.Parameter) map[string]parameter.Parameter {
+	if len(d.scopes) > 0 {
+		parameters[config.DownloadScopesParameter] = &value.ValueParameter{Value: d.scopes}
+	}
+	return parameters
+}
+
+// withSecrets adds the environment variable parameters referenced by the redacted secrets
+func withSecrets(parameters map[string]parameter.Parameter, secretParams map[string]parameter.Parameter) map[string]parameter.Parameter {
+	for name, param := range secretParams {
+		parameters[name] = param
+	}
+	return parameters
+}
+
+// getConfigId returns the config id the object was deployed from when its externalId was generated by monaco,
+// so that deploying the downloaded config updates the same object.
+// Otherwise, a config id is generated from the object id.
+func getConfigId(o client.DownloadSettingsObject) string {
+	if configId, found := idutils.GetConfigIdFromExternalID(o.SchemaId, o.ExternalId); found {
+		return configId
+	}
+	return idutils.GenerateUuidFromName(o.ObjectId)
+}
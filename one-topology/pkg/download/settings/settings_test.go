@@ -26,8 +26,11 @@ import (
 	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/environment"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/template"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/discard"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/secrets"
 	v2 "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -253,3 +256,147 @@ func TestDownload(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertAllObjects(t *testing.T) {
+	objects := []client.DownloadSettingsObject{
+		{
+			ExternalId:    idutils.GenerateExternalID("builtin:alerting.profile", "my-profile"),
+			SchemaVersion: "1.2.3",
+			SchemaId:      "builtin:alerting.profile",
+			ObjectId:      "oid1",
+			Scope:         "environment",
+			Value:         json.RawMessage(`{"name": "profile"}`),
+		},
+		{
+			ExternalId:    "created-in-the-ui",
+			SchemaVersion: "1.2.3",
+			SchemaId:      "builtin:alerting.profile",
+			ObjectId:      "oid2",
+			Scope:         "HOST-1234",
+			Value:         json.RawMessage(`{"name": "other"}`),
+		},
+		{
+			ExternalId:    idutils.GenerateExternalID("builtin:other.schema", "my-profile"),
+			SchemaVersion: "1.2.3",
+			SchemaId:      "builtin:alerting.profile",
+			ObjectId:      "oid3",
+			Scope:         "environment",
+			Value:         json.RawMessage(`{"name": "foreign"}`),
+		},
+	}
+
+	configs := NewSettingsDownloader(nil).convertAllObjects(objects, "project", nil)
+	assert.Len(t, configs, 3)
+
+	wantIds := []string{"my-profile", idutils.GenerateUuidFromName("oid2"), idutils.GenerateUuidFromName("oid3")}
+	wantScopes := []string{"environment", "HOST-1234", "environment"}
+	for i, c := range configs {
+		assert.Equal(t, coordinate.Coordinate{Project: "project", Type: "builtin:alerting.profile", ConfigId: wantIds[i]}, c.Coordinate)
+		assert.Equal(t, config.SettingsType{SchemaId: "builtin:alerting.profile", SchemaVersion: "1.2.3"}, c.Type)
+		assert.Equal(t, &value.ValueParameter{Value: wantIds[i]}, c.Parameters[config.NameParameter])
+		assert.Equal(t, &value.ValueParameter{Value: wantScopes[i]}, c.Parameters[config.ScopeParameter])
+		assert.Equal(t, objects[i].ObjectId, c.OriginObjectId)
+		assert.JSONEq(t, string(objects[i].Value), c.Template.Content())
+	}
+}
+
+func TestConvertAllObjectsSkipsInvalidValues(t *testing.T) {
+	objects := []client.DownloadSettingsObject{
+		{SchemaId: "builtin:alerting.profile", ObjectId: "oid1", Value: json.RawMessage(`not json`)},
+		{SchemaId: "builtin:alerting.profile", ObjectId: "oid2", Value: json.RawMessage(`{}`)},
+	}
+
+	configs := NewSettingsDownloader(nil).convertAllObjects(objects, "project", nil)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, "oid2", configs[0].OriginObjectId)
+}
+
+func TestConvertAllObjectsRedactsSecrets(t *testing.T) {
+	definition, err := ParseSchemaDefinition([]byte(`{
+		"schemaId": "builtin:problem.notifications",
+		"properties": {
+			"webHookNotification": {"type": {"$ref": "#/types/WebHook"}, "nullable": true},
+			"token": {"type": "secret", "nullable": true}
+		},
+		"types": {"WebHook": {"properties": {"url": {"type": "text", "nullable": false}}}}
+	}`))
+	assert.NoError(t, err)
+
+	redactor := secrets.NewRedactor(secrets.DefaultPaths())
+	d := NewSettingsDownloader(nil, WithRedactor(redactor), WithSchemaSecrets())
+
+	objects := []client.DownloadSettingsObject{
+		{
+			ObjectId: "oid1",
+			SchemaId: "builtin:problem.notifications",
+			Scope:    "environment",
+			Value:    json.RawMessage(`{"token": "t0ken", "webHookNotification": {"url": "https://hook", "headers": [{"name": "Authorization", "value": "Bearer abc"}]}}`),
+		},
+	}
+
+	configs := d.convertAllObjects(objects, "project", &definition)
+	assert.Len(t, configs, 1)
+	assert.NotContains(t, configs[0].Template.Content(), "t0ken")
+	assert.NotContains(t, configs[0].Template.Content(), "Bearer abc")
+	assert.Contains(t, configs[0].Template.Content(), "https://hook")
+
+	redactions := redactor.Redactions()
+	assert.Len(t, redactions, 2)
+	assert.Equal(t, "token", redactions[0].Path)
+	assert.Equal(t, "webHookNotification.headers[0].value", redactions[1].Path)
+	for _, redaction := range redactions {
+		assert.Equal(t, environment.New(redaction.EnvironmentVariable), configs[0].Parameters[redaction.Parameter])
+	}
+}
+
+func TestFilterAndRedactFlat_RedactsSecrets(t *testing.T) {
+	redactor := secrets.NewRedactor(secrets.Paths{"builtin:my.schema": {"password"}})
+	d := NewSettingsDownloader(nil, WithRedactor(redactor))
+
+	objects := []string{
+		`{"downloaded":{"objectId":"oid1","value":{"password":"p4ssw0rd"}}}`,
+		`{"downloaded":{"objectId":"oid2","value":{"name":"no secrets"}}}`,
+	}
+
+	redacted, params := d.filterAndRedactFlat(objects, "builtin:my.schema", nil)
+	assert.NotContains(t, redacted[0], "p4ssw0rd")
+	assert.Contains(t, redacted[0], `"objectId":"oid1"`)
+	assert.Equal(t, `{"downloaded":{"objectId":"oid2","value":{"name":"no secrets"}}}`, redacted[1])
+	assert.Len(t, redactor.Redactions(), 1)
+	assert.Len(t, params, 1)
+	assert.Contains(t, redacted[0], "{{ ."+redactor.Redactions()[0].Parameter+" }}")
+}
+
+func TestFilterAndRedactFlat_DiscardsFilteredObjects(t *testing.T) {
+	d := NewSettingsDownloader(nil, WithDiscardConditions(discard.File{
+		"builtin:my.schema": {
+			PreDownload:  []discard.Condition{{Path: "scope", Regex: "^HOST-"}},
+			PostDownload: []discard.Condition{{Path: "name", Equals: "Team A"}},
+		},
+	}))
+
+	objects := []string{
+		`{"downloaded":{"objectId":"oid1","scope":"HOST-1234","value":{"name":"Team B"}}}`,
+		`{"downloaded":{"objectId":"oid2","scope":"environment","value":{"name":"Team A"}}}`,
+		`{"downloaded":{"objectId":"oid3","scope":"environment","value":{"name":"Team B"}}}`,
+	}
+
+	filtered, _ := d.filterAndRedactFlat(objects, "builtin:my.schema", nil)
+	assert.Equal(t, []string{objects[2]}, filtered)
+
+	filtered, _ = d.filterAndRedactFlat(objects, "builtin:other.schema", nil)
+	assert.Equal(t, objects, filtered, "the conditions only apply to their schema")
+}
+
+func TestDownloadFlat_AppliesFilters(t *testing.T) {
+	c := client.NewMockClient(gomock.NewController(t))
+	c.EXPECT().ListSettingsFlat("builtin:alerting.profile", gomock.Any()).Return([]string{
+		`{"downloaded":{"objectId":"oid1","scope":"environment","value":{"name":"Default"}}}`,
+		`{"downloaded":{"objectId":"oid2","scope":"environment","value":{"name":"Team A"}}}`,
+	}, nil)
+
+	configs := NewSettingsDownloader(c).downloadFlat("builtin:alerting.profile", "project", nil)
+	assert.Len(t, configs, 1)
+	assert.NotContains(t, configs[0].Template.Content(), `"Default"`, "the built-in filter discards the default profile")
+	assert.Contains(t, configs[0].Template.Content(), `"Team A"`)
+}
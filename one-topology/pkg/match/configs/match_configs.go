@@ -16,21 +16,58 @@ package configs
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
-	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/slices"
 	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/settings"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 )
 
 type configTypeInfo struct {
 	configTypeString string
 	configType       config.Type
+	// incompatible is set when the settings 2.0 schema of the type is missing or of a different major version on the target
+	incompatible bool
+	// schema is the downloaded definition of the settings 2.0 schema of the type, if any
+	schema *settings.SchemaDefinition
+}
+
+// matchInputs are read from disk once per run and shared by all the waves
+type matchInputs struct {
+	replacements        map[string]map[string]string
+	schemaCompatibility schemaCompatibility
+	schemaDefinitions   map[string]settings.SchemaDefinition
+}
+
+func loadMatchInputs(fs afero.Fs, matchParameters match.MatchParameters) (matchInputs, error) {
+	replacements, err := readReplacements(fs, matchParameters)
+	if err != nil {
+		return matchInputs{}, err
+	}
+
+	schemaCompatibility, err := loadSchemaCompatibility(fs, matchParameters)
+	if err != nil {
+		return matchInputs{}, err
+	}
+
+	schemaDefinitions, err := loadSchemaDefinitions(fs, matchParameters)
+	if err != nil {
+		return matchInputs{}, err
+	}
+
+	return matchInputs{
+		replacements:        replacements,
+		schemaCompatibility: schemaCompatibility,
+		schemaDefinitions:   schemaDefinitions,
+	}, nil
 }
 
 func MatchConfigs(fs afero.Fs, matchParameters match.MatchParameters, configPerTypeSource project.ConfigsPerType, configPerTypeTarget project.ConfigsPerType) ([]string, int, int, error) {
@@ -58,17 +95,37 @@ func MatchConfigs(fs afero.Fs, matchParameters match.MatchParameters, configPerT
 		Stats:   map[string]int{},
 	}
 
-	typesToProcessFirst := []string{"application-web", "application-mobile", "synthetic-monitor"}
+	inputs, err := loadMatchInputs(fs, matchParameters)
+	if err != nil {
+		return []string{}, 0, 0, err
+	}
+
+	err = removeTerraformImports(fs, matchParameters)
+	if err != nil {
+		return []string{}, 0, 0, err
+	}
+
+	waves := genProcessingWaves(configPerTypeSource, configPerTypeTarget)
+	configIdMatches := map[string]string{}
+	projectConfigs := []config.Config{}
 
-	errs, matchPayload, stats, configsSourceCount, configsTargetCount = processConfigBatch(configPerTypeTarget, matchParameters,
-		fs, errs, configPerTypeSource, matchPayload,
-		configsSourceCount, configsTargetCount, stats,
-		typesToProcessFirst)
+	for waveIdx, wave := range waves {
+		log.Debug("Processing wave %d of %d", waveIdx+1, len(waves))
 
-	errs, matchPayload, stats, configsSourceCount, configsTargetCount = processConfigBatch(configPerTypeTarget, matchParameters,
-		fs, errs, configPerTypeSource, matchPayload,
-		configsSourceCount, configsTargetCount, stats,
-		[]string{})
+		var waveMatches map[string]string
+		errs, matchPayload, stats, configsSourceCount, configsTargetCount, waveMatches = processConfigBatch(configPerTypeTarget, matchParameters,
+			fs, errs, configPerTypeSource, matchPayload,
+			configsSourceCount, configsTargetCount, stats,
+			wave, configIdMatches, &projectConfigs, inputs)
+
+		entityIdMatches, configIdMatchesWave := splitConfigMatches(waveMatches)
+		if len(entityIdMatches) > 0 {
+			entities.AddMatches(entityIdMatches)
+		}
+		for configIdSource, configIdTarget := range configIdMatchesWave {
+			configIdMatches[configIdSource] = configIdTarget
+		}
+	}
 
 	if len(errs) >= 1 {
 		return []string{}, 0, 0, errutils.PrintAndFormatErrors(errs, "failed to match configs with required fields")
@@ -78,22 +135,62 @@ func MatchConfigs(fs afero.Fs, matchParameters match.MatchParameters, configPerT
 	for action, total := range matchPayload.Stats {
 		log.Info("%s: %v", runeLabelMap[action], total)
 	}
+	setSummaryCounts(matchPayload, runeLabelMap)
 	writeMatchPayload(fs, matchParameters, matchPayload)
 
+	entityMatches, err := entities.LoadMatches(fs, matchParameters)
+	if err != nil {
+		return []string{}, 0, 0, err
+	}
+
+	plan, err := genMigrationPlan(matchPayload, entityMatches)
+	if err != nil {
+		return []string{}, 0, 0, err
+	}
+
+	err = writeMigrationPlan(fs, matchParameters, plan)
+	if err != nil {
+		return []string{}, 0, 0, err
+	}
+
+	if matchParameters.SqliteFile != "" {
+		err = writeMatchStore(fs, matchParameters, matchPayload, entityMatches, plan)
+		if err != nil {
+			return []string{}, 0, 0, err
+		}
+	}
+
+	if matchParameters.ReportFormat != "" {
+		err = writeReport(fs, matchParameters, matchPayload)
+		if err != nil {
+			return []string{}, 0, 0, err
+		}
+	}
+
+	if matchParameters.HtmlReportFile != "" {
+		err = writeHtmlReport(fs, matchParameters, matchPayload, entityMatches)
+		if err != nil {
+			return []string{}, 0, 0, err
+		}
+	}
+
+	if matchParameters.EmitProjectDir != "" {
+		err = writeEmittedProject(fs, matchParameters, projectConfigs)
+		if err != nil {
+			return []string{}, 0, 0, err
+		}
+	}
+
 	return stats, configsSourceCount, configsTargetCount, nil
 }
 
 func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParameters match.MatchParameters,
 	fs afero.Fs, errs []error, configPerTypeSource project.ConfigsPerType, matchPayload MatchPayload,
 	configsSourceCount int, configsTargetCount int, stats []string,
-	typesToProcessFirst []string) ([]error, MatchPayload, []string, int, int) {
-
-	typeCount := len(configPerTypeTarget)
-	isFirstCall := len(typesToProcessFirst) > 0
+	wave []configTypeInfo, configIdMatches map[string]string, projectConfigs *[]config.Config, inputs matchInputs) ([]error, MatchPayload, []string, int, int, map[string]string) {
 
-	if isFirstCall {
-		typeCount = len(typesToProcessFirst)
-	}
+	typeCount := len(wave)
+	waveMatches := map[string]string{}
 
 	channel := make(chan configTypeInfo, typeCount)
 	mutex := sync.Mutex{}
@@ -104,14 +201,9 @@ func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParamet
 	}
 	waitGroup.Add(maxThreads)
 
-	replacements, err := readReplacements(fs, matchParameters)
-	if err != nil {
-		errs = append(errs, err)
-	}
-
 	processType := func(configTypeInfo configTypeInfo) {
 
-		if skipConfigType(matchParameters, configTypeInfo.configTypeString) {
+		if matchParameters.SkipType(configTypeInfo.configTypeString) {
 			log.Debug("Skip Type: %s", configTypeInfo.configTypeString)
 			return
 		}
@@ -126,7 +218,7 @@ func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParamet
 			return
 		}
 
-		configProcessingPtr, err := genConfigProcessing(fs, matchParameters, configPerTypeSource, configPerTypeTarget, configTypeInfo.configTypeString, entityMatches, replacements)
+		configProcessingPtr, err := genConfigProcessing(fs, matchParameters, configPerTypeSource, configPerTypeTarget, configTypeInfo.configTypeString, entityMatches, configIdMatches, inputs.replacements)
 		if err != nil {
 			mutex.Lock()
 			errs = append(errs, err)
@@ -134,6 +226,17 @@ func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParamet
 			return
 		}
 
+		warnPartialDownload(configPerTypeSource, configPerTypeTarget, configTypeInfo.configTypeString)
+
+		var reason string
+		configTypeInfo.incompatible, reason = inputs.schemaCompatibility.isIncompatible(configTypeInfo)
+		if configTypeInfo.incompatible {
+			log.Warn("Configs of type %s will not be added or updated, the schema is incompatible: %s", configTypeInfo.configTypeString, reason)
+		}
+		if schema, found := inputs.schemaDefinitions[configTypeInfo.configTypeString]; found {
+			configTypeInfo.schema = &schema
+		}
+
 		configsSourceCountType := len(configProcessingPtr.Source.RemainingMatch)
 		configsTargetCountType := len(configProcessingPtr.Target.RemainingMatch)
 
@@ -150,27 +253,48 @@ func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParamet
 			return
 		}
 
-		if isFirstCall {
-			entities.AddMatches(configMatches.Matches)
-		} else {
-			err = writeMatches(fs, configProcessingPtr, matchParameters, configTypeInfo, configMatches, configIdxToWriteSource)
+		validateSourceConfigs(configProcessingPtr, configTypeInfo, configIdxToWriteSource)
+
+		err = writeMatches(fs, configProcessingPtr, matchParameters, configTypeInfo, configMatches, configIdxToWriteSource)
+		if err != nil {
+			mutex.Lock()
+			errs = append(errs, fmt.Errorf("failed to persist matches of type: %s, see error: %w", configTypeInfo.configTypeString, err))
+			mutex.Unlock()
+			return
+		}
+
+		err = writeTerraformImports(fs, matchParameters, configTypeInfo, matchEntityMatches)
+		if err != nil {
+			mutex.Lock()
+			errs = append(errs, fmt.Errorf("failed to write terraform imports of type: %s, see error: %w", configTypeInfo.configTypeString, err))
+			mutex.Unlock()
+			return
+		}
+
+		var projectConfigsType []config.Config
+		if matchParameters.EmitProjectDir != "" {
+			projectConfigsType, err = genProjectConfigs(matchParameters, configProcessingPtr, configTypeInfo, configMatches, configIdxToWriteSource)
 			if err != nil {
 				mutex.Lock()
-				errs = append(errs, fmt.Errorf("failed to persist matches of type: %s, see error: %w", configTypeInfo.configTypeString, err))
+				errs = append(errs, fmt.Errorf("failed to translate configs of type: %s, see error: %w", configTypeInfo.configTypeString, err))
 				mutex.Unlock()
 				return
 			}
+		}
 
-			mutex.Lock()
-			matchPayload.Modules = append(matchPayload.Modules, matchEntityMatches)
-			for action, value := range matchEntityMatches["stats"].(map[string]int) {
-				matchPayload.Stats[action] += value
-			}
-			configsSourceCount += configsSourceCountType
-			configsTargetCount += configsTargetCountType
-			stats = append(stats, fmt.Sprintf("%65s %10d %12d %10d %10d %10d", configTypeInfo.configTypeString, len(configMatches.Matches), len(configMatches.MultiMatched), len(configMatches.UnMatched), configsTargetCountType, configsSourceCountType))
-			mutex.Unlock()
+		mutex.Lock()
+		matchPayload.Modules = append(matchPayload.Modules, matchEntityMatches)
+		for action, value := range matchEntityMatches["stats"].(map[string]int) {
+			matchPayload.Stats[action] += value
 		}
+		for configIdSource, configIdTarget := range configMatches.Matches {
+			waveMatches[configIdSource] = configIdTarget
+		}
+		*projectConfigs = append(*projectConfigs, projectConfigsType...)
+		configsSourceCount += configsSourceCountType
+		configsTargetCount += configsTargetCountType
+		stats = append(stats, fmt.Sprintf("%65s %10d %12d %10d %10d %10d", configTypeInfo.configTypeString, len(configMatches.Matches), len(configMatches.MultiMatched), len(configMatches.UnMatched), configsTargetCountType, configsSourceCountType))
+		mutex.Unlock()
 
 	}
 
@@ -190,50 +314,66 @@ func processConfigBatch(configPerTypeTarget project.ConfigsPerType, matchParamet
 
 	}
 
-	typeDone := map[string]bool{}
+	for _, configTypeInfo := range wave {
+		channel <- configTypeInfo
+	}
 
-	for configsType, configObjectList := range configPerTypeTarget {
-		if isFirstCall && slices.Contains(typesToProcessFirst, configsType) {
-			// pass
-		} else {
-			continue
-		}
+	close(channel)
+	waitGroup.Wait()
 
-		if len(configObjectList) >= 1 {
-			typeDone[configsType] = true
-			channel <- configTypeInfo{configsType, configObjectList[0].Type}
-		}
+	return errs, matchPayload, stats, configsSourceCount, configsTargetCount, waveMatches
+}
+
+// warnPartialDownload warns when the configs of a type were downloaded for some scopes only,
+// as configs outside of these scopes are then reported as unmatched
+func warnPartialDownload(configPerTypeSource project.ConfigsPerType, configPerTypeTarget project.ConfigsPerType, configsType string) {
+	if scopes, found := downloadScopes(configPerTypeSource[configsType]); found {
+		log.Warn("Source configs of type %s were downloaded for scopes %v only", configsType, scopes)
 	}
-	for configsType, configObjectList := range configPerTypeSource {
-		if isFirstCall {
-			break
-		}
+	if scopes, found := downloadScopes(configPerTypeTarget[configsType]); found {
+		log.Warn("Target configs of type %s were downloaded for scopes %v only", configsType, scopes)
+	}
+}
 
-		if typeDone[configsType] {
+// downloadScopes returns the scopes the configs were downloaded for, if the download was restricted to some scopes
+func downloadScopes(configs []config.Config) (interface{}, bool) {
+	for _, c := range configs {
+		param, found := c.Parameters[config.DownloadScopesParameter]
+		if !found {
 			continue
 		}
-
-		if len(configObjectList) >= 1 {
-			typeDone[configsType] = true
-			channel <- configTypeInfo{configsType, configObjectList[0].Type}
+		if valueParam, ok := param.(*value.ValueParameter); ok {
+			return valueParam.Value, true
 		}
 	}
 
-	close(channel)
-	waitGroup.Wait()
-
-	return errs, matchPayload, stats, configsSourceCount, configsTargetCount
+	return nil, false
 }
 
-func skipConfigType(matchParameters match.MatchParameters, configsType string) bool {
-	if len(matchParameters.SpecificTypes) == 0 {
-		return false
-	}
+// setSummaryCounts records the number of configs per type and action, the multi matched configs are counted apart
+func setSummaryCounts(matchPayload MatchPayload, runeLabelMap map[string]string) {
+	for _, module := range matchPayload.Modules {
+		schemaId, _ := module["schemaId"].(string)
+		moduleStats, ok := module["stats"].(map[string]int)
+		if !ok {
+			continue
+		}
 
-	if slices.Contains(matchParameters.SpecificTypes, configsType) {
-		return matchParameters.SkipSpecificTypes
-	}
+		counts := map[string]int{}
+		for status, count := range moduleStats {
+			if status == "" {
+				continue
+			}
 
-	return !matchParameters.SkipSpecificTypes
+			label := runeLabelMap[status[0:1]]
+			if strings.HasSuffix(status, string(match.STATUS_MULTI_MATCH_RUNE)) && len(status) > 1 {
+				label = match.STATUS_MULTI_MATCH
+			}
+			counts[label] += count
+		}
 
+		for label, count := range counts {
+			summary.SetCount(schemaId, label, count)
+		}
+	}
 }
@@ -0,0 +1,454 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/maps"
+	toposort "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/sort"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/classic"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/cloudflare/ahocorasick"
+)
+
+// typeReferences holds what a config type provides to and needs from other config types
+type typeReferences struct {
+	// providedEntityTypes are the entity types of the config ids of this type, e.g. APPLICATION for application-web
+	providedEntityTypes map[string]bool
+	// referencedEntityTypes are the entity types of all entity ids found in the payloads of this type
+	referencedEntityTypes map[string]bool
+	// referencedConfigIds are the non-entity config ids of other types found in the payloads of this type
+	referencedConfigIds map[string]bool
+	// configIds are the ids of all configs of this type
+	configIds []string
+}
+
+// flatConfigIds is used to only extract the ids out of a flat dump
+type flatConfigIds []struct {
+	Downloaded struct {
+		ClassicId string `json:"classicId"`
+		ObjectId  string `json:"objectId"`
+	} `json:"downloaded"`
+}
+
+// genProcessingWaves sorts all config types into waves that can be processed in parallel.
+// A type is placed after every type it depends on, so that the matches of the referenced types
+// can be used to replace the ids in its payloads.
+// Types that are part of a dependency cycle are processed together in a last wave.
+func genProcessingWaves(configPerTypeSource project.ConfigsPerType, configPerTypeTarget project.ConfigsPerType) [][]configTypeInfo {
+
+	startTime := time.Now()
+
+	typeInfos := genConfigTypeInfos(configPerTypeSource, configPerTypeTarget)
+
+	typeNames := make([]string, len(typeInfos))
+	for i, typeInfo := range typeInfos {
+		typeNames[i] = typeInfo.configTypeString
+	}
+
+	referencesPerType := map[string]typeReferences{}
+	for _, configsType := range typeNames {
+		references, err := extractTypeReferences(configPerTypeSource[configsType])
+		if err != nil {
+			log.Warn("Could not extract the dependencies of type %s, it will not wait for other types: %v", configsType, err)
+			continue
+		}
+		referencesPerType[configsType] = references
+	}
+
+	addConfigIdReferences(configPerTypeSource, referencesPerType)
+
+	dependencies := genTypeDependencies(typeNames, referencesPerType)
+
+	waveIndexes := sortTypeWaves(typeNames, dependencies)
+
+	waves := make([][]configTypeInfo, len(waveIndexes))
+	for i, wave := range waveIndexes {
+		waves[i] = make([]configTypeInfo, len(wave))
+		for j, typeIdx := range wave {
+			waves[i][j] = typeInfos[typeIdx]
+		}
+	}
+
+	log.Debug("Sorted %d config types into %d processing waves in %v", len(typeInfos), len(waves), time.Since(startTime))
+
+	return waves
+}
+
+// genConfigTypeInfos lists all types of the source and the target, sorted by name.
+// The type information of the target is used in priority.
+func genConfigTypeInfos(configPerTypeSource project.ConfigsPerType, configPerTypeTarget project.ConfigsPerType) []configTypeInfo {
+	typeInfoMap := map[string]configTypeInfo{}
+
+	for _, configPerType := range []project.ConfigsPerType{configPerTypeTarget, configPerTypeSource} {
+		for configsType, configObjectList := range configPerType {
+			if _, found := typeInfoMap[configsType]; found {
+				continue
+			}
+
+			if len(configObjectList) >= 1 {
+				typeInfoMap[configsType] = configTypeInfo{configTypeString: configsType, configType: configObjectList[0].Type}
+			}
+		}
+	}
+
+	typeNames := maps.Keys(typeInfoMap)
+	sort.Strings(typeNames)
+
+	typeInfos := make([]configTypeInfo, len(typeNames))
+	for i, configsType := range typeNames {
+		typeInfos[i] = typeInfoMap[configsType]
+	}
+
+	return typeInfos
+}
+
+func extractTypeReferences(configObjectList []config.Config) (typeReferences, error) {
+	references := typeReferences{
+		providedEntityTypes:   map[string]bool{},
+		referencedEntityTypes: map[string]bool{},
+		referencedConfigIds:   map[string]bool{},
+		configIds:             []string{},
+	}
+
+	if len(configObjectList) <= 0 {
+		return references, nil
+	}
+
+	templateBytes, err := configObjectList[0].LoadTemplateBytes()
+	if err != nil {
+		return references, err
+	}
+
+	if len(templateBytes) == 0 {
+		return references, nil
+	}
+
+	var ids flatConfigIds
+	err = json.Unmarshal(templateBytes, &ids)
+	if err != nil {
+		return references, err
+	}
+
+	configIdLocation, _ := getConfigTypeInfo(configObjectList[0].Type)
+
+	for _, id := range ids {
+		configId := id.Downloaded.ObjectId
+		if configIdLocation == classic.ClassicIdKey {
+			configId = id.Downloaded.ClassicId
+		}
+
+		if configId == "" {
+			continue
+		}
+
+		references.configIds = append(references.configIds, configId)
+
+		entityType, isEntityId := getEntityIdType(configId)
+		if isEntityId {
+			references.providedEntityTypes[entityType] = true
+		}
+	}
+
+	for _, entityId := range entityExtractionRegex.FindAll(templateBytes, -1) {
+		entityType, _ := getEntityIdType(string(entityId))
+		references.referencedEntityTypes[entityType] = true
+	}
+
+	return references, nil
+}
+
+// getEntityIdType returns the entity type of an id, if the full id is an entity id
+func getEntityIdType(id string) (string, bool) {
+	if entityExtractionRegex.FindString(id) != id {
+		return "", false
+	}
+
+	return id[0:(len(id) - 17)], true
+}
+
+// addConfigIdReferences searches the quoted non-entity config ids of all types in the payloads of all types.
+func addConfigIdReferences(configPerTypeSource project.ConfigsPerType, referencesPerType map[string]typeReferences) {
+
+	quotedIds := []string{}
+	ids := []string{}
+	idsDone := map[string]bool{}
+
+	for _, references := range referencesPerType {
+		for _, configId := range references.configIds {
+			if idsDone[configId] {
+				continue
+			}
+			idsDone[configId] = true
+
+			if _, isEntityId := getEntityIdType(configId); isEntityId {
+				continue
+			}
+
+			quotedId, err := json.Marshal(configId)
+			if err != nil {
+				continue
+			}
+			quotedIds = append(quotedIds, string(quotedId))
+			ids = append(ids, configId)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	idMatcher := ahocorasick.NewStringMatcher(quotedIds)
+
+	for configsType, references := range referencesPerType {
+		if len(references.configIds) == 0 {
+			continue
+		}
+
+		templateBytes, err := configPerTypeSource[configsType][0].LoadTemplateBytes()
+		if err != nil {
+			log.Warn("Could not search config ids referenced by type %s: %v", configsType, err)
+			continue
+		}
+
+		for _, idx := range idMatcher.MatchThreadSafe(templateBytes) {
+			references.referencedConfigIds[ids[idx]] = true
+		}
+	}
+}
+
+// genTypeDependencies returns, for each type, the types it depends on.
+//
+// A type depends on another type if it references entities of a type provided by the other type,
+// or if it references the ids of configs of the other type.
+// When both types share the same ids (e.g. application-web and application-web-data-privacy),
+// only the sub-type (the one prefixed by the name of the other) depends on the other.
+func genTypeDependencies(typeNames []string, referencesPerType map[string]typeReferences) map[string]map[string]bool {
+
+	providersPerEntityType := map[string][]string{}
+	ownersPerConfigId := map[string][]string{}
+
+	for _, configsType := range typeNames {
+		references, found := referencesPerType[configsType]
+		if !found {
+			continue
+		}
+
+		for entityType := range references.providedEntityTypes {
+			providersPerEntityType[entityType] = append(providersPerEntityType[entityType], configsType)
+		}
+
+		for _, configId := range references.configIds {
+			ownersPerConfigId[configId] = append(ownersPerConfigId[configId], configsType)
+		}
+	}
+
+	dependencies := make(map[string]map[string]bool, len(typeNames))
+
+	addDependencies := func(configsType string, isSharedWithType bool, providers []string) {
+		for _, provider := range providers {
+			if provider == configsType {
+				continue
+			}
+
+			if isSharedWithType && !isSubType(configsType, provider) {
+				continue
+			}
+
+			dependencies[configsType][provider] = true
+		}
+	}
+
+	for _, configsType := range typeNames {
+		dependencies[configsType] = map[string]bool{}
+
+		references, found := referencesPerType[configsType]
+		if !found {
+			continue
+		}
+
+		for entityType := range references.referencedEntityTypes {
+			addDependencies(configsType, references.providedEntityTypes[entityType], providersPerEntityType[entityType])
+		}
+
+		for configId := range references.referencedConfigIds {
+			owners := ownersPerConfigId[configId]
+			isOwner := false
+			for _, owner := range owners {
+				if owner == configsType {
+					isOwner = true
+					break
+				}
+			}
+			addDependencies(configsType, isOwner, owners)
+		}
+	}
+
+	return dependencies
+}
+
+// isSubType checks if configsType is a sub-api of parentType, e.g. dashboard-sharing for dashboard
+func isSubType(configsType string, parentType string) bool {
+	return strings.HasPrefix(configsType, parentType+"-")
+}
+
+// sortTypeWaves sorts the types topologically and groups them into waves of independent types.
+// The returned waves contain the indexes of the types in typeNames.
+func sortTypeWaves(typeNames []string, dependencies map[string]map[string]bool) [][]int {
+
+	typeIndexes := make(map[string]int, len(typeNames))
+	for i, configsType := range typeNames {
+		typeIndexes[configsType] = i
+	}
+
+	incomingEdges := make([][]int, len(typeNames))
+	for i, configsType := range typeNames {
+		for dependency := range dependencies[configsType] {
+			incomingEdges[i] = append(incomingEdges[i], typeIndexes[dependency])
+		}
+	}
+
+	waves, errs := toposort.TopologySortStages(incomingEdges)
+
+	if len(errs) > 0 {
+		cycleWave := make([]int, len(errs))
+		for idx, err := range errs {
+			cycleWave[idx] = err.OnId
+
+			dependsOn := make([]string, len(err.UnresolvedIncomingEdgesFrom))
+			for k, j := range err.UnresolvedIncomingEdgesFrom {
+				dependsOn[k] = typeNames[j]
+			}
+			log.Warn("Circular dependency between config types: %s still depends on %v, processing it in the last wave", typeNames[err.OnId], dependsOn)
+		}
+		waves = append(waves, cycleWave)
+	}
+
+	for i, wave := range waves {
+		waveTypes := make([]string, len(wave))
+		for j, typeIdx := range wave {
+			waveTypes[j] = typeNames[typeIdx]
+		}
+		log.Debug("Config types processing wave %d: %v", i+1, waveTypes)
+	}
+
+	return waves
+}
+
+// splitConfigMatches splits config matches into entity id matches, used like any other entity match,
+// and plain config id matches, used to replace the references to these configs in the following waves.
+func splitConfigMatches(configMatches map[string]string) (map[string]string, map[string]string) {
+	entityIdMatches := map[string]string{}
+	configIdMatches := map[string]string{}
+
+	for configIdSource, configIdTarget := range configMatches {
+		entityTypeSource, isEntityIdSource := getEntityIdType(configIdSource)
+		entityTypeTarget, isEntityIdTarget := getEntityIdType(configIdTarget)
+
+		if isEntityIdSource && isEntityIdTarget && entityTypeSource == entityTypeTarget {
+			entityIdMatches[configIdSource] = configIdTarget
+		} else if !isEntityIdSource && !isEntityIdTarget {
+			configIdMatches[configIdSource] = configIdTarget
+		}
+	}
+
+	return entityIdMatches, configIdMatches
+}
+
+// configIdentityKeys are the keys of the value of a config that identify the config itself, they are not references
+var configIdentityKeys = map[string]bool{
+	"id":          true,
+	"name":        true,
+	"displayName": true,
+}
+
+// replaceConfigReferences replaces, in place, the references of a downloaded config to the configs matched in a previous wave.
+// Like the dependency resolution of the download, a config does not reference itself: its id, the identifying keys of its
+// value and any string equal to its own id are kept. The other string values that are exactly a matched config id are replaced
+func replaceConfigReferences(confMap map[string]interface{}, configIdLocation string, configIdMatches map[string]string) bool {
+	selfId, _ := confMap[configIdLocation].(string)
+
+	wasModified := false
+	for key, subValue := range confMap {
+		if key == configIdLocation {
+			continue
+		}
+
+		if valueMap, ok := subValue.(map[string]interface{}); ok && key == rules.ValueKey {
+			for valueKey, valueSubValue := range valueMap {
+				if configIdentityKeys[valueKey] {
+					continue
+				}
+				replaced, modified := replaceReferences(valueSubValue, selfId, configIdMatches)
+				if modified {
+					valueMap[valueKey] = replaced
+					wasModified = true
+				}
+			}
+			continue
+		}
+
+		replaced, modified := replaceReferences(subValue, selfId, configIdMatches)
+		if modified {
+			confMap[key] = replaced
+			wasModified = true
+		}
+	}
+
+	return wasModified
+}
+
+// replaceReferences replaces, in place, the string values that are exactly the id of a matched config other than selfId
+func replaceReferences(confInterface interface{}, selfId string, configIdMatches map[string]string) (interface{}, bool) {
+	switch value := confInterface.(type) {
+	case string:
+		if value == selfId {
+			return confInterface, false
+		}
+		configIdTarget, found := configIdMatches[value]
+		if found && configIdTarget != value {
+			return configIdTarget, true
+		}
+	case map[string]interface{}:
+		wasModified := false
+		for key, subValue := range value {
+			replaced, modified := replaceReferences(subValue, selfId, configIdMatches)
+			if modified {
+				value[key] = replaced
+				wasModified = true
+			}
+		}
+		return value, wasModified
+	case []interface{}:
+		wasModified := false
+		for idx, subValue := range value {
+			replaced, modified := replaceReferences(subValue, selfId, configIdMatches)
+			if modified {
+				value[idx] = replaced
+				wasModified = true
+			}
+		}
+		return value, wasModified
+	}
+
+	return confInterface, false
+}
@@ -0,0 +1,189 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/classic"
+	"gotest.tools/assert"
+)
+
+func genTestReferences(configIds []string, provided []string, referencedEntityTypes []string, referencedConfigIds []string) typeReferences {
+	references := typeReferences{
+		providedEntityTypes:   map[string]bool{},
+		referencedEntityTypes: map[string]bool{},
+		referencedConfigIds:   map[string]bool{},
+		configIds:             configIds,
+	}
+	for _, entityType := range provided {
+		references.providedEntityTypes[entityType] = true
+	}
+	for _, entityType := range referencedEntityTypes {
+		references.referencedEntityTypes[entityType] = true
+	}
+	for _, configId := range referencedConfigIds {
+		references.referencedConfigIds[configId] = true
+	}
+	return references
+}
+
+func TestGenTypeDependencies(t *testing.T) {
+
+	typeNames := []string{"alerting-profile", "application-web", "application-web-data-privacy", "management-zone"}
+
+	referencesPerType := map[string]typeReferences{
+		"alerting-profile": genTestReferences(
+			[]string{"a1b2c3"}, nil, []string{"HOST"}, []string{"-12345", "a1b2c3"}),
+		"application-web": genTestReferences(
+			[]string{"APPLICATION-0123456789ABCDEF"}, []string{"APPLICATION"}, []string{"APPLICATION"}, nil),
+		"application-web-data-privacy": genTestReferences(
+			[]string{"APPLICATION-0123456789ABCDEF"}, []string{"APPLICATION"}, []string{"APPLICATION"}, nil),
+		"management-zone": genTestReferences(
+			[]string{"-12345"}, nil, []string{"APPLICATION"}, []string{"-12345"}),
+	}
+
+	dependencies := genTypeDependencies(typeNames, referencesPerType)
+
+	expected := map[string]map[string]bool{
+		"alerting-profile":             {"management-zone": true},
+		"application-web":              {},
+		"application-web-data-privacy": {"application-web": true},
+		"management-zone":              {"application-web": true, "application-web-data-privacy": true},
+	}
+
+	assert.DeepEqual(t, dependencies, expected)
+}
+
+func TestSortTypeWaves(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		typeNames    []string
+		dependencies map[string]map[string]bool
+		expected     [][]int
+	}{
+		{
+			"independent types are in a single wave",
+			[]string{"a", "b", "c"},
+			map[string]map[string]bool{},
+			[][]int{{0, 1, 2}},
+		},
+		{
+			"dependent types are in later waves",
+			[]string{"a", "b", "c", "d"},
+			map[string]map[string]bool{
+				"a": {"b": true},
+				"b": {"c": true},
+				"d": {"c": true},
+			},
+			[][]int{{2}, {1, 3}, {0}},
+		},
+		{
+			"circular types are in the last wave",
+			[]string{"a", "b", "c"},
+			map[string]map[string]bool{
+				"a": {"b": true},
+				"b": {"a": true},
+			},
+			[][]int{{2}, {0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waves := sortTypeWaves(tt.typeNames, tt.dependencies)
+			if !reflect.DeepEqual(waves, tt.expected) {
+				t.Errorf("sortTypeWaves() = %v, want %v", waves, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitConfigMatches(t *testing.T) {
+
+	entityIdMatches, configIdMatches := splitConfigMatches(map[string]string{
+		"APPLICATION-0123456789ABCDEF": "APPLICATION-FEDCBA9876543210",
+		"-12345":                       "-67890",
+		"APPLICATION-0000000000000000": "-11111",
+	})
+
+	assert.DeepEqual(t, entityIdMatches, map[string]string{"APPLICATION-0123456789ABCDEF": "APPLICATION-FEDCBA9876543210"})
+	assert.DeepEqual(t, configIdMatches, map[string]string{"-12345": "-67890"})
+}
+
+func TestReplaceConfigReferences(t *testing.T) {
+
+	conf := map[string]interface{}{
+		"value": map[string]interface{}{
+			"managementZoneId": "-12345",
+			"description":      "not -12345",
+			"rules": []interface{}{
+				"-12345",
+				map[string]interface{}{"id": "other"},
+			},
+		},
+	}
+
+	wasModified := replaceConfigReferences(conf, classic.ClassicIdKey, map[string]string{"-12345": "-67890"})
+
+	assert.Equal(t, wasModified, true)
+	assert.DeepEqual(t, conf, map[string]interface{}{
+		"value": map[string]interface{}{
+			"managementZoneId": "-67890",
+			"description":      "not -12345",
+			"rules": []interface{}{
+				"-67890",
+				map[string]interface{}{"id": "other"},
+			},
+		},
+	})
+
+	wasModified = replaceConfigReferences(conf, classic.ClassicIdKey, map[string]string{"unknown": "id"})
+	assert.Equal(t, wasModified, false)
+}
+
+func TestReplaceConfigReferences_KeepsSelfId(t *testing.T) {
+
+	conf := map[string]interface{}{
+		classic.ClassicIdKey: "dashboard-1",
+		"value": map[string]interface{}{
+			"id":   "dashboard-1",
+			"name": "dashboard-2",
+			"permissions": []interface{}{
+				map[string]interface{}{"dashboardId": "dashboard-1"},
+				map[string]interface{}{"dashboardId": "dashboard-2"},
+			},
+		},
+	}
+
+	wasModified := replaceConfigReferences(conf, classic.ClassicIdKey, map[string]string{"dashboard-1": "dashboard-9", "dashboard-2": "dashboard-8"})
+
+	assert.Equal(t, wasModified, true)
+	assert.DeepEqual(t, conf, map[string]interface{}{
+		classic.ClassicIdKey: "dashboard-1",
+		"value": map[string]interface{}{
+			"id":   "dashboard-1",
+			"name": "dashboard-2",
+			"permissions": []interface{}{
+				map[string]interface{}{"dashboardId": "dashboard-1"},
+				map[string]interface{}{"dashboardId": "dashboard-8"},
+			},
+		},
+	})
+}
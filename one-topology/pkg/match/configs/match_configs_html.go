@@ -0,0 +1,177 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
+	"github.com/spf13/afero"
+)
+
+// writeHtmlReport writes the match payload and the stats of the entity matches used as a static html file
+func writeHtmlReport(fs afero.Fs, matchParameters match.MatchParameters, matchPayload MatchPayload, entityMatches entities.MatchOutputPerType) error {
+	htmlReport := genHtmlReport(matchParameters, matchPayload, entityMatches)
+	htmlReport.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+
+	return report.WriteHtml(fs, matchParameters.HtmlReportFile, htmlReport)
+}
+
+type htmlEntityMatch struct {
+	entityType string
+	status     string
+	targetId   string
+}
+
+func genHtmlReport(matchParameters match.MatchParameters, matchPayload MatchPayload, entityMatches entities.MatchOutputPerType) report.HtmlReport {
+
+	htmlReport := report.HtmlReport{
+		Name:              matchParameters.Name,
+		MatchType:         matchParameters.Type,
+		SourceEnvironment: matchParameters.Source.Environment,
+		TargetEnvironment: matchParameters.Target.Environment,
+		EntityStats:       make([]report.EntityStats, 0, len(entityMatches)),
+		ConfigTypes:       make([]report.HtmlConfigType, 0, len(matchPayload.Modules)),
+	}
+
+	entityMatchesById := map[string]htmlEntityMatch{}
+	for entityType, matchOutputType := range entityMatches {
+		htmlReport.EntityStats = append(htmlReport.EntityStats, entities.GenEntityStats(entityType, matchOutputType))
+		addHtmlEntityMatches(entityMatchesById, entityType, matchOutputType)
+	}
+	report.SortEntityStats(htmlReport.EntityStats)
+
+	referencedBy := map[string][]report.HtmlLink{}
+	runeLabelMap := match.GetRuneLabelMap()
+
+	for _, module := range matchPayload.Modules {
+		data, ok := module["data"].(MatchEntityMatch)
+		if !ok {
+			continue
+		}
+
+		schemaId, _ := module["schemaId"].(string)
+		configType := report.HtmlConfigType{
+			Type:   schemaId,
+			Anchor: "type-" + schemaId,
+			Rows:   make([]report.HtmlConfigRow, 0, len(data)),
+		}
+		statusCounts := map[string]int{}
+
+		for _, entry := range data {
+			result, ok := entry.(map[string]string)
+			if !ok || result["status"] == "" {
+				continue
+			}
+
+			row := report.HtmlConfigRow{
+				Row:    genReportRow(result, runeLabelMap),
+				Anchor: report.GenConfigAnchor(schemaId, len(configType.Rows)),
+			}
+			statusCounts[row.Status] += 1
+
+			if row.SourceId != "" && row.TargetId != "" {
+				row.SourceJson = report.PrettyJson(result["data_main"])
+				row.TargetJson = report.PrettyJson(result["data_target"])
+			}
+
+			// the entity list of configs to Delete references target entities, they are not part of the entity matches
+			if row.SourceId != "" {
+				var entityList []string
+				json.Unmarshal([]byte(result["entity_list"]), &entityList)
+
+				for _, entityId := range entityList {
+					row.Entities = append(row.Entities, report.HtmlLink{Label: entityId, Anchor: report.GenEntityAnchor(entityId)})
+					referencedBy[entityId] = append(referencedBy[entityId], report.HtmlLink{Label: schemaId + ": " + row.SourceName, Anchor: row.Anchor})
+				}
+			}
+
+			configType.Rows = append(configType.Rows, row)
+		}
+
+		configType.Stats = genHtmlStats(statusCounts)
+		htmlReport.ConfigTypes = append(htmlReport.ConfigTypes, configType)
+	}
+
+	sort.Slice(htmlReport.ConfigTypes, func(i, j int) bool {
+		return htmlReport.ConfigTypes[i].Type < htmlReport.ConfigTypes[j].Type
+	})
+
+	htmlReport.Entities = genHtmlEntities(referencedBy, entityMatchesById)
+
+	return htmlReport
+}
+
+func addHtmlEntityMatches(entityMatchesById map[string]htmlEntityMatch, entityType string, matchOutputType entities.MatchOutputType) {
+	for entityIdSource, entityIdTarget := range matchOutputType.Matches {
+		entityMatchesById[entityIdSource] = htmlEntityMatch{entityType: entityType, status: report.StatusMatched, targetId: entityIdTarget}
+	}
+	for entityIdSource := range matchOutputType.MultiMatched {
+		entityMatchesById[entityIdSource] = htmlEntityMatch{entityType: entityType, status: report.StatusMulti}
+	}
+	for _, postProcessSource := range matchOutputType.PostProcessSource {
+		for _, entityIdSource := range postProcessSource.IDs {
+			entityMatchesById[entityIdSource] = htmlEntityMatch{entityType: entityType, status: report.StatusMulti}
+		}
+	}
+	for _, entityIdSource := range matchOutputType.UnMatched {
+		entityMatchesById[entityIdSource] = htmlEntityMatch{entityType: entityType, status: report.StatusUnMatched}
+	}
+}
+
+func genHtmlEntities(referencedBy map[string][]report.HtmlLink, entityMatchesById map[string]htmlEntityMatch) []report.HtmlEntity {
+
+	htmlEntities := make([]report.HtmlEntity, 0, len(referencedBy))
+
+	for entityId, configLinks := range referencedBy {
+		htmlEntity := report.HtmlEntity{
+			EntityId: entityId,
+			Anchor:   report.GenEntityAnchor(entityId),
+			Status:   report.StatusUnknown,
+			Configs:  configLinks,
+		}
+
+		if entityMatch, found := entityMatchesById[entityId]; found {
+			htmlEntity.Type = entityMatch.entityType
+			htmlEntity.Status = entityMatch.status
+			htmlEntity.TargetId = entityMatch.targetId
+		}
+
+		htmlEntities = append(htmlEntities, htmlEntity)
+	}
+
+	sort.Slice(htmlEntities, func(i, j int) bool {
+		return htmlEntities[i].EntityId < htmlEntities[j].EntityId
+	})
+
+	return htmlEntities
+}
+
+func genHtmlStats(statusCounts map[string]int) []report.HtmlStat {
+	stats := make([]report.HtmlStat, 0, len(statusCounts))
+	for label, count := range statusCounts {
+		stats = append(stats, report.HtmlStat{Label: label, Count: count})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Label < stats[j].Label
+	})
+
+	return stats
+}
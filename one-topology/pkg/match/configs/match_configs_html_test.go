@@ -0,0 +1,70 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
+	"gotest.tools/assert"
+)
+
+func TestGenHtmlReport(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"schemaId": "builtin:alerting.profile",
+				"data": MatchEntityMatch{
+					map[string]string{"status": "U", "key_id": "changed", "target_key_id": "changed", "monaco_type": "builtin:alerting.profile", "monaco_id": "s1", "target_id": "t1",
+						"data_main": `{"host":"HOST-1"}`, "data_target": `{"host":"HOST-9"}`, "entity_list": `["HOST-1","HOST-2"]`},
+					map[string]string{"status": "D", "key_id": "gone", "monaco_type": "builtin:alerting.profile", "monaco_id": "t2", "entity_list": `["HOST-8"]`},
+				},
+			},
+		},
+	}
+
+	entityMatches := entities.MatchOutputPerType{
+		"HOST": {Matches: map[string]string{"HOST-1": "HOST-9"}, UnMatched: []string{"HOST-3"}},
+	}
+
+	htmlReport := genHtmlReport(match.MatchParameters{Name: "test", Type: "configs"}, matchPayload, entityMatches)
+
+	assert.DeepEqual(t, htmlReport.EntityStats, []report.EntityStats{{Type: "HOST", Matched: 1, UnMatched: 1, Source: 2}})
+
+	assert.Equal(t, len(htmlReport.ConfigTypes), 1)
+	configType := htmlReport.ConfigTypes[0]
+	assert.DeepEqual(t, configType.Stats, []report.HtmlStat{{Label: match.ACTION_DELETE, Count: 1}, {Label: match.ACTION_UPDATE, Count: 1}})
+
+	updateRow := configType.Rows[0]
+	assert.Equal(t, updateRow.Anchor, "config-builtin:alerting.profile-0")
+	assert.Equal(t, updateRow.SourceJson, "{\n  \"host\": \"HOST-1\"\n}")
+	assert.Equal(t, updateRow.TargetJson, "{\n  \"host\": \"HOST-9\"\n}")
+	assert.DeepEqual(t, updateRow.Entities, []report.HtmlLink{{Label: "HOST-1", Anchor: "entity-HOST-1"}, {Label: "HOST-2", Anchor: "entity-HOST-2"}})
+
+	deleteRow := configType.Rows[1]
+	assert.Equal(t, deleteRow.SourceJson, "")
+	assert.Equal(t, len(deleteRow.Entities), 0)
+
+	configLink := report.HtmlLink{Label: "builtin:alerting.profile: changed", Anchor: updateRow.Anchor}
+	assert.DeepEqual(t, htmlReport.Entities, []report.HtmlEntity{
+		{EntityId: "HOST-1", Anchor: "entity-HOST-1", Type: "HOST", Status: report.StatusMatched, TargetId: "HOST-9", Configs: []report.HtmlLink{configLink}},
+		{EntityId: "HOST-2", Anchor: "entity-HOST-2", Status: report.StatusUnknown, Configs: []report.HtmlLink{configLink}},
+	})
+}
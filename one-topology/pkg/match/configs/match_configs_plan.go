@@ -0,0 +1,495 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	toposort "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/sort"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/cloudflare/ahocorasick"
+	"github.com/spf13/afero"
+)
+
+const planDir = "plan"
+const planFileName = "plan"
+
+// MigrationPlan is the ordered list of all the actions needed to migrate the source configs to the target
+type MigrationPlan struct {
+	Stats        map[string]int `json:"stats"`
+	Stages       []PlanStage    `json:"stages"`
+	Cycles       []PlanCycle    `json:"cycles"`
+	MultiMatched []PlanConfig   `json:"multiMatched"`
+}
+
+// planBuilder holds the actionable configs while the plan is sorted
+type planBuilder struct {
+	plan          MigrationPlan
+	configs       []PlanConfig
+	payloads      []string
+	entityLists   [][]string
+	entityMatches entities.MatchOutputPerType
+	targetIds     map[string]map[string]bool
+}
+
+// PlanStage groups configs that only depend on configs of previous stages and can be applied in parallel.
+// Delete stages are ordered so that a config is deleted before the configs it references.
+type PlanStage struct {
+	Stage   int          `json:"stage"`
+	Action  string       `json:"action"`
+	Configs []PlanConfig `json:"configs"`
+}
+
+// PlanConfig is a single action of the plan
+type PlanConfig struct {
+	Key       string       `json:"key"`
+	Action    string       `json:"action"`
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	SourceId  string       `json:"sourceId,omitempty"`
+	TargetId  string       `json:"targetId,omitempty"`
+	DependsOn []string     `json:"dependsOn"`
+	Entities  []PlanEntity `json:"entities,omitempty"`
+}
+
+// PlanEntity is an entity referenced by a config to Add or Update
+type PlanEntity struct {
+	EntityId string `json:"entityId"`
+	Matched  bool   `json:"matched"`
+}
+
+// PlanCycle marks a config that could not be ordered because of a circular dependency
+type PlanCycle struct {
+	Key       string   `json:"key"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func genPlanKey(configType string, configId string) string {
+	return configType + "/" + configId
+}
+
+// genMigrationPlan orders all the actionable configs of the match payload.
+// Adds and Updates come first, with every config after the configs and entities it references,
+// then Deletes, with every config before the configs it references.
+func genMigrationPlan(matchPayload MatchPayload, entityMatches entities.MatchOutputPerType) (MigrationPlan, error) {
+
+	builder := planBuilder{
+		plan: MigrationPlan{
+			Stats:        map[string]int{},
+			Stages:       []PlanStage{},
+			Cycles:       []PlanCycle{},
+			MultiMatched: []PlanConfig{},
+		},
+		configs:       []PlanConfig{},
+		payloads:      []string{},
+		entityLists:   [][]string{},
+		entityMatches: entityMatches,
+		targetIds:     map[string]map[string]bool{},
+	}
+
+	runeLabelMap := match.GetRuneLabelMap()
+
+	for _, module := range matchPayload.Modules {
+		data, ok := module["data"].(MatchEntityMatch)
+		if !ok {
+			continue
+		}
+
+		for _, entry := range data {
+			result, ok := entry.(map[string]string)
+			if !ok {
+				continue
+			}
+			err := builder.addResult(result, runeLabelMap)
+			if err != nil {
+				return MigrationPlan{}, err
+			}
+		}
+	}
+
+	builder.addStages(match.ACTION_ADD+"/"+match.ACTION_UPDATE, func(planConfig PlanConfig) bool {
+		return planConfig.Action != match.ACTION_DELETE
+	}, false)
+	builder.addStages(match.ACTION_DELETE, func(planConfig PlanConfig) bool {
+		return planConfig.Action == match.ACTION_DELETE
+	}, true)
+
+	return builder.plan, nil
+}
+
+func (builder *planBuilder) addResult(result map[string]string, runeLabelMap map[string]string) error {
+	status := result["status"]
+	configType := result["monaco_type"]
+
+	planConfig := PlanConfig{
+		Action:    runeLabelMap[status],
+		Type:      configType,
+		Name:      result["key_id"],
+		DependsOn: []string{},
+	}
+
+	switch status {
+	case string(match.ACTION_ADD_RUNE):
+		planConfig.SourceId = result["monaco_id"]
+		planConfig.Key = genPlanKey(configType, planConfig.SourceId)
+	case string(match.ACTION_UPDATE_RUNE):
+		planConfig.SourceId = result["monaco_id"]
+		planConfig.TargetId = result["target_id"]
+		planConfig.Key = genPlanKey(configType, planConfig.SourceId)
+	case string(match.ACTION_DELETE_RUNE):
+		planConfig.TargetId = result["monaco_id"]
+		planConfig.Key = genPlanKey(configType, planConfig.TargetId)
+	case string(match.ACTION_IDENTICAL_RUNE):
+		builder.plan.Stats[match.ACTION_IDENTICAL] += 1
+		return nil
+	case string(match.ACTION_INCOMPATIBLE_RUNE):
+		builder.plan.Stats[match.ACTION_INCOMPATIBLE] += 1
+		return nil
+	default:
+		planConfig.Action = match.STATUS_MULTI_MATCH
+		planConfig.SourceId = result["monaco_id"]
+		planConfig.TargetId = result["target_id"]
+		planConfig.Key = genPlanKey(configType, planConfig.SourceId)
+		builder.plan.MultiMatched = append(builder.plan.MultiMatched, planConfig)
+		builder.plan.Stats[match.STATUS_MULTI_MATCH] += 1
+		return nil
+	}
+
+	payload := result["data_main"]
+	if planConfig.Action == match.ACTION_DELETE {
+		payload = result["data_target"]
+	}
+
+	var entityList []string
+	if result["entity_list"] != "" {
+		err := json.Unmarshal([]byte(result["entity_list"]), &entityList)
+		if err != nil {
+			return fmt.Errorf("failed to parse the entity list of config %s, see error: %w", planConfig.Key, err)
+		}
+	}
+
+	builder.configs = append(builder.configs, planConfig)
+	builder.payloads = append(builder.payloads, payload)
+	builder.entityLists = append(builder.entityLists, entityList)
+	builder.plan.Stats[planConfig.Action] += 1
+
+	return nil
+}
+
+// addStages sorts the selected configs topologically and appends them to the plan as stages
+func (builder *planBuilder) addStages(label string, isSelected func(PlanConfig) bool, isReversed bool) {
+
+	selected := []int{}
+	for idx, planConfig := range builder.configs {
+		if isSelected(planConfig) {
+			selected = append(selected, idx)
+		}
+	}
+
+	if len(selected) == 0 {
+		return
+	}
+
+	// ids are searched quoted, so that only complete string values are considered references
+	idKeys := map[string][]int{}
+	quotedIds := []string{}
+	for nodeIdx, configIdx := range selected {
+		planConfig := builder.configs[configIdx]
+		for _, configId := range []string{planConfig.SourceId, planConfig.TargetId} {
+			if configId == "" {
+				continue
+			}
+			quotedId, err := json.Marshal(configId)
+			if err != nil {
+				continue
+			}
+			if _, found := idKeys[string(quotedId)]; !found {
+				quotedIds = append(quotedIds, string(quotedId))
+			}
+			idKeys[string(quotedId)] = append(idKeys[string(quotedId)], nodeIdx)
+		}
+	}
+
+	idMatcher := ahocorasick.NewStringMatcher(quotedIds)
+
+	// edges hold, for each node, the nodes it has to wait for
+	edges := make([]map[int]bool, len(selected))
+
+	for nodeIdx, configIdx := range selected {
+		planConfig := &builder.configs[configIdx]
+		dependencies := map[int]bool{}
+
+		for _, idx := range idMatcher.MatchThreadSafe([]byte(builder.payloads[configIdx])) {
+			for _, dependencyIdx := range idKeys[quotedIds[idx]] {
+				if dependencyIdx == nodeIdx {
+					continue
+				}
+				dependencies[dependencyIdx] = true
+			}
+		}
+
+		for dependencyIdx := range dependencies {
+			planConfig.DependsOn = append(planConfig.DependsOn, builder.configs[selected[dependencyIdx]].Key)
+
+			from, to := dependencyIdx, nodeIdx
+			if isReversed {
+				from, to = nodeIdx, dependencyIdx
+			}
+			if edges[to] == nil {
+				edges[to] = map[int]bool{}
+			}
+			edges[to][from] = true
+		}
+		sort.Strings(planConfig.DependsOn)
+
+		if !isReversed {
+			planConfig.Entities = builder.genPlanEntities(builder.entityLists[configIdx])
+		}
+	}
+
+	stages := builder.sortStages(label, selected, edges)
+
+	for _, stage := range stages {
+		if len(stage) == 0 {
+			continue
+		}
+		planStage := PlanStage{
+			Stage:   len(builder.plan.Stages) + 1,
+			Action:  label,
+			Configs: make([]PlanConfig, len(stage)),
+		}
+		for i, nodeIdx := range stage {
+			planStage.Configs[i] = builder.configs[selected[nodeIdx]]
+		}
+		sort.SliceStable(planStage.Configs, func(i, j int) bool {
+			return planStage.Configs[i].Key < planStage.Configs[j].Key
+		})
+		builder.plan.Stages = append(builder.plan.Stages, planStage)
+	}
+}
+
+// sortStages sorts the nodes topologically into stages.
+// Only the nodes that are part of a dependency are sorted, all the others are placed in the first stage.
+func (builder *planBuilder) sortStages(label string, selected []int, edges []map[int]bool) [][]int {
+
+	linkedIdx := map[int]int{}
+	linkedNodes := []int{}
+	addLinkedNode := func(nodeIdx int) {
+		if _, found := linkedIdx[nodeIdx]; !found {
+			linkedIdx[nodeIdx] = len(linkedNodes)
+			linkedNodes = append(linkedNodes, nodeIdx)
+		}
+	}
+
+	for to, froms := range edges {
+		for from := range froms {
+			addLinkedNode(to)
+			addLinkedNode(from)
+		}
+	}
+	sort.Ints(linkedNodes)
+	for i, nodeIdx := range linkedNodes {
+		linkedIdx[nodeIdx] = i
+	}
+
+	incomingEdges := make([][]int, len(linkedNodes))
+	for i, nodeIdx := range linkedNodes {
+		for from := range edges[nodeIdx] {
+			incomingEdges[i] = append(incomingEdges[i], linkedIdx[from])
+		}
+	}
+
+	linkedStages, errs := toposort.TopologySortStages(incomingEdges)
+
+	stages := [][]int{{}}
+	for nodeIdx := range selected {
+		if _, found := linkedIdx[nodeIdx]; !found {
+			stages[0] = append(stages[0], nodeIdx)
+		}
+	}
+	for i, linkedStage := range linkedStages {
+		if i >= len(stages) {
+			stages = append(stages, []int{})
+		}
+		for _, idx := range linkedStage {
+			stages[i] = append(stages[i], linkedNodes[idx])
+		}
+	}
+
+	if len(errs) > 0 {
+		cycleStage := make([]int, len(errs))
+		for i, err := range errs {
+			cycleStage[i] = linkedNodes[err.OnId]
+
+			dependsOn := make([]string, len(err.UnresolvedIncomingEdgesFrom))
+			for j, dependencyIdx := range err.UnresolvedIncomingEdgesFrom {
+				dependsOn[j] = builder.configs[selected[linkedNodes[dependencyIdx]]].Key
+			}
+			cycle := PlanCycle{Key: builder.configs[selected[cycleStage[i]]].Key, DependsOn: dependsOn}
+			builder.plan.Cycles = append(builder.plan.Cycles, cycle)
+
+			log.Warn("Circular dependency in the migration plan: %s still waits for %v, it is placed in the last %s stage", cycle.Key, cycle.DependsOn, label)
+		}
+		stages = append(stages, cycleStage)
+	}
+
+	return stages
+}
+
+func (builder *planBuilder) genPlanEntities(entityList []string) []PlanEntity {
+	if len(entityList) == 0 {
+		return nil
+	}
+
+	planEntities := make([]PlanEntity, 0, len(entityList))
+	entityIdDone := map[string]bool{}
+
+	for _, entityId := range entityList {
+		if entityIdDone[entityId] {
+			continue
+		}
+		entityIdDone[entityId] = true
+
+		planEntities = append(planEntities, PlanEntity{
+			EntityId: entityId,
+			Matched:  builder.isEntityMatched(entityId),
+		})
+	}
+
+	sort.Slice(planEntities, func(i, j int) bool {
+		return planEntities[i].EntityId < planEntities[j].EntityId
+	})
+
+	return planEntities
+}
+
+// isEntityMatched checks if an entity id, after replacement, exists on the target
+func (builder *planBuilder) isEntityMatched(entityId string) bool {
+	entityType, isEntityId := getEntityIdType(entityId)
+	if !isEntityId {
+		return false
+	}
+
+	matchOutputType, found := builder.entityMatches[entityType]
+	if !found {
+		return false
+	}
+
+	if _, found := matchOutputType.Matches[entityId]; found {
+		return true
+	}
+
+	targetIds, found := builder.targetIds[entityType]
+	if !found {
+		targetIds = make(map[string]bool, len(matchOutputType.Matches))
+		for _, entityIdTarget := range matchOutputType.Matches {
+			targetIds[entityIdTarget] = true
+		}
+		builder.targetIds[entityType] = targetIds
+	}
+
+	return targetIds[entityId]
+}
+
+func writeMigrationPlan(fs afero.Fs, matchParameters match.MatchParameters, plan MigrationPlan) error {
+
+	err := writeJsonMatchFile(fs, matchParameters.OutputDir, planDir, planFileName, plan)
+	if err != nil {
+		return err
+	}
+
+	fullPlanPath := filepath.Join(path.Join(matchParameters.OutputDir, planDir), fmt.Sprintf("%s.txt", planFileName))
+
+	err = afero.WriteFile(fs, fullPlanPath, []byte(plan.String()), 0664)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Wrote migration plan with %d configs in %d stages", plan.countConfigs(), len(plan.Stages))
+
+	return nil
+}
+
+func (plan MigrationPlan) countConfigs() int {
+	count := 0
+	for _, stage := range plan.Stages {
+		count += len(stage.Configs)
+	}
+	return count
+}
+
+// String renders the plan for human review
+func (plan MigrationPlan) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Migration plan: %d configs in %d stages\n", plan.countConfigs(), len(plan.Stages))
+	for _, action := range []string{match.ACTION_ADD, match.ACTION_UPDATE, match.ACTION_DELETE, match.ACTION_IDENTICAL, match.ACTION_INCOMPATIBLE, match.STATUS_MULTI_MATCH} {
+		fmt.Fprintf(&sb, "  %-14s %d\n", action+":", plan.Stats[action])
+	}
+
+	for _, stage := range plan.Stages {
+		fmt.Fprintf(&sb, "\nStage %d (%s, %d configs)\n", stage.Stage, stage.Action, len(stage.Configs))
+
+		for _, planConfig := range stage.Configs {
+			ids := planConfig.SourceId
+			if planConfig.TargetId != "" && planConfig.TargetId != planConfig.SourceId {
+				if ids == "" {
+					ids = planConfig.TargetId
+				} else {
+					ids += " -> " + planConfig.TargetId
+				}
+			}
+
+			fmt.Fprintf(&sb, "  %-8s %-50s %q (%s)\n", planConfig.Action, planConfig.Type, planConfig.Name, ids)
+
+			if len(planConfig.DependsOn) > 0 {
+				fmt.Fprintf(&sb, "           depends on: %s\n", strings.Join(planConfig.DependsOn, ", "))
+			}
+
+			unmatched := []string{}
+			for _, planEntity := range planConfig.Entities {
+				if !planEntity.Matched {
+					unmatched = append(unmatched, planEntity.EntityId)
+				}
+			}
+			if len(unmatched) > 0 {
+				fmt.Fprintf(&sb, "           unmatched entities: %s\n", strings.Join(unmatched, ", "))
+			}
+		}
+	}
+
+	if len(plan.Cycles) > 0 {
+		fmt.Fprintf(&sb, "\nCircular dependencies (%d)\n", len(plan.Cycles))
+		for _, cycle := range plan.Cycles {
+			fmt.Fprintf(&sb, "  %s waits for %s\n", cycle.Key, strings.Join(cycle.DependsOn, ", "))
+		}
+	}
+
+	if len(plan.MultiMatched) > 0 {
+		fmt.Fprintf(&sb, "\nMulti matched, to be resolved before applying (%d)\n", len(plan.MultiMatched))
+		for _, planConfig := range plan.MultiMatched {
+			fmt.Fprintf(&sb, "  %-50s %q (%s -> %s)\n", planConfig.Type, planConfig.Name, planConfig.SourceId, planConfig.TargetId)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"gotest.tools/assert"
+)
+
+func genTestPlanResult(status rune, configType string, configId string, targetId string, payload string, entityList string) map[string]string {
+	result := map[string]string{
+		"status":      string(status),
+		"key_id":      configId,
+		"monaco_type": configType,
+		"monaco_id":   configId,
+		"target_id":   targetId,
+		"entity_list": entityList,
+	}
+	if status == match.ACTION_DELETE_RUNE {
+		result["data_target"] = payload
+	} else {
+		result["data_main"] = payload
+	}
+	return result
+}
+
+func getPlanStageKeys(plan MigrationPlan) [][]string {
+	stageKeys := [][]string{}
+	for _, stage := range plan.Stages {
+		keys := []string{}
+		for _, planConfig := range stage.Configs {
+			keys = append(keys, planConfig.Key)
+		}
+		stageKeys = append(stageKeys, keys)
+	}
+	return stageKeys
+}
+
+func TestGenMigrationPlan(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"data": MatchEntityMatch{
+					genTestPlanResult(match.ACTION_ADD_RUNE, "management-zone", "-1", "", `{"name":"mz"}`, `[]`),
+					genTestPlanResult(match.ACTION_UPDATE_RUNE, "alerting-profile", "a1", "b1", `{"managementZone":"-1"}`, `[]`),
+					genTestPlanResult(match.ACTION_ADD_RUNE, "notification", "n1", "", `{"alertingProfile":"a1","host":"HOST-0123456789ABCDEF"}`, `["HOST-0123456789ABCDEF","HOST-FEDCBA9876543210"]`),
+					genTestPlanResult(match.ACTION_IDENTICAL_RUNE, "dashboard", "d1", "d1", `{}`, `[]`),
+					genTestPlanResult(match.ACTION_DELETE_RUNE, "management-zone", "-2", "", `{"name":"old"}`, `[]`),
+					genTestPlanResult(match.ACTION_DELETE_RUNE, "alerting-profile", "a2", "", `{"managementZone":"-2"}`, `[]`),
+					map[string]string{"status": "U, M", "key_id": "m1", "monaco_type": "dashboard", "monaco_id": "m1", "target_id": "m2"},
+				},
+			},
+		},
+	}
+
+	entityMatches := entities.MatchOutputPerType{
+		"HOST": {Matches: map[string]string{"HOST-0123456789ABCDEF": "HOST-AAAAAAAAAAAAAAAA"}},
+	}
+
+	plan, err := genMigrationPlan(matchPayload, entityMatches)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, getPlanStageKeys(plan), [][]string{
+		{"management-zone/-1"},
+		{"alerting-profile/a1"},
+		{"notification/n1"},
+		{"alerting-profile/a2"},
+		{"management-zone/-2"},
+	})
+	assert.DeepEqual(t, plan.Stats, map[string]int{
+		match.ACTION_ADD:         2,
+		match.ACTION_UPDATE:      1,
+		match.ACTION_DELETE:      2,
+		match.ACTION_IDENTICAL:   1,
+		match.STATUS_MULTI_MATCH: 1,
+	})
+
+	notification := plan.Stages[2].Configs[0]
+	assert.DeepEqual(t, notification.DependsOn, []string{"alerting-profile/a1"})
+	assert.DeepEqual(t, notification.Entities, []PlanEntity{
+		{EntityId: "HOST-0123456789ABCDEF", Matched: true},
+		{EntityId: "HOST-FEDCBA9876543210", Matched: false},
+	})
+	assert.Equal(t, plan.Stages[1].Configs[0].TargetId, "b1")
+	assert.Equal(t, len(plan.MultiMatched), 1)
+	assert.Equal(t, len(plan.Cycles), 0)
+}
+
+func TestGenMigrationPlanCycle(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"data": MatchEntityMatch{
+					genTestPlanResult(match.ACTION_ADD_RUNE, "a", "1", "", `{"ref":"2"}`, `[]`),
+					genTestPlanResult(match.ACTION_ADD_RUNE, "b", "2", "", `{"ref":"1"}`, `[]`),
+					genTestPlanResult(match.ACTION_ADD_RUNE, "c", "3", "", `{}`, `[]`),
+				},
+			},
+		},
+	}
+
+	plan, err := genMigrationPlan(matchPayload, entities.MatchOutputPerType{})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, getPlanStageKeys(plan), [][]string{
+		{"c/3"},
+		{"a/1", "b/2"},
+	})
+	assert.Equal(t, len(plan.Cycles), 2)
+}
+
+func TestGenMigrationPlanInvalidEntityList(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"data": MatchEntityMatch{
+					genTestPlanResult(match.ACTION_ADD_RUNE, "a", "1", "", `{}`, `["HOST-0123456789ABCDEF"`),
+				},
+			},
+		},
+	}
+
+	_, err := genMigrationPlan(matchPayload, entities.MatchOutputPerType{})
+	assert.ErrorContains(t, err, "failed to parse the entity list of config a/1")
+}
@@ -0,0 +1,179 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
+	valueParam "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/template"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/classic"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/processing"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
+	"github.com/spf13/afero"
+)
+
+const emittedManifestFileName = "manifest.yaml"
+
+// genProjectConfigs converts the translated source configs of a type to Monaco configs deployable to the target environment.
+// Matched configs keep the id of their target config, so that they update it instead of creating a copy.
+func genProjectConfigs(matchParameters match.MatchParameters, configProcessingPtr *processing.MatchProcessing, configTypeInfo configTypeInfo, configMatches MatchOutputType, configIdxToWriteSource []bool) ([]config.Config, error) {
+
+	projectId := getEmittedProjectId(matchParameters)
+	targetEnv := matchParameters.Target.Manifest.Environments[matchParameters.Target.Environment]
+	_, isSettings := getConfigTypeInfo(configTypeInfo.configType)
+
+	values := *configProcessingPtr.Source.RawMatchList.GetValuesConfig()
+	configs := make([]config.Config, 0, len(values))
+
+	for idx, conf := range values {
+		if configIdxToWriteSource != nil && !configIdxToWriteSource[idx] {
+			continue
+		}
+
+		confMap := conf.(map[string]interface{})
+		downloaded := confMap[rules.DownloadedKey].(map[string]interface{})
+
+		configId := confMap[rules.ConfigIdKey].(string)
+		targetId, isMatched := configMatches.Matches[configId]
+		if isMatched {
+			configId = targetId
+		}
+
+		name, ok := confMap[rules.ConfigNameKey].(string)
+		if !ok || name == "" {
+			name = configId
+		}
+
+		// the value is copied, as sanitizing it would modify the cached source config
+		valueRaw, err := json.Marshal(downloaded[rules.ValueKey])
+		if err != nil {
+			return nil, err
+		}
+		var value map[string]interface{}
+		err = json.Unmarshal(valueRaw, &value)
+		if err != nil {
+			return nil, err
+		}
+
+		params := config.Parameters{
+			config.NameParameter: &valueParam.ValueParameter{Value: name},
+		}
+
+		var configType config.Type
+		if isSettings {
+			schemaVersion, _ := downloaded["schemaVersion"].(string)
+			configType = config.SettingsType{
+				SchemaId:      configTypeInfo.configTypeString,
+				SchemaVersion: schemaVersion,
+			}
+
+			scope, _ := downloaded["scope"].(string)
+			params[config.ScopeParameter] = &valueParam.ValueParameter{Value: scope}
+		} else {
+			configType = config.ClassicApiType{Api: configTypeInfo.configTypeString}
+			value = classic.SanitizeProperties(value, configTypeInfo.configTypeString)
+		}
+
+		content, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		originObjectId := ""
+		if isMatched {
+			originObjectId = targetId
+		}
+
+		configs = append(configs, config.Config{
+			Template: template.NewDownloadTemplate(configId, name, string(content)),
+			Coordinate: coordinate.Coordinate{
+				Project:  projectId,
+				Type:     configTypeInfo.configTypeString,
+				ConfigId: configId,
+			},
+			Group:          targetEnv.Group,
+			Environment:    matchParameters.Target.Environment,
+			Type:           configType,
+			Parameters:     params,
+			Skip:           false,
+			OriginObjectId: originObjectId,
+		})
+	}
+
+	return configs, nil
+}
+
+func getEmittedProjectId(matchParameters match.MatchParameters) string {
+	projectId := config.Sanitize(matchParameters.Source.Project)
+	if projectId == "" {
+		projectId = "project"
+	}
+	return projectId
+}
+
+// writeEmittedProject writes the translated configs as a Monaco project, with a manifest pointing at the target environment
+func writeEmittedProject(fs afero.Fs, matchParameters match.MatchParameters, configs []config.Config) error {
+
+	projectId := getEmittedProjectId(matchParameters)
+	outputDir := filepath.Clean(matchParameters.EmitProjectDir)
+
+	errs := config.WriteConfigs(&config.WriterContext{
+		Fs:              fs,
+		OutputFolder:    outputDir,
+		ProjectFolder:   projectId,
+		ParametersSerde: config.DefaultParameterParsers,
+	}, configs)
+	if len(errs) > 0 {
+		return errutils.PrintAndFormatErrors(errs, "failed to write the emitted project")
+	}
+
+	targetEnv, found := matchParameters.Target.Manifest.Environments[matchParameters.Target.Environment]
+	if !found {
+		return fmt.Errorf("target environment %s not found in its manifest", matchParameters.Target.Environment)
+	}
+
+	projectManifest := manifest.Manifest{
+		Projects: manifest.ProjectDefinitionByProjectID{
+			projectId: {
+				Name: projectId,
+				Path: projectId,
+			},
+		},
+		Environments: map[string]manifest.EnvironmentDefinition{
+			matchParameters.Target.Environment: targetEnv,
+		},
+	}
+
+	err := manifest.WriteManifest(&manifest.WriterContext{
+		Fs:           fs,
+		ManifestPath: filepath.Join(outputDir, emittedManifestFileName),
+	}, projectManifest)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Wrote %d translated configs as project %s to '%s'", len(configs), projectId, outputDir)
+
+	return nil
+}
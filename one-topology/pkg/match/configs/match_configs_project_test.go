@@ -0,0 +1,128 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"path/filepath"
+	"testing"
+
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	valueParam "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/processing"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func genTestProjectMatchParameters(emitProjectDir string) match.MatchParameters {
+	return match.MatchParameters{
+		EmitProjectDir: emitProjectDir,
+		Source: match.MatchParametersEnv{
+			Project: "source",
+		},
+		Target: match.MatchParametersEnv{
+			Environment: "target-env",
+			Manifest: manifest.Manifest{
+				Environments: map[string]manifest.EnvironmentDefinition{
+					"target-env": {
+						Name:  "target-env",
+						Group: "default",
+						URL:   manifest.URLDefinition{Value: "https://target.live.dynatrace.com"},
+						Auth:  manifest.Auth{Token: manifest.AuthSecret{Name: "TARGET_TOKEN"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func genTestSettingsValue(objectId string, name string, scope string) interface{} {
+	return map[string]interface{}{
+		rules.ConfigIdKey:   objectId,
+		rules.ConfigNameKey: name,
+		rules.DownloadedKey: map[string]interface{}{
+			SettingsIdKey:   objectId,
+			"schemaVersion": "1.2.3",
+			"scope":         scope,
+			rules.ValueKey:  map[string]interface{}{"name": name},
+		},
+	}
+}
+
+func TestGenProjectConfigs(t *testing.T) {
+
+	configType := config.SettingsType{SchemaId: "builtin:alerting.profile"}
+	sourceValues := []interface{}{
+		genTestSettingsValue("source-1", "matched", "environment"),
+		genTestSettingsValue("source-2", "added", "HOST-0123456789ABCDEF"),
+		genTestSettingsValue("source-3", "skipped", "environment"),
+	}
+
+	configProcessingPtr := processing.NewMatchProcessing(
+		&RawConfigsList{Values: &sourceValues}, configType,
+		&RawConfigsList{Values: &[]interface{}{}}, configType)
+
+	configMatches := MatchOutputType{
+		Matches: map[string]string{"source-1": "target-1"},
+	}
+
+	configs, err := genProjectConfigs(genTestProjectMatchParameters("out"), configProcessingPtr,
+		configTypeInfo{configTypeString: "builtin:alerting.profile", configType: configType},
+		configMatches, []bool{true, true, false})
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(configs), 2)
+
+	assert.Equal(t, configs[0].Coordinate.ConfigId, "target-1")
+	assert.Equal(t, configs[0].OriginObjectId, "target-1")
+	assert.Equal(t, configs[0].Environment, "target-env")
+	assert.DeepEqual(t, configs[0].Type, config.SettingsType{SchemaId: "builtin:alerting.profile", SchemaVersion: "1.2.3"})
+
+	assert.Equal(t, configs[1].Coordinate.ConfigId, "source-2")
+	assert.Equal(t, configs[1].OriginObjectId, "")
+	assert.DeepEqual(t, configs[1].Parameters[config.ScopeParameter], &valueParam.ValueParameter{Value: "HOST-0123456789ABCDEF"})
+}
+
+func TestWriteEmittedProject(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	matchParameters := genTestProjectMatchParameters("out")
+
+	configType := config.SettingsType{SchemaId: "builtin:alerting.profile"}
+	sourceValues := []interface{}{
+		genTestSettingsValue("source-1", "added", "environment"),
+	}
+	configProcessingPtr := processing.NewMatchProcessing(
+		&RawConfigsList{Values: &sourceValues}, configType,
+		&RawConfigsList{Values: &[]interface{}{}}, configType)
+
+	configs, err := genProjectConfigs(matchParameters, configProcessingPtr,
+		configTypeInfo{configTypeString: "builtin:alerting.profile", configType: configType},
+		MatchOutputType{}, nil)
+	assert.NilError(t, err)
+
+	err = writeEmittedProject(fs, matchParameters, configs)
+	assert.NilError(t, err)
+
+	exists, _ := afero.Exists(fs, filepath.Join("out", emittedManifestFileName))
+	assert.Assert(t, exists, "the manifest should be written")
+
+	exists, _ = afero.DirExists(fs, filepath.Join("out", "source", "builtinalerting.profile"))
+	assert.Assert(t, exists, "the configs should be written in the project folder")
+}
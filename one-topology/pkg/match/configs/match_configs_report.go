@@ -0,0 +1,90 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
+	"github.com/spf13/afero"
+)
+
+// writeReport writes a report sheet per config type of the match payload
+func writeReport(fs afero.Fs, matchParameters match.MatchParameters, matchPayload MatchPayload) error {
+	return report.Write(fs, matchParameters.OutputDir, matchParameters.ReportFormat, genReportSheets(matchPayload))
+}
+
+func genReportSheets(matchPayload MatchPayload) []report.Sheet {
+
+	runeLabelMap := match.GetRuneLabelMap()
+	sheets := make([]report.Sheet, 0, len(matchPayload.Modules))
+
+	for _, module := range matchPayload.Modules {
+		data, ok := module["data"].(MatchEntityMatch)
+		if !ok {
+			continue
+		}
+
+		schemaId, _ := module["schemaId"].(string)
+		sheet := report.Sheet{Name: schemaId, IsConfig: true, Rows: make([]report.Row, 0, len(data))}
+
+		for _, entry := range data {
+			result, ok := entry.(map[string]string)
+			if !ok || result["status"] == "" {
+				continue
+			}
+
+			sheet.Rows = append(sheet.Rows, genReportRow(result, runeLabelMap))
+		}
+
+		sheets = append(sheets, sheet)
+	}
+
+	return sheets
+}
+
+func genReportRow(result map[string]string, runeLabelMap map[string]string) report.Row {
+
+	status := result["status"]
+	action := status[0:1]
+	isMultiMatched := strings.HasSuffix(status, string(match.STATUS_MULTI_MATCH_RUNE)) && len(status) > 1
+
+	row := report.Row{
+		Status:    runeLabelMap[action],
+		MatchTier: result["match_tier"],
+	}
+
+	if action == string(match.ACTION_DELETE_RUNE) {
+		row.TargetId = result["monaco_id"]
+		row.TargetName = result["key_id"]
+		return row
+	}
+
+	row.SourceId = result["monaco_id"]
+	row.SourceName = result["key_id"]
+	row.TargetId = result["target_id"]
+	row.TargetName = result["target_key_id"]
+
+	if isMultiMatched {
+		row.Status = report.StatusMulti
+	}
+
+	if action == string(match.ACTION_UPDATE_RUNE) || isMultiMatched {
+		row.DiffSummary = report.DiffSummary(result["data_main"], result["data_target"])
+	}
+
+	return row
+}
@@ -0,0 +1,57 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
+	"gotest.tools/assert"
+)
+
+func TestGenReportSheets(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"schemaId": "builtin:alerting.profile",
+				"data": MatchEntityMatch{
+					map[string]string{"status": "A", "key_id": "new", "monaco_type": "builtin:alerting.profile", "monaco_id": "s1"},
+					map[string]string{"status": "U", "key_id": "changed", "target_key_id": "changed", "monaco_type": "builtin:alerting.profile", "monaco_id": "s2", "target_id": "t2", "match_tier": "1: Name",
+						"data_main": `{"name":"changed","severity":"HIGH","extra":1}`, "data_target": `{"name":"changed","severity":"LOW","other":2}`},
+					map[string]string{"status": "D", "key_id": "gone", "monaco_type": "builtin:alerting.profile", "monaco_id": "t3"},
+					map[string]string{"status": "I, M", "key_id": "twice", "target_key_id": "twice", "monaco_type": "builtin:alerting.profile", "monaco_id": "s4", "target_id": "t4",
+						"data_main": `{"name":"twice"}`, "data_target": `{"name":"twice"}`},
+				},
+			},
+		},
+	}
+
+	sheets := genReportSheets(matchPayload)
+
+	assert.Equal(t, len(sheets), 1)
+	assert.Equal(t, sheets[0].Name, "builtin:alerting.profile")
+	assert.Equal(t, sheets[0].IsConfig, true)
+	assert.DeepEqual(t, sheets[0].Rows, []report.Row{
+		{SourceId: "s1", SourceName: "new", Status: match.ACTION_ADD},
+		{SourceId: "s2", SourceName: "changed", TargetId: "t2", TargetName: "changed", Status: match.ACTION_UPDATE, MatchTier: "1: Name",
+			DiffSummary: "changed: severity; only in source: extra; only in target: other"},
+		{TargetId: "t3", TargetName: "gone", Status: match.ACTION_DELETE},
+		{SourceId: "s4", SourceName: "twice", TargetId: "t4", TargetName: "twice", Status: report.StatusMulti},
+	})
+}
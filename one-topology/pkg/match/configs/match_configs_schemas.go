@@ -0,0 +1,159 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/settings"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/processing"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
+	"github.com/spf13/afero"
+)
+
+// schemaCompatibility holds the settings 2.0 schema versions of the source and the target environments
+type schemaCompatibility struct {
+	source settings.SchemaVersions
+	target settings.SchemaVersions
+}
+
+// loadSchemaCompatibility reads the schema versions persisted by the download of both projects.
+// The compatibility is not checked if any of the projects was downloaded without schema versions
+func loadSchemaCompatibility(fs afero.Fs, matchParameters match.MatchParameters) (schemaCompatibility, error) {
+	source, foundSource, err := settings.LoadSchemaVersions(fs, matchParameters.Source.ProjectFolder())
+	if err != nil {
+		return schemaCompatibility{}, err
+	}
+
+	target, foundTarget, err := settings.LoadSchemaVersions(fs, matchParameters.Target.ProjectFolder())
+	if err != nil {
+		return schemaCompatibility{}, err
+	}
+
+	if !foundSource || !foundTarget {
+		log.Debug("No schema versions downloaded for the source or the target project, schema compatibility will not be checked")
+		return schemaCompatibility{}, nil
+	}
+
+	return schemaCompatibility{source: source, target: target}, nil
+}
+
+// isIncompatible returns true with the reason when the settings 2.0 schema of the config type is missing
+// or of a different major version on the target
+func (c schemaCompatibility) isIncompatible(configsTypeInfo configTypeInfo) (bool, string) {
+	if c.source == nil || c.target == nil {
+		return false, ""
+	}
+
+	settingsType, isSettings := configsTypeInfo.configType.(config.SettingsType)
+	if !isSettings {
+		return false, ""
+	}
+
+	targetVersion, found := c.target[settingsType.SchemaId]
+	if !found {
+		return true, "missing on target"
+	}
+
+	sourceVersion, found := c.source[settingsType.SchemaId]
+	if !found {
+		return false, ""
+	}
+
+	if settings.MajorVersion(sourceVersion) != settings.MajorVersion(targetVersion) {
+		return true, fmt.Sprintf("version %s on source, %s on target", sourceVersion, targetVersion)
+	}
+
+	return false, ""
+}
+
+// genAction replaces an Add or Update action by the Incompatible action when the schema of the config type is incompatible
+func genAction(configsTypeInfo configTypeInfo, action rune) rune {
+	if !configsTypeInfo.incompatible {
+		return action
+	}
+
+	if action == match.ACTION_ADD_RUNE || action == match.ACTION_UPDATE_RUNE {
+		return match.ACTION_INCOMPATIBLE_RUNE
+	}
+
+	return action
+}
+
+// loadSchemaDefinitions reads the schema definitions downloaded into the source and the target projects.
+// The definitions of the target, where the translated configs are applied, take precedence
+func loadSchemaDefinitions(fs afero.Fs, matchParameters match.MatchParameters) (map[string]settings.SchemaDefinition, error) {
+	definitions, err := settings.LoadSchemaDefinitions(fs, matchParameters.Source.ProjectFolder())
+	if err != nil {
+		return nil, err
+	}
+
+	targetDefinitions, err := settings.LoadSchemaDefinitions(fs, matchParameters.Target.ProjectFolder())
+	if err != nil {
+		return nil, err
+	}
+
+	for schemaId, definition := range targetDefinitions {
+		definitions[schemaId] = definition
+	}
+
+	return definitions, nil
+}
+
+// isOrderedList returns true if the schema of the type defines the property as a list, whose order matters.
+// Without schema definition, all arrays are compared regardless of their order
+func (c configTypeInfo) isOrderedList(property string) bool {
+	return c.schema != nil && c.schema.IsOrderedList(property)
+}
+
+// validateSourceConfigs validates the translated source configs to write against the schema definition of their type,
+// and warns about the invalid ones and the ones holding secrets
+func validateSourceConfigs(configProcessingPtr *processing.MatchProcessing, configsTypeInfo configTypeInfo, configIdxToWriteSource []bool) {
+	if configsTypeInfo.schema == nil {
+		return
+	}
+
+	invalidCount := 0
+	for idx, conf := range *configProcessingPtr.Source.RawMatchList.GetValuesConfig() {
+		if configIdxToWriteSource != nil && !configIdxToWriteSource[idx] {
+			continue
+		}
+
+		confMap := conf.(map[string]interface{})
+		value, ok := confMap[rules.DownloadedKey].(map[string]interface{})[rules.ValueKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		configId, _ := confMap[rules.ConfigIdKey].(string)
+
+		if problems := configsTypeInfo.schema.Validate(value); len(problems) > 0 {
+			log.Warn("Translated config %s of type %s does not match its schema: %s", configId, configsTypeInfo.configTypeString, strings.Join(problems, ", "))
+			invalidCount++
+		}
+
+		if secrets := configsTypeInfo.schema.SecretPaths(value); len(secrets) > 0 {
+			log.Warn("Translated config %s of type %s holds secrets: %s", configId, configsTypeInfo.configTypeString, strings.Join(secrets, ", "))
+		}
+	}
+
+	if invalidCount > 0 {
+		summary.SetCount(configsTypeInfo.configTypeString, "Invalid", invalidCount)
+	}
+}
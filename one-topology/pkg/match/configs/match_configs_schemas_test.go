@@ -0,0 +1,97 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download/settings"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestSchemaCompatibility(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	matchParameters := match.MatchParameters{
+		Source: match.MatchParametersEnv{WorkingDir: "source", Project: "proj"},
+		Target: match.MatchParametersEnv{WorkingDir: "target", Project: "proj"},
+	}
+
+	compatibility, err := loadSchemaCompatibility(fs, matchParameters)
+	assert.NilError(t, err)
+	incompatible, _ := compatibility.isIncompatible(configTypeInfo{configTypeString: "builtin:a", configType: config.SettingsType{SchemaId: "builtin:a"}})
+	assert.Equal(t, incompatible, false, "compatibility is not checked without schema versions")
+
+	err = settings.WriteSchemaVersions(fs, "source/proj", settings.NewSchemaVersions(client.SchemaList{
+		{SchemaId: "builtin:a", LatestSchemaVersion: "1.2.0"},
+		{SchemaId: "builtin:b", LatestSchemaVersion: "1.0.3"},
+		{SchemaId: "builtin:c", LatestSchemaVersion: "2.1"},
+	}))
+	assert.NilError(t, err)
+	err = settings.WriteSchemaVersions(fs, "target/proj", settings.NewSchemaVersions(client.SchemaList{
+		{SchemaId: "builtin:a", LatestSchemaVersion: "1.5.1"},
+		{SchemaId: "builtin:c", LatestSchemaVersion: "3.0"},
+	}))
+	assert.NilError(t, err)
+
+	compatibility, err = loadSchemaCompatibility(fs, matchParameters)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name             string
+		configType       config.Type
+		wantIncompatible bool
+		wantReason       string
+	}{
+		{"same major version", config.SettingsType{SchemaId: "builtin:a"}, false, ""},
+		{"missing on target", config.SettingsType{SchemaId: "builtin:b"}, true, "missing on target"},
+		{"different major version", config.SettingsType{SchemaId: "builtin:c"}, true, "version 2.1 on source, 3.0 on target"},
+		{"classic api", config.ClassicApiType{Api: "dashboard"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incompatible, reason := compatibility.isIncompatible(configTypeInfo{configTypeString: "type", configType: tt.configType})
+			assert.Equal(t, incompatible, tt.wantIncompatible)
+			assert.Equal(t, reason, tt.wantReason)
+		})
+	}
+}
+
+func TestMatchConfigsFailsEarlyOnInvalidSchemaVersions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	matchParameters := match.MatchParameters{
+		Source: match.MatchParametersEnv{WorkingDir: "source", Project: "proj"},
+		Target: match.MatchParametersEnv{WorkingDir: "target", Project: "proj"},
+	}
+	err := afero.WriteFile(fs, "source/proj/"+settings.SchemaVersionsFileName, []byte("not json"), 0644)
+	assert.NilError(t, err)
+
+	_, _, _, err = MatchConfigs(fs, matchParameters, nil, nil)
+	assert.ErrorContains(t, err, "failed to parse the schema versions")
+}
+
+func TestGenAction(t *testing.T) {
+	incompatible := configTypeInfo{configTypeString: "builtin:c", incompatible: true}
+
+	assert.Equal(t, genAction(incompatible, match.ACTION_ADD_RUNE), match.ACTION_INCOMPATIBLE_RUNE)
+	assert.Equal(t, genAction(incompatible, match.ACTION_UPDATE_RUNE), match.ACTION_INCOMPATIBLE_RUNE)
+	assert.Equal(t, genAction(incompatible, match.ACTION_DELETE_RUNE), match.ACTION_DELETE_RUNE)
+	assert.Equal(t, genAction(configTypeInfo{configTypeString: "builtin:a"}, match.ACTION_ADD_RUNE), match.ACTION_ADD_RUNE)
+}
@@ -0,0 +1,60 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/rules"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/scope"
+	"gotest.tools/assert"
+)
+
+func TestIsConfigInScope(t *testing.T) {
+	selector, err := scope.NewSelector(scope.Definition{
+		ConfigNamePattern: "^prod",
+		SettingsScopes:    []string{"HOST-"},
+	})
+	assert.NilError(t, err)
+
+	matchParameters := match.MatchParameters{SourceSelector: selector}
+
+	genRefMap := func(name string, settingsScope string) map[string]interface{} {
+		return map[string]interface{}{
+			rules.ConfigNameKey: name,
+			rules.DownloadedKey: map[string]interface{}{
+				"scope": settingsScope,
+			},
+		}
+	}
+
+	assert.Assert(t, isConfigInScope(matchParameters, genRefMap("prod-alerting", "HOST-1")))
+	assert.Assert(t, !isConfigInScope(matchParameters, genRefMap("dev-alerting", "HOST-1")))
+	assert.Assert(t, !isConfigInScope(matchParameters, genRefMap("prod-alerting", "environment")))
+	assert.Assert(t, isConfigInScope(match.MatchParameters{}, genRefMap("dev-alerting", "environment")))
+}
+
+func TestAddOutOfScope(t *testing.T) {
+	module := Module{}
+
+	addOutOfScope(&module, "config-1")
+	addOutOfScope(&module, "config-1")
+	addOutOfScope(&module, "config-2")
+
+	assert.DeepEqual(t, module[outOfScopeKey], []string{"config-1", "config-2"})
+}
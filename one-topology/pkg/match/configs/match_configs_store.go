@@ -0,0 +1,148 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/store"
+	"github.com/spf13/afero"
+)
+
+// writeMatchStore writes the configs, matches and statuses of the match payload into the SQLite store,
+// with the entity matches used and the entities referenced by the plan that do not exist in the target
+func writeMatchStore(fs afero.Fs, matchParameters match.MatchParameters, matchPayload MatchPayload, entityMatches entities.MatchOutputPerType, plan MigrationPlan) error {
+
+	matchStore, err := store.CreateForMatch(fs, matchParameters)
+	if err != nil {
+		return err
+	}
+	defer matchStore.Close()
+
+	runeLabelMap := match.GetRuneLabelMap()
+
+	for _, module := range matchPayload.Modules {
+		data, ok := module["data"].(MatchEntityMatch)
+		if !ok {
+			continue
+		}
+
+		for _, entry := range data {
+			result, ok := entry.(map[string]string)
+			if !ok {
+				continue
+			}
+
+			err = writeStoreResult(matchStore, result, runeLabelMap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for entityType, matchOutputType := range entityMatches {
+		for entityIdSource, entityIdTarget := range matchOutputType.Matches {
+			err = matchStore.WriteMatch(store.Match{Kind: store.KindEntity, MatchType: entityType, SourceId: entityIdSource, TargetId: entityIdTarget})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	unresolvedCount := 0
+	for _, stage := range plan.Stages {
+		for _, planConfig := range stage.Configs {
+			for _, planEntity := range planConfig.Entities {
+				if planEntity.Matched {
+					continue
+				}
+
+				err = matchStore.WriteUnresolvedReference(store.UnresolvedReference{ConfigType: planConfig.Type, ConfigId: planConfig.SourceId, EntityId: planEntity.EntityId})
+				if err != nil {
+					return err
+				}
+				unresolvedCount++
+			}
+		}
+	}
+
+	err = matchStore.Commit()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Wrote match store %s with %d unresolved entity references", matchParameters.SqliteFile, unresolvedCount)
+
+	return nil
+}
+
+func writeStoreResult(matchStore *store.Store, result map[string]string, runeLabelMap map[string]string) error {
+	status := result["status"]
+	if status == "" {
+		return nil
+	}
+
+	configType := result["monaco_type"]
+	action := status[0:1]
+	isMultiMatched := strings.HasSuffix(status, string(match.STATUS_MULTI_MATCH_RUNE)) && len(status) > 1
+
+	sourceId := ""
+	targetId := result["target_id"]
+	if action == string(match.ACTION_DELETE_RUNE) {
+		targetId = result["monaco_id"]
+	} else {
+		sourceId = result["monaco_id"]
+	}
+
+	if sourceId != "" {
+		err := matchStore.WriteConfig(store.Config{Environment: store.EnvSource, ConfigType: configType, ConfigId: sourceId, Name: result["key_id"], Value: result["data_main"]})
+		if err != nil {
+			return err
+		}
+	}
+
+	if targetId != "" {
+		err := matchStore.WriteConfig(store.Config{Environment: store.EnvTarget, ConfigType: configType, ConfigId: targetId, Name: result["key_id"], Value: result["data_target"]})
+		if err != nil {
+			return err
+		}
+	}
+
+	if sourceId != "" && targetId != "" {
+		configMatch := store.Match{Kind: store.KindConfig, MatchType: configType, SourceId: sourceId, TargetId: targetId}
+
+		var err error
+		if isMultiMatched {
+			err = matchStore.WriteMultiMatchCandidate(configMatch)
+		} else {
+			err = matchStore.WriteMatch(configMatch)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return matchStore.WriteStatus(store.Status{
+		Kind:         store.KindConfig,
+		MatchType:    configType,
+		SourceId:     sourceId,
+		TargetId:     targetId,
+		Status:       runeLabelMap[action],
+		MultiMatched: isMultiMatched,
+	})
+}
@@ -0,0 +1,80 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWriteMatchStore(t *testing.T) {
+
+	matchPayload := MatchPayload{
+		Modules: []Module{
+			{
+				"data": MatchEntityMatch{
+					genTestPlanResult(match.ACTION_ADD_RUNE, "notification", "n1", "", `{"host":"HOST-0123456789ABCDEF"}`, `["HOST-0123456789ABCDEF","HOST-FEDCBA9876543210"]`),
+					genTestPlanResult(match.ACTION_UPDATE_RUNE, "alerting-profile", "a1", "b1", `{}`, `[]`),
+					genTestPlanResult(match.ACTION_DELETE_RUNE, "alerting-profile", "a2", "", `{}`, `[]`),
+					map[string]string{"status": "U, M", "key_id": "m1", "monaco_type": "dashboard", "monaco_id": "m1", "target_id": "m2"},
+					map[string]string{"status": "I, M", "key_id": "m1", "monaco_type": "dashboard", "monaco_id": "m1", "target_id": "m3"},
+				},
+			},
+		},
+	}
+
+	entityMatches := entities.MatchOutputPerType{
+		"HOST": {Matches: map[string]string{"HOST-0123456789ABCDEF": "HOST-AAAAAAAAAAAAAAAA"}},
+	}
+
+	matchParameters := match.MatchParameters{
+		Name:       "test",
+		Type:       "configs",
+		SqliteFile: filepath.Join(t.TempDir(), "match.db"),
+	}
+
+	plan, err := genMigrationPlan(matchPayload, entityMatches)
+	assert.NilError(t, err)
+	err = writeMatchStore(afero.NewOsFs(), matchParameters, matchPayload, entityMatches, plan)
+	assert.NilError(t, err)
+
+	db, err := sql.Open("sqlite", matchParameters.SqliteFile)
+	assert.NilError(t, err)
+	defer db.Close()
+
+	queryString := func(query string) string {
+		value := ""
+		assert.NilError(t, db.QueryRow(query).Scan(&value))
+		return value
+	}
+
+	assert.Equal(t, queryString("SELECT COUNT(*) FROM configs WHERE environment = 'source'"), "3")
+	assert.Equal(t, queryString("SELECT COUNT(*) FROM configs WHERE environment = 'target'"), "4")
+	assert.Equal(t, queryString("SELECT target_id FROM matches WHERE kind = 'config'"), "b1")
+	assert.Equal(t, queryString("SELECT target_id FROM matches WHERE kind = 'entity'"), "HOST-AAAAAAAAAAAAAAAA")
+	assert.Equal(t, queryString("SELECT COUNT(*) FROM multi_match_candidates WHERE source_id = 'm1'"), "2")
+	assert.Equal(t, queryString("SELECT target_id FROM statuses WHERE status = 'Delete' AND source_id IS NULL"), "a2")
+	assert.Equal(t, queryString("SELECT COUNT(*) FROM statuses WHERE multi_matched = 1"), "2")
+	assert.Equal(t, queryString("SELECT config_id || ' ' || entity_id FROM unresolved_references"), "n1 HOST-FEDCBA9876543210")
+	assert.Equal(t, queryString("SELECT name FROM run"), "test")
+}
@@ -0,0 +1,343 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configs
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/spf13/afero"
+)
+
+const terraformImportDir = "terraform_import"
+
+// Resource types of the terraform-provider-dynatrace (v1.30.1) export, per classic API ID.
+// Only the APIs with a resource importable by the classic config ID are listed.
+var terraformClassicResourceTypes = map[string]string{
+	"alerting-profile":                "dynatrace_alerting_profile",
+	"network-zone":                    "dynatrace_network_zone",
+	"management-zone":                 "dynatrace_management_zone",
+	"auto-tag":                        "dynatrace_autotag",
+	"dashboard":                       "dynatrace_json_dashboard",
+	"notification":                    "dynatrace_notification",
+	"anomaly-detection-metrics":       "dynatrace_custom_anomalies",
+	"anomaly-detection-disks":         "dynatrace_disk_anomalies",
+	"synthetic-location":              "dynatrace_synthetic_location",
+	"application-web":                 "dynatrace_web_application",
+	"application-mobile":              "dynatrace_mobile_application",
+	"app-detection-rule":              "dynatrace_application_detection_rule",
+	"aws-credentials":                 "dynatrace_aws_credentials",
+	"kubernetes-credentials":          "dynatrace_k8s_credentials",
+	"azure-credentials":               "dynatrace_azure_credentials",
+	"request-attributes":              "dynatrace_request_attribute",
+	"calculated-metrics-service":      "dynatrace_calculated_service_metric",
+	"conditional-naming-processgroup": "dynatrace_processgroup_naming",
+	"conditional-naming-host":         "dynatrace_host_naming",
+	"conditional-naming-service":      "dynatrace_service_naming",
+	"maintenance-window":              "dynatrace_maintenance_window",
+	"request-naming-service":          "dynatrace_request_naming",
+	"slo":                             "dynatrace_slo",
+	"credential-vault":                "dynatrace_credentials",
+}
+
+// Resource types of the terraform-provider-dynatrace (v1.30.1) export, per settings schema ID.
+// Settings resources are imported by their object ID.
+var terraformSettingsResourceTypes = map[string]string{
+	"builtin:accounting.ddu.limit":                                     "dynatrace_ddu_pool",
+	"builtin:activegate-token":                                         "dynatrace_activegate_token",
+	"builtin:alerting.connectivity-alerts":                             "dynatrace_connectivity_alerts",
+	"builtin:alerting.maintenance-window":                              "dynatrace_maintenance",
+	"builtin:alerting.profile":                                         "dynatrace_alerting",
+	"builtin:anomaly-detection.databases":                              "dynatrace_database_anomalies_v2",
+	"builtin:anomaly-detection.disk-rules":                             "dynatrace_disk_anomaly_rules",
+	"builtin:anomaly-detection.frequent-issues":                        "dynatrace_frequent_issues",
+	"builtin:anomaly-detection.infrastructure-aws":                     "dynatrace_aws_anomalies",
+	"builtin:anomaly-detection.infrastructure-disks":                   "dynatrace_disk_anomalies_v2",
+	"builtin:anomaly-detection.infrastructure-disks.per-disk-override": "dynatrace_disk_specific_anomalies_v2",
+	"builtin:anomaly-detection.infrastructure-hosts":                   "dynatrace_host_anomalies_v2",
+	"builtin:anomaly-detection.infrastructure-vmware":                  "dynatrace_vmware_anomalies",
+	"builtin:anomaly-detection.kubernetes.cluster":                     "dynatrace_k8s_cluster_anomalies",
+	"builtin:anomaly-detection.kubernetes.namespace":                   "dynatrace_k8s_namespace_anomalies",
+	"builtin:anomaly-detection.kubernetes.node":                        "dynatrace_k8s_node_anomalies",
+	"builtin:anomaly-detection.kubernetes.pvc":                         "dynatrace_k8s_pvc_anomalies",
+	"builtin:anomaly-detection.kubernetes.workload":                    "dynatrace_k8s_workload_anomalies",
+	"builtin:anomaly-detection.metric-events":                          "dynatrace_metric_events",
+	"builtin:anomaly-detection.services":                               "dynatrace_service_anomalies_v2",
+	"builtin:apis.detection-rules":                                     "dynatrace_api_detection",
+	"builtin:audit-log":                                                "dynatrace_audit_log",
+	"builtin:availability.process-group-alerting":                      "dynatrace_pg_alerting",
+	"builtin:bizevents-processing-buckets.rule":                        "dynatrace_business_events_buckets",
+	"builtin:bizevents-processing-metrics.rule":                        "dynatrace_business_events_metrics",
+	"builtin:bizevents-processing-pipelines.rule":                      "dynatrace_business_events_processing",
+	"builtin:bizevents.http.incoming":                                  "dynatrace_business_events_oneagent",
+	"builtin:cloud.cloudfoundry":                                       "dynatrace_cloud_foundry",
+	"builtin:cloud.kubernetes":                                         "dynatrace_kubernetes",
+	"builtin:container.built-in-monitoring-rule":                       "dynatrace_container_builtin_rule",
+	"builtin:container.monitoring-rule":                                "dynatrace_container_rule",
+	"builtin:container.technology":                                     "dynatrace_container_technology",
+	"builtin:custom-metrics":                                           "dynatrace_user_session_metrics",
+	"builtin:custom-unit":                                              "dynatrace_custom_units",
+	"builtin:dashboards.general":                                       "dynatrace_dashboards_general",
+	"builtin:dashboards.image.allowlist":                               "dynatrace_dashboards_allowlist",
+	"builtin:dashboards.presets":                                       "dynatrace_dashboards_presets",
+	"builtin:declarativegrouping":                                      "dynatrace_declarative_grouping",
+	"builtin:deployment.activegate.updates":                            "dynatrace_activegate_updates",
+	"builtin:deployment.management.update-windows":                     "dynatrace_update_windows",
+	"builtin:deployment.oneagent.default-version":                      "dynatrace_oneagent_default_version",
+	"builtin:deployment.oneagent.updates":                              "dynatrace_oneagent_updates",
+	"builtin:disk.analytics.extension":                                 "dynatrace_disk_analytics",
+	"builtin:disk.options":                                             "dynatrace_disk_options",
+	"builtin:eec.local":                                                "dynatrace_extension_execution_controller",
+	"builtin:eec.remote":                                               "dynatrace_extension_execution_remote",
+	"builtin:eula-settings":                                            "dynatrace_eula_settings",
+	"builtin:exclude.network.traffic":                                  "dynatrace_network_traffic",
+	"builtin:failure-detection.environment.parameters":                 "dynatrace_failure_detection_parameters",
+	"builtin:failure-detection.environment.rules":                      "dynatrace_failure_detection_rules",
+	"builtin:failure-detection.service.general-parameters":             "dynatrace_service_failure",
+	"builtin:failure-detection.service.http-parameters":                "dynatrace_service_http_failure",
+	"builtin:geo-settings":                                             "dynatrace_geolocation",
+	"builtin:host.monitoring":                                          "dynatrace_host_monitoring",
+	"builtin:host.monitoring.aix-kernel-extension":                     "dynatrace_aix_extension",
+	"builtin:host.process-groups.monitoring-state":                     "dynatrace_host_process_group_monitoring",
+	"builtin:ibmmq.ims-bridges":                                        "dynatrace_ims_bridges",
+	"builtin:ibmmq.queue-managers":                                     "dynatrace_queue_manager",
+	"builtin:ibmmq.queue-sharing-group":                                "dynatrace_queue_sharing_groups",
+	"builtin:issue-tracking.integration":                               "dynatrace_issue_tracking",
+	"builtin:logmonitoring.custom-log-source-settings":                 "dynatrace_log_custom_source",
+	"builtin:logmonitoring.log-agent-configuration":                    "dynatrace_log_oneagent",
+	"builtin:logmonitoring.log-buckets-rules":                          "dynatrace_log_buckets",
+	"builtin:logmonitoring.log-custom-attributes":                      "dynatrace_log_custom_attribute",
+	"builtin:logmonitoring.log-dpp-rules":                              "dynatrace_log_processing",
+	"builtin:logmonitoring.log-events":                                 "dynatrace_log_events",
+	"builtin:logmonitoring.log-storage-settings":                       "dynatrace_log_storage",
+	"builtin:logmonitoring.logs-on-grail-activate":                     "dynatrace_log_grail",
+	"builtin:logmonitoring.schemaless-log-metric":                      "dynatrace_log_metrics",
+	"builtin:logmonitoring.sensitive-data-masking-settings":            "dynatrace_log_sensitive_data_masking",
+	"builtin:logmonitoring.timestamp-configuration":                    "dynatrace_log_timestamp",
+	"builtin:mainframe.mqfilters":                                      "dynatrace_ibm_mq_filters",
+	"builtin:mainframe.txmonitoring":                                   "dynatrace_mainframe_transaction_monitoring",
+	"builtin:mainframe.txstartfilters":                                 "dynatrace_transaction_start_filters",
+	"builtin:management-zones":                                         "dynatrace_management_zone_v2",
+	"builtin:metric.metadata":                                          "dynatrace_metric_metadata",
+	"builtin:metric.query":                                             "dynatrace_metric_query",
+	"builtin:monitored-technologies.apache":                            "dynatrace_monitored_technologies_apache",
+	"builtin:monitored-technologies.dotnet":                            "dynatrace_monitored_technologies_dotnet",
+	"builtin:monitored-technologies.go":                                "dynatrace_monitored_technologies_go",
+	"builtin:monitored-technologies.iis":                               "dynatrace_monitored_technologies_iis",
+	"builtin:monitored-technologies.java":                              "dynatrace_monitored_technologies_java",
+	"builtin:monitored-technologies.nginx":                             "dynatrace_monitored_technologies_nginx",
+	"builtin:monitored-technologies.nodejs":                            "dynatrace_monitored_technologies_nodejs",
+	"builtin:monitored-technologies.open-tracing-native":               "dynatrace_monitored_technologies_opentracing",
+	"builtin:monitored-technologies.php":                               "dynatrace_monitored_technologies_php",
+	"builtin:monitored-technologies.varnish":                           "dynatrace_monitored_technologies_varnish",
+	"builtin:monitored-technologies.wsmb":                              "dynatrace_monitored_technologies_wsmb",
+	"builtin:monitoredentities.generic.relation":                       "dynatrace_generic_relationships",
+	"builtin:monitoredentities.generic.type":                           "dynatrace_generic_types",
+	"builtin:monitoring.slo":                                           "dynatrace_slo_v2",
+	"builtin:monitoring.slo.normalization":                             "dynatrace_slo_normalization",
+	"builtin:nettracer.traffic":                                        "dynatrace_nettracer",
+	"builtin:networkzones":                                             "dynatrace_network_zones",
+	"builtin:oneagent.features":                                        "dynatrace_oneagent_features",
+	"builtin:opentelemetry-metrics":                                    "dynatrace_opentelemetry_metrics",
+	"builtin:os-services-monitoring":                                   "dynatrace_os_services",
+	"builtin:ownership.config":                                         "dynatrace_ownership_config",
+	"builtin:ownership.teams":                                          "dynatrace_ownership_teams",
+	"builtin:preferences.privacy":                                      "dynatrace_data_privacy",
+	"builtin:process-group.advanced-detection-rule":                    "dynatrace_process_group_detection",
+	"builtin:process-group.cloud-application-workload-detection":       "dynatrace_cloudapp_workloaddetection",
+	"builtin:process-group.detection-flags":                            "dynatrace_process_group_detection_flags",
+	"builtin:process-group.monitoring.state":                           "dynatrace_process_group_monitoring",
+	"builtin:process-group.simple-detection-rule":                      "dynatrace_process_group_simple_detection",
+	"builtin:process-visibility":                                       "dynatrace_process_visibility",
+	"builtin:process.custom-process-monitoring-rule":                   "dynatrace_process_monitoring_rule",
+	"builtin:process.process-monitoring":                               "dynatrace_process_monitoring",
+	"builtin:processavailability":                                      "dynatrace_process_availability",
+	"builtin:remote.environment":                                       "dynatrace_remote_environments",
+	"builtin:resource-attribute":                                       "dynatrace_resource_attributes",
+	"builtin:rum.host-headers":                                         "dynatrace_rum_host_headers",
+	"builtin:rum.ip-determination":                                     "dynatrace_rum_ip_determination",
+	"builtin:rum.ip-mappings":                                          "dynatrace_rum_ip_locations",
+	"builtin:rum.overload-prevention":                                  "dynatrace_rum_overload_prevention",
+	"builtin:rum.processgroup":                                         "dynatrace_process_group_rum",
+	"builtin:rum.provider-breakdown":                                   "dynatrace_rum_provider_breakdown",
+	"builtin:rum.resource-timing-origins":                              "dynatrace_rum_advanced_correlation",
+	"builtin:rum.user-experience-score":                                "dynatrace_user_experience_score",
+	"builtin:rum.web.beacon-domain-origins":                            "dynatrace_web_app_beacon_origins",
+	"builtin:rum.web.custom-errors":                                    "dynatrace_web_app_custom_errors",
+	"builtin:rum.web.custom-rum-javascript-version":                    "dynatrace_web_app_javascript_version",
+	"builtin:rum.web.enablement":                                       "dynatrace_web_app_enablement",
+	"builtin:rum.web.key-performance-metric-custom-actions":            "dynatrace_web_app_key_performance_custom",
+	"builtin:rum.web.key-performance-metric-load-actions":              "dynatrace_web_app_key_performance_load",
+	"builtin:rum.web.key-performance-metric-xhr-actions":               "dynatrace_web_app_key_performance_xhr",
+	"builtin:rum.web.request-errors":                                   "dynatrace_web_app_request_errors",
+	"builtin:rum.web.resource-cleanup-rules":                           "dynatrace_web_app_resource_cleanup",
+	"builtin:rum.web.resource-types":                                   "dynatrace_web_app_resource_types",
+	"builtin:service-detection.external-web-request":                   "dynatrace_service_external_web_request",
+	"builtin:service-detection.external-web-service":                   "dynatrace_service_external_web_service",
+	"builtin:service-detection.full-web-request":                       "dynatrace_service_full_web_request",
+	"builtin:service-detection.full-web-service":                       "dynatrace_service_full_web_service",
+	"builtin:sessionreplay.web.privacy-preferences":                    "dynatrace_session_replay_web_privacy",
+	"builtin:sessionreplay.web.resource-capturing":                     "dynatrace_session_replay_resource_capture",
+	"builtin:settings.mutedrequests":                                   "dynatrace_muted_requests",
+	"builtin:settings.subscriptions.service":                           "dynatrace_key_requests",
+	"builtin:span-attribute":                                           "dynatrace_span_attribute",
+	"builtin:span-capturing":                                           "dynatrace_span_capture_rule",
+	"builtin:span-context-propagation":                                 "dynatrace_span_context_propagation",
+	"builtin:span-entry-points":                                        "dynatrace_span_entry_point",
+	"builtin:synthetic.synthetic-availability-settings":                "dynatrace_synthetic_availability",
+	"builtin:tags.auto-tagging":                                        "dynatrace_autotag_v2",
+	"builtin:tokens.token-settings":                                    "dynatrace_token_settings",
+	"builtin:user-action-custom-metrics":                               "dynatrace_user_action_metrics",
+	"builtin:user-settings":                                            "dynatrace_user_settings",
+}
+
+var terraformInvalidNameCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+type terraformImport struct {
+	resourceName string
+	id           string
+}
+
+func getTerraformResourceType(configType config.Type, configTypeString string) (string, bool) {
+	_, isSettings := getConfigTypeInfo(configType)
+	if isSettings {
+		resourceType, found := terraformSettingsResourceTypes[configTypeString]
+		return resourceType, found
+	}
+
+	resourceType, found := terraformClassicResourceTypes[configTypeString]
+	return resourceType, found
+}
+
+// genTerraformResourceName turns a config name into a unique terraform resource name
+func genTerraformResourceName(name string, usedNames map[string]bool) string {
+	resourceName := strings.ToLower(strings.Trim(terraformInvalidNameCharsRegex.ReplaceAllString(name, "_"), "_"))
+	if resourceName == "" {
+		resourceName = "config"
+	} else if resourceName[0] >= '0' && resourceName[0] <= '9' {
+		resourceName = "config_" + resourceName
+	}
+
+	uniqueName := resourceName
+	for i := 2; usedNames[uniqueName]; i++ {
+		uniqueName = fmt.Sprintf("%s_%d", resourceName, i)
+	}
+	usedNames[uniqueName] = true
+
+	return uniqueName
+}
+
+// genTerraformImports lists the target configs of the Update and Identical matches of a type
+func genTerraformImports(matchEntityMatches Module) []terraformImport {
+
+	data, ok := matchEntityMatches["data"].(MatchEntityMatch)
+	if !ok {
+		return nil
+	}
+
+	matched := []map[string]string{}
+	for _, entry := range data {
+		result, ok := entry.(map[string]string)
+		if !ok || result["target_id"] == "" {
+			continue
+		}
+		if result["status"] != string(match.ACTION_UPDATE_RUNE) && result["status"] != string(match.ACTION_IDENTICAL_RUNE) {
+			continue
+		}
+		matched = append(matched, result)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i]["key_id"] == matched[j]["key_id"] {
+			return matched[i]["target_id"] < matched[j]["target_id"]
+		}
+		return matched[i]["key_id"] < matched[j]["key_id"]
+	})
+
+	usedNames := map[string]bool{}
+	imports := make([]terraformImport, len(matched))
+	for i, result := range matched {
+		imports[i] = terraformImport{
+			resourceName: genTerraformResourceName(result["key_id"], usedNames),
+			id:           result["target_id"],
+		}
+	}
+
+	return imports
+}
+
+func formatTerraformImports(resourceType string, imports []terraformImport) string {
+	var sb strings.Builder
+
+	for _, terraformImport := range imports {
+		fmt.Fprintf(&sb, "import {\n  to = %s.%s\n  id = %q\n}\n\n", resourceType, terraformImport.resourceName, terraformImport.id)
+	}
+
+	return sb.String()
+}
+
+// removeTerraformImports removes the import blocks of a previous match,
+// so that the types without matched target configs this time do not keep stale import blocks
+func removeTerraformImports(fs afero.Fs, matchParameters match.MatchParameters) error {
+	importDir := path.Join(matchParameters.OutputDir, terraformImportDir)
+
+	err := fs.RemoveAll(importDir)
+	if err != nil {
+		return fmt.Errorf("failed to remove the previous terraform imports in %s, see error: %w", importDir, err)
+	}
+
+	return nil
+}
+
+// writeTerraformImports writes the terraform import blocks of the matched target configs,
+// so that they are adopted into the terraform state instead of being recreated
+func writeTerraformImports(fs afero.Fs, matchParameters match.MatchParameters, configTypeInfo configTypeInfo, matchEntityMatches Module) error {
+
+	resourceType, found := getTerraformResourceType(configTypeInfo.configType, configTypeInfo.configTypeString)
+	if !found {
+		log.Debug("No terraform resource type for %s, skipping import blocks", configTypeInfo.configTypeString)
+		return nil
+	}
+
+	imports := genTerraformImports(matchEntityMatches)
+	if len(imports) == 0 {
+		return nil
+	}
+
+	importDir := path.Join(matchParameters.OutputDir, terraformImportDir)
+	err := fs.MkdirAll(importDir, 0777)
+	if err != nil {
+		return err
+	}
+
+	sanitizedType := config.Sanitize(configTypeInfo.configTypeString)
+	fullImportPath := filepath.Join(importDir, fmt.Sprintf("%s.tf", sanitizedType))
+
+	err = afero.WriteFile(fs, fullImportPath, []byte(formatTerraformImports(resourceType, imports)), 0664)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Wrote %d terraform import blocks for %s", len(imports), configTypeInfo.configTypeString)
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package configs
+
+import (
+	"path/filepath"
+	"testing"
+
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestGenTerraformResourceName(t *testing.T) {
+
+	usedNames := map[string]bool{}
+
+	assert.Equal(t, genTerraformResourceName("My Zone (prod)", usedNames), "my_zone_prod")
+	assert.Equal(t, genTerraformResourceName("my zone prod", usedNames), "my_zone_prod_2")
+	assert.Equal(t, genTerraformResourceName("42 hosts", usedNames), "config_42_hosts")
+	assert.Equal(t, genTerraformResourceName("***", usedNames), "config")
+}
+
+func TestWriteTerraformImports(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	matchParameters := match.MatchParameters{OutputDir: "out"}
+
+	matchEntityMatches := Module{
+		"data": MatchEntityMatch{
+			map[string]string{"status": "U", "key_id": "zone b", "target_id": "target-b"},
+			map[string]string{"status": "I", "key_id": "zone a", "target_id": "target-a"},
+			map[string]string{"status": "A", "key_id": "zone c", "target_id": ""},
+			map[string]string{"status": "U, M", "key_id": "zone d", "target_id": "target-d"},
+			map[string]string{"status": "D", "key_id": "zone e", "target_id": "target-e"},
+		},
+	}
+
+	typeInfo := configTypeInfo{
+		configTypeString: "builtin:management-zones",
+		configType:       config.SettingsType{SchemaId: "builtin:management-zones"},
+	}
+
+	err := writeTerraformImports(fs, matchParameters, typeInfo, matchEntityMatches)
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, filepath.Join("out", terraformImportDir, "builtinmanagement-zones.tf"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `import {
+  to = dynatrace_management_zone_v2.zone_a
+  id = "target-a"
+}
+
+import {
+  to = dynatrace_management_zone_v2.zone_b
+  id = "target-b"
+}
+
+`)
+
+	unknownTypeInfo := configTypeInfo{
+		configTypeString: "unknown-api",
+		configType:       config.ClassicApiType{Api: "unknown-api"},
+	}
+	err = writeTerraformImports(fs, matchParameters, unknownTypeInfo, matchEntityMatches)
+	assert.NilError(t, err)
+
+	exists, _ := afero.Exists(fs, filepath.Join("out", terraformImportDir, "unknown-api.tf"))
+	assert.Assert(t, !exists, "no import blocks without a resource type")
+}
+
+func TestRemoveTerraformImports(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	matchParameters := match.MatchParameters{OutputDir: "out"}
+
+	stalePath := filepath.Join("out", terraformImportDir, "builtinmanagement-zones.tf")
+	err := afero.WriteFile(fs, stalePath, []byte("import {}\n"), 0664)
+	assert.NilError(t, err)
+
+	err = removeTerraformImports(fs, matchParameters)
+	assert.NilError(t, err)
+
+	exists, _ := afero.Exists(fs, stalePath)
+	assert.Assert(t, !exists, "import blocks of a previous match are removed")
+
+	err = removeTerraformImports(fs, matchParameters)
+	assert.NilError(t, err)
+}
@@ -88,7 +88,7 @@ func genMultiMatchedMap(matchParameters match.MatchParameters, remainingResultsP
 				return err
 			}
 
-			actionStatus := match.ACTION_UPDATE_RUNE
+			actionStatus := genAction(configsTypeInfo, match.ACTION_UPDATE_RUNE)
 			if areConfigsIdentical {
 				actionStatus = match.ACTION_IDENTICAL_RUNE
 			}
@@ -157,6 +157,8 @@ func addConfigResult(matchParameters match.MatchParameters, configProcessingPtr
 	}
 
 	dataTargetJsonRaw := []byte{}
+	configIdTarget := ""
+	keyIdTarget := ""
 
 	if targetId >= 0 {
 		refMap = (*configProcessingPtr.Target.RawMatchList.GetValuesConfig())[targetId].(map[string]interface{})
@@ -164,9 +166,12 @@ func addConfigResult(matchParameters match.MatchParameters, configProcessingPtr
 		if err != nil {
 			return err
 		}
+		configIdTarget, _ = refMap[rules.ConfigIdKey].(string)
+		keyIdTarget = genKeyId(refMap)
 	}
 
 	dataSourceJsonRaw := []byte{}
+	matchTier := ""
 
 	if sourceId >= 0 {
 		refMap = (*configProcessingPtr.Source.RawMatchList.GetValuesConfig())[sourceId].(map[string]interface{})
@@ -174,40 +179,41 @@ func addConfigResult(matchParameters match.MatchParameters, configProcessingPtr
 		if err != nil {
 			return err
 		}
-		if configIdxToWriteSource != nil {
+		if configIdxToWriteSource != nil && action != match.ACTION_INCOMPATIBLE_RUNE {
 			(*configIdxToWriteSource)[sourceId] = true
 		}
+		if targetId >= 0 {
+			matchTier = configProcessingPtr.GetMatchTier(sourceId)
+		}
 	}
 
 	entityListRaw, err := json.Marshal(refMap[rules.EntitiesListKey])
 	if err != nil {
 		return err
 	}
-	key_id := ""
-	configName, configNameOk := refMap[rules.ConfigNameKey]
+	key_id := genKeyId(refMap)
 	configIdLocation, _ := getConfigTypeInfo(configProcessingPtr.GetConfigType())
 	configId, _ := refMap[rules.DownloadedKey].(map[string]interface{})[configIdLocation].(string)
-	scope, scopeOk := refMap[rules.DownloadedKey].(map[string]interface{})["scope"]
 
-	if configNameOk {
-		key_id = configName.(string)
-		if scopeOk {
-			key_id = key_id + " ( " + scope.(string) + " )"
+	if !isConfigInScope(matchParameters, refMap) {
+		if sourceId >= 0 && configIdxToWriteSource != nil {
+			(*configIdxToWriteSource)[sourceId] = false
 		}
-	} else if scopeOk {
-		key_id = scope.(string)
-	} else {
-		key_id = refMap[rules.ConfigIdKey].(string)
+		addOutOfScope(matchEntityMatches, configId)
+		return nil
 	}
 
 	(*matchEntityMatches)["data"] = append((*matchEntityMatches)["data"].(MatchEntityMatch), map[string]string{
-		"status":      status,
-		"key_id":      key_id,
-		"data_main":   string(dataSourceJsonRaw),
-		"data_target": string(dataTargetJsonRaw),
-		"entity_list": string(entityListRaw),
-		"monaco_type": configProcessingPtr.GetType(),
-		"monaco_id":   configId,
+		"status":        status,
+		"key_id":        key_id,
+		"target_key_id": keyIdTarget,
+		"data_main":     string(dataSourceJsonRaw),
+		"data_target":   string(dataTargetJsonRaw),
+		"entity_list":   string(entityListRaw),
+		"monaco_type":   configProcessingPtr.GetType(),
+		"monaco_id":     configId,
+		"target_id":     configIdTarget,
+		"match_tier":    matchTier,
 	})
 
 	(*matchEntityMatches)["stats"].(map[string]int)[status] += 1
@@ -215,6 +221,46 @@ func addConfigResult(matchParameters match.MatchParameters, configProcessingPtr
 	return nil
 }
 
+// isConfigInScope applies the source selector to the config of refMap, the source config or the target config of a delete
+func isConfigInScope(matchParameters match.MatchParameters, refMap map[string]interface{}) bool {
+	if !matchParameters.SourceSelector.HasConfigCriteria() {
+		return true
+	}
+
+	configName, hasName := refMap[rules.ConfigNameKey].(string)
+	settingsScope, hasSettingsScope := refMap[rules.DownloadedKey].(map[string]interface{})["scope"].(string)
+
+	return matchParameters.SourceSelector.IsConfigInScope(configName, hasName, settingsScope, hasSettingsScope)
+}
+
+// addOutOfScope lists the configs left out of the match payload by the source selector
+func addOutOfScope(matchEntityMatches *Module, configId string) {
+	outOfScope, _ := (*matchEntityMatches)[outOfScopeKey].([]string)
+
+	if len(outOfScope) > 0 && outOfScope[len(outOfScope)-1] == configId {
+		return
+	}
+
+	(*matchEntityMatches)[outOfScopeKey] = append(outOfScope, configId)
+}
+
+// genKeyId generates the key of a config shown in the match payload: its name, followed by its scope for settings
+func genKeyId(refMap map[string]interface{}) string {
+	configName, configNameOk := refMap[rules.ConfigNameKey]
+	scope, scopeOk := refMap[rules.DownloadedKey].(map[string]interface{})["scope"]
+
+	if configNameOk {
+		if scopeOk {
+			return configName.(string) + " ( " + scope.(string) + " )"
+		}
+		return configName.(string)
+	} else if scopeOk {
+		return scope.(string)
+	}
+
+	return refMap[rules.ConfigIdKey].(string)
+}
+
 func printMultiMatchedSample(remainingResultsPtr *processing.CompareResultList, configProcessingPtr *processing.MatchProcessing) {
 	multiMatchedCount := len(remainingResultsPtr.CompareResults)
 
@@ -290,6 +336,9 @@ type ConfigResultParam struct {
 
 const allConfigEntity = "all_configs"
 
+// outOfScopeKey lists the ids of the configs of a module excluded by the source selector
+const outOfScopeKey = "outOfScope"
+
 func genOutputPayload(matchParameters match.MatchParameters, configProcessingPtr *processing.MatchProcessing, remainingResultsPtr *processing.CompareResultList, matchedConfigs *map[int]int, configsTypeInfo configTypeInfo, prevMatches MatchOutputType) (MatchOutputType, Module, []bool, error) {
 
 	configIdxToWriteSource := make([]bool, len(*configProcessingPtr.Source.RawMatchList.GetValuesConfig()))
@@ -335,7 +384,7 @@ func genOutputPayload(matchParameters match.MatchParameters, configProcessingPtr
 			actionStatus = match.ACTION_IDENTICAL_RUNE
 			identicalConfigResultParamList = append(identicalConfigResultParamList, ConfigResultParam{sourceI, targetI, string(actionStatus), actionStatus})
 		} else {
-			actionStatus = match.ACTION_UPDATE_RUNE
+			actionStatus = genAction(configsTypeInfo, match.ACTION_UPDATE_RUNE)
 			updateConfigResultParamList = append(updateConfigResultParamList, ConfigResultParam{sourceI, targetI, string(actionStatus), actionStatus})
 		}
 
@@ -371,7 +420,7 @@ func genOutputPayload(matchParameters match.MatchParameters, configProcessingPtr
 			continue
 		}
 
-		actionStatus := match.ACTION_ADD_RUNE
+		actionStatus := genAction(configsTypeInfo, match.ACTION_ADD_RUNE)
 		matchStatus.Source.actionStatus[sourceI] = actionStatus
 
 		err = addConfigResult(matchParameters, configProcessingPtr, &matchEntityMatches, &configIdxToWriteSource, ConfigResultParam{sourceI, -1, string(actionStatus), actionStatus})
@@ -443,6 +492,10 @@ func areConfigsIdentical(configProcessingPtr *processing.MatchProcessing, source
 			continue
 		}
 
+		if configTypeInfo.isOrderedList(key) {
+			continue
+		}
+
 		sliceTargetInterface, ok := (*configProcessingPtr.Target.RawMatchList.GetValuesConfig())[targetI].(map[string]interface{})[rules.DownloadedKey].(map[string]interface{})[rules.ValueKey].(map[string]interface{})[key]
 		if !(ok) {
 			break
@@ -655,7 +708,7 @@ func writeTarFile(fs afero.Fs, outputDir string, isImport bool, configTypeInfo c
 		return err
 	}
 
-	tarFolder, _, err := tar.New(path.Join(tarDir, sanitizedType))
+	tarFolder, _, err := tar.New(fs, path.Join(tarDir, sanitizedType))
 	if err != nil {
 		return err
 	}
@@ -102,7 +102,7 @@ func unmarshalConfigs(configPerType []config.Config) (*RawConfigsList, error) {
 }
 
 func enhanceConfigs(rawConfigsList *RawConfigsList, configType config.Type,
-	entityMatches entities.MatchOutputPerType, replacements map[string]map[string]string) (*RawConfigsList, error) {
+	entityMatches entities.MatchOutputPerType, configIdMatches map[string]string, replacements map[string]map[string]string) (*RawConfigsList, error) {
 
 	configIdLocation, isSettings := getConfigTypeInfo(configType)
 	var settingsType string
@@ -154,6 +154,13 @@ func enhanceConfigs(rawConfigsList *RawConfigsList, configType config.Type,
 				}
 			}
 
+			if len(configIdMatches) > 0 {
+				wasModified := replaceConfigReferences(confMap, configIdLocation, configIdMatches)
+				if wasModified {
+					confInterfaceModified = confMap
+				}
+			}
+
 			uniqueConfKey := ""
 			uniqueConfOk := false
 			var classicNameValue interface{}
@@ -267,7 +274,7 @@ func getConfigTypeInfo(configType config.Type) (string, bool) {
 
 func genConfigProcessing(fs afero.Fs, matchParameters match.MatchParameters,
 	configPerTypeSource project.ConfigsPerType, configPerTypeTarget project.ConfigsPerType, configsType string,
-	entityMatches entities.MatchOutputPerType, replacements map[string]map[string]string) (*processing.MatchProcessing, error) {
+	entityMatches entities.MatchOutputPerType, configIdMatches map[string]string, replacements map[string]map[string]string) (*processing.MatchProcessing, error) {
 
 	startTime := time.Now()
 	log.Debug("Enhancing %s", configsType)
@@ -283,7 +290,7 @@ func genConfigProcessing(fs afero.Fs, matchParameters match.MatchParameters,
 	if len(configObjectListSource) >= 1 {
 		sourceType = configObjectListSource[0].Type
 
-		rawConfigsSource, err = enhanceConfigs(rawConfigsSource, sourceType, entityMatches, replacements)
+		rawConfigsSource, err = enhanceConfigs(rawConfigsSource, sourceType, entityMatches, configIdMatches, replacements)
 		if err != nil {
 			return nil, err
 		}
@@ -300,9 +307,9 @@ func genConfigProcessing(fs afero.Fs, matchParameters match.MatchParameters,
 	if len(configObjectListTarget) >= 1 {
 		targetType = configObjectListTarget[0].Type
 
-		configTypeInfoTarget := configTypeInfo{configsType, configObjectListTarget[0].Type}
+		configTypeInfoTarget := configTypeInfo{configTypeString: configsType, configType: configObjectListTarget[0].Type}
 
-		rawConfigsTarget, err = enhanceConfigs(rawConfigsTarget, targetType, nil, nil)
+		rawConfigsTarget, err = enhanceConfigs(rawConfigsTarget, targetType, nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
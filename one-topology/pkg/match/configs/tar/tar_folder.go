@@ -5,52 +5,65 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"sync"
 
 	"github.com/dynatrace-oss/terraform-provider-dynatrace/dynatrace/api"
+	"github.com/spf13/afero"
 )
 
 const bootstrapentry = "__bootstrap__"
 const bootstrapoffset = 512
 const indexentry = "__index__"
+const tempsuffix = ".tmp"
+
+// the legacy bootstrap only holds the index offset as an uint32,
+// the versioned one holds its version as an uint32, followed by the index offset as an uint64
+const legacybootstrapversion = 1
+const legacybootstrapsize = 4
+const bootstrapversion = 2
+const bootstrapsize = 12
 
 type Folder struct {
+	fs          afero.Fs
 	mu          sync.Mutex
 	name        string
 	index       tarIndex
 	indexOffset int64
-	offsetBytes []byte
+	version     uint32
 }
 
 type indexEntry struct {
 	api.Stub
-	Offset int64
+	Offset   int64
+	Checksum string `json:",omitempty"`
 }
 
 type tarIndex map[string]indexEntry
 
-func New(name string) (*Folder, bool, error) {
-	tf := &Folder{name: name + ".tar", index: tarIndex{}, offsetBytes: make([]byte, 4)}
+func New(fs afero.Fs, name string) (*Folder, bool, error) {
+	tf := &Folder{fs: fs, name: name + ".tar", index: tarIndex{}, version: bootstrapversion}
 
-	if fileExists(tf.name) {
+	if tf.fileExists(tf.name) {
 		return tf, true, tf.initExisting()
 	}
 	return tf, false, tf.initNew()
 }
 
-func NewExisting(name string) (*Folder, bool, error) {
-	tf := &Folder{name: name + ".tar", index: tarIndex{}, offsetBytes: make([]byte, 4)}
+func NewExisting(fs afero.Fs, name string) (*Folder, bool, error) {
+	tf := &Folder{fs: fs, name: name + ".tar", index: tarIndex{}, version: bootstrapversion}
 
-	if fileExists(tf.name) {
+	if tf.fileExists(tf.name) {
 		return tf, true, tf.initExisting()
 	}
 	return nil, false, nil
 }
 
 func (tf *Folder) initNew() error {
-	file, err := os.OpenFile(tf.name, os.O_CREATE|os.O_RDWR, 0644)
+	file, err := tf.fs.OpenFile(tf.name, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return err
 	}
@@ -58,18 +71,21 @@ func (tf *Folder) initNew() error {
 	defer file.Close()
 
 	tf.indexOffset = 1024
-	binary.LittleEndian.PutUint32(tf.offsetBytes, uint32(tf.indexOffset))
-	if err := tf.write(writer, bootstrapentry, tf.offsetBytes); err != nil {
+	bootstrap, err := tf.encodeBootstrap()
+	if err != nil {
+		return err
+	}
+	if err := tf.write(writer, bootstrapentry, bootstrap); err != nil {
 		return err
 	}
-	if err := tf.writeIndex(writer); err != nil {
+	if err := tf.writeIndex(writer, tf.index); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (me *Folder) initExisting() error {
-	file, err := os.OpenFile(me.name, os.O_RDONLY, 0)
+	file, err := me.fs.OpenFile(me.name, os.O_RDONLY, 0)
 	if err != nil {
 		return err
 	}
@@ -82,13 +98,16 @@ func (me *Folder) initExisting() error {
 	if header.Name != bootstrapentry {
 		return errors.New("not an indexed tar file")
 	}
-	if header.Size != 4 {
-		return errors.New("bootstrap size is not 4. file is corrupt")
+	if header.Size != legacybootstrapsize && header.Size != bootstrapsize {
+		return fmt.Errorf("bootstrap size is neither %d nor %d. file is corrupt", legacybootstrapsize, bootstrapsize)
 	}
-	if _, err := reader.Read(me.offsetBytes); err != nil && err != io.EOF {
+	bootstrap := make([]byte, header.Size)
+	if _, err := io.ReadFull(reader, bootstrap); err != nil {
+		return err
+	}
+	if err = me.decodeBootstrap(bootstrap); err != nil {
 		return err
 	}
-	me.indexOffset = int64(binary.LittleEndian.Uint32(me.offsetBytes))
 	if _, err = file.Seek(me.indexOffset, io.SeekStart); err != nil {
 		return err
 	}
@@ -102,6 +121,49 @@ func (me *Folder) initExisting() error {
 	return nil
 }
 
+func (me *Folder) encodeBootstrap() ([]byte, error) {
+	if me.version == legacybootstrapversion {
+		if me.indexOffset > math.MaxUint32 {
+			return nil, fmt.Errorf("%s uses the legacy 32-bit format and cannot grow past 4 GiB, compact it to upgrade it", me.name)
+		}
+		bootstrap := make([]byte, legacybootstrapsize)
+		binary.LittleEndian.PutUint32(bootstrap, uint32(me.indexOffset))
+		return bootstrap, nil
+	}
+
+	bootstrap := make([]byte, bootstrapsize)
+	binary.LittleEndian.PutUint32(bootstrap, me.version)
+	binary.LittleEndian.PutUint64(bootstrap[4:], uint64(me.indexOffset))
+	return bootstrap, nil
+}
+
+func (me *Folder) decodeBootstrap(bootstrap []byte) error {
+	if len(bootstrap) == legacybootstrapsize {
+		me.version = legacybootstrapversion
+		me.indexOffset = int64(binary.LittleEndian.Uint32(bootstrap))
+		return nil
+	}
+
+	me.version = binary.LittleEndian.Uint32(bootstrap)
+	if me.version != bootstrapversion {
+		return fmt.Errorf("unsupported bootstrap version %d", me.version)
+	}
+	me.indexOffset = int64(binary.LittleEndian.Uint64(bootstrap[4:]))
+	return nil
+}
+
+func (me *Folder) writeBootstrap(file afero.File) error {
+	bootstrap, err := me.encodeBootstrap()
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(bootstrapoffset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = file.Write(bootstrap)
+	return err
+}
+
 func (me *Folder) Get(id string) (*api.Stub, []byte, error) {
 	me.mu.Lock()
 	defer me.mu.Unlock()
@@ -113,7 +175,7 @@ func (me *Folder) Get(id string) (*api.Stub, []byte, error) {
 		stub := idxEntry.Stub
 		return &stub, nil, nil
 	}
-	file, err := os.OpenFile(me.name, os.O_RDONLY, 0)
+	file, err := me.fs.OpenFile(me.name, os.O_RDONLY, 0)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -145,7 +207,7 @@ func (me *Folder) Save(stub api.Stub, data []byte) error {
 	me.mu.Lock()
 	defer me.mu.Unlock()
 
-	file, err := os.OpenFile(me.name, os.O_RDWR, 0644)
+	file, err := me.fs.OpenFile(me.name, os.O_RDWR, 0644)
 	if err != nil {
 		return err
 	}
@@ -156,29 +218,25 @@ func (me *Folder) Save(stub api.Stub, data []byte) error {
 	writer := tar.NewWriter(file)
 
 	if data != nil {
-		me.index[stub.ID] = indexEntry{Stub: stub, Offset: me.indexOffset}
+		me.index[stub.ID] = indexEntry{Stub: stub, Offset: me.indexOffset, Checksum: checksum(data)}
 		if err = me.write(writer, stub.ID, data); err != nil {
 			return err
 		}
 		if me.indexOffset, err = file.Seek(0, io.SeekCurrent); err != nil {
 			return err
 		}
-		if err = me.writeIndex(writer); err != nil {
+		if err = me.writeIndex(writer, me.index); err != nil {
 			return err
 		}
-		if _, err = file.Seek(bootstrapoffset, 0); err != nil {
+		if err = me.writeBootstrap(file); err != nil {
 			return err
 		}
-		binary.LittleEndian.PutUint32(me.offsetBytes, uint32(me.indexOffset))
-		if _, err = file.Write(me.offsetBytes); err != nil {
-			return nil
-		}
 	} else {
 		me.index[stub.ID] = indexEntry{Stub: stub, Offset: 0}
 		if me.indexOffset, err = file.Seek(0, io.SeekCurrent); err != nil {
 			return err
 		}
-		if err = me.writeIndex(writer); err != nil {
+		if err = me.writeIndex(writer, me.index); err != nil {
 			return err
 		}
 	}
@@ -187,20 +245,67 @@ func (me *Folder) Save(stub api.Stub, data []byte) error {
 
 type GetStubData func(idx int) (api.Stub, []byte, bool, error)
 
+// SaveAllCallback writes the items to a temporary copy of the tar file, which replaces it once complete.
+// A failure or a crash while writing leaves the current tar file untouched.
+// The copy always uses the current bootstrap version, upgrading legacy tar files.
 func (me *Folder) SaveAllCallback(numItems int, getStubData GetStubData) error {
 	me.mu.Lock()
 	defer me.mu.Unlock()
 
-	file, err := os.OpenFile(me.name, os.O_RDWR, 0644)
+	tempName := me.name + tempsuffix
+
+	saved := &Folder{fs: me.fs, name: tempName, index: make(tarIndex, len(me.index)), indexOffset: me.indexOffset, version: bootstrapversion}
+	for id, idxEntry := range me.index {
+		saved.index[id] = idxEntry
+	}
+
+	if err := saved.writeCopy(me.name, numItems, getStubData); err != nil {
+		me.fs.Remove(tempName)
+		return err
+	}
+
+	if err := me.fs.Rename(tempName, me.name); err != nil {
+		me.fs.Remove(tempName)
+		return err
+	}
+
+	me.index = saved.index
+	me.indexOffset = saved.indexOffset
+	me.version = saved.version
+
+	return nil
+}
+
+// writeCopy copies the data entries of sourceName, then appends the items
+func (me *Folder) writeCopy(sourceName string, numItems int, getStubData GetStubData) error {
+	source, err := me.fs.OpenFile(sourceName, os.O_RDONLY, 0)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	if _, err = file.Seek(me.indexOffset, io.SeekStart); err != nil {
+	defer source.Close()
+
+	file, err := me.fs.OpenFile(me.name, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+	if err != nil {
 		return err
 	}
+	defer file.Close()
 	writer := tar.NewWriter(file)
 
+	// both bootstrap versions are padded to the same size, data entries keep their offsets
+	bootstrap, err := me.encodeBootstrap()
+	if err != nil {
+		return err
+	}
+	if err = me.write(writer, bootstrapentry, bootstrap); err != nil {
+		return err
+	}
+	if _, err = source.Seek(firstentryoffset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.CopyN(file, source, me.indexOffset-firstentryoffset); err != nil {
+		return err
+	}
+
 	for idx := 0; idx < numItems; idx++ {
 		stub, data, isSkipped, err := getStubData(idx)
 		if err != nil {
@@ -213,7 +318,7 @@ func (me *Folder) SaveAllCallback(numItems int, getStubData GetStubData) error {
 
 		if data != nil {
 
-			me.index[stub.ID] = indexEntry{Stub: stub, Offset: me.indexOffset}
+			me.index[stub.ID] = indexEntry{Stub: stub, Offset: me.indexOffset, Checksum: checksum(data)}
 			if err = me.write(writer, stub.ID, data); err != nil {
 				return err
 			}
@@ -228,18 +333,17 @@ func (me *Folder) SaveAllCallback(numItems int, getStubData GetStubData) error {
 	if _, err = file.Seek(me.indexOffset, io.SeekStart); err != nil {
 		return err
 	}
-	if err = me.writeIndex(writer); err != nil {
+	if err = me.writeIndex(writer, me.index); err != nil {
 		return err
 	}
-	if _, err = file.Seek(bootstrapoffset, 0); err != nil {
+	if err = me.writeBootstrap(file); err != nil {
 		return err
 	}
-	binary.LittleEndian.PutUint32(me.offsetBytes, uint32(me.indexOffset))
-	if _, err = file.Write(me.offsetBytes); err != nil {
-		return nil
-	}
 
-	return nil
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	return file.Close()
 }
 
 func (tf *Folder) write(writer *tar.Writer, name string, data []byte) error {
@@ -256,16 +360,16 @@ func (tf *Folder) write(writer *tar.Writer, name string, data []byte) error {
 	return writer.Flush()
 }
 
-func (me *Folder) writeIndex(writer *tar.Writer) error {
+func (me *Folder) writeIndex(writer *tar.Writer, index tarIndex) error {
 	defer writer.Close()
-	data, err := json.MarshalIndent(me.index, "", "\t")
+	data, err := json.MarshalIndent(index, "", "\t")
 	if err != nil {
 		panic(err)
 	}
 	return me.write(writer, indexentry, data)
 }
 
-func (me *Folder) read(file *os.File) ([]byte, error) {
+func (me *Folder) read(file afero.File) ([]byte, error) {
 	return me.readWith(tar.NewReader(file))
 }
 
@@ -275,21 +379,18 @@ func (me *Folder) readWith(reader *tar.Reader) ([]byte, error) {
 		return nil, err
 	}
 	data := make([]byte, header.Size)
-	if _, err := reader.Read(data); err != nil && err != io.EOF {
+	if _, err := io.ReadFull(reader, data); err != nil && err != io.EOF {
 		return nil, err
 	}
 	return data, nil
 }
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true
-	}
-	if errors.Is(err, os.ErrNotExist) {
+func (me *Folder) fileExists(path string) bool {
+	exists, err := afero.Exists(me.fs, path)
+	if err != nil {
 		return false
 	}
-	return false
+	return exists
 }
 
 func (me *Folder) Delete(id string) error {
@@ -297,7 +398,7 @@ func (me *Folder) Delete(id string) error {
 	defer me.mu.Unlock()
 	if _, found := me.index[id]; found {
 		delete(me.index, id)
-		file, err := os.OpenFile(me.name, os.O_RDWR, 0644)
+		file, err := me.fs.OpenFile(me.name, os.O_RDWR, 0644)
 		if err != nil {
 			return err
 		}
@@ -306,16 +407,12 @@ func (me *Folder) Delete(id string) error {
 			return err
 		}
 		writer := tar.NewWriter(file)
-		if err = me.writeIndex(writer); err != nil {
+		if err = me.writeIndex(writer, me.index); err != nil {
 			return err
 		}
-		if _, err = file.Seek(bootstrapoffset, 0); err != nil {
+		if err = me.writeBootstrap(file); err != nil {
 			return err
 		}
-		binary.LittleEndian.PutUint32(me.offsetBytes, uint32(me.indexOffset))
-		if _, err = file.Write(me.offsetBytes); err != nil {
-			return nil
-		}
 		return nil
 	}
 	return nil
@@ -0,0 +1,265 @@
+package tar
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/terraform-provider-dynatrace/dynatrace/api"
+	"github.com/spf13/afero"
+)
+
+const compactsuffix = ".compact"
+
+// the first data entry follows the bootstrap header and its padded content
+const firstentryoffset = 2 * bootstrapoffset
+
+// Entry is an entry of the index of a tar folder.
+// Entries with an Offset of 0 only hold a stub, without data.
+type Entry struct {
+	api.Stub
+	Offset   int64
+	Checksum string
+}
+
+// VerifyResult holds the findings of Folder.Verify
+type VerifyResult struct {
+	Indexed      int
+	StubsOnly    int
+	Unreferenced int
+	Errors       []error
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (me *Folder) Name() string {
+	return me.name
+}
+
+// Entries lists the index entries, sorted by ID
+func (me *Folder) Entries() []Entry {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	entries := make([]Entry, 0, len(me.index))
+	for _, idxEntry := range me.index {
+		entries = append(entries, Entry{Stub: idxEntry.Stub, Offset: idxEntry.Offset, Checksum: idxEntry.Checksum})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+
+	return entries
+}
+
+// Verify checks that every index entry points to a tar entry of the same id,
+// with a valid JSON content matching the checksum when the index holds one.
+func (me *Folder) Verify() (VerifyResult, error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	result := VerifyResult{Errors: []error{}}
+
+	file, err := me.fs.OpenFile(me.name, os.O_RDONLY, 0)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	stored, err := me.countStoredEntries(file)
+	if err != nil {
+		return result, err
+	}
+
+	ids := make([]string, 0, len(me.index))
+	for id := range me.index {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	idPerOffset := map[int64]string{}
+
+	for _, id := range ids {
+		idxEntry := me.index[id]
+		result.Indexed++
+
+		if idxEntry.ID != id {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: index key does not match the stub id %s", id, idxEntry.ID))
+		}
+
+		if idxEntry.Offset == 0 {
+			result.StubsOnly++
+			continue
+		}
+
+		if idxEntry.Offset < firstentryoffset || idxEntry.Offset >= me.indexOffset {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: offset %d is outside of the data section (%d - %d)", id, idxEntry.Offset, firstentryoffset, me.indexOffset))
+			continue
+		}
+
+		if otherId, found := idPerOffset[idxEntry.Offset]; found {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: offset %d is also used by %s", id, idxEntry.Offset, otherId))
+			continue
+		}
+		idPerOffset[idxEntry.Offset] = id
+
+		header, data, err := readEntryAt(file, idxEntry.Offset)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: could not read the entry at offset %d: %w", id, idxEntry.Offset, err))
+			continue
+		}
+
+		if header.Name != id+".json" {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: offset %d points to entry %s", id, idxEntry.Offset, header.Name))
+			continue
+		}
+
+		if int64(len(data)) != header.Size {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: read %d bytes, expected %d", id, len(data), header.Size))
+			continue
+		}
+
+		if !json.Valid(data) {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: content is not valid JSON", id))
+		}
+
+		if idxEntry.Checksum != "" && idxEntry.Checksum != checksum(data) {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: checksum does not match the content", id))
+		}
+	}
+
+	result.Unreferenced = stored - len(idPerOffset)
+	if result.Unreferenced < 0 {
+		result.Unreferenced = 0
+	}
+
+	return result, nil
+}
+
+// Compact rewrites the tar file with only the entries referenced by the index.
+// Entries that were deleted or overwritten are dropped, their count is returned.
+// Legacy tar files are upgraded to the current bootstrap version.
+func (me *Folder) Compact() (int, error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	file, err := me.fs.OpenFile(me.name, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	stored, err := me.countStoredEntries(file)
+	if err != nil {
+		return 0, err
+	}
+
+	entries := make([]indexEntry, 0, len(me.index))
+	for _, idxEntry := range me.index {
+		entries = append(entries, idxEntry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+
+	compactName := me.name + compactsuffix
+	if me.fileExists(compactName) {
+		if err = me.fs.Remove(compactName); err != nil {
+			return 0, err
+		}
+	}
+
+	compacted := &Folder{fs: me.fs, name: compactName, index: tarIndex{}, version: bootstrapversion}
+	if err = compacted.initNew(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	var getStubData GetStubData = func(idx int) (api.Stub, []byte, bool, error) {
+		idxEntry := entries[idx]
+		if idxEntry.Offset == 0 {
+			return idxEntry.Stub, nil, false, nil
+		}
+
+		_, data, err := readEntryAt(file, idxEntry.Offset)
+		if err != nil {
+			return api.Stub{}, nil, false, fmt.Errorf("%s: %w", idxEntry.ID, err)
+		}
+		written++
+
+		return idxEntry.Stub, data, false, nil
+	}
+
+	if err = compacted.SaveAllCallback(len(entries), getStubData); err != nil {
+		me.fs.Remove(compactName)
+		return 0, err
+	}
+
+	if err = me.fs.Rename(compactName, me.name); err != nil {
+		return 0, err
+	}
+
+	me.index = compacted.index
+	me.indexOffset = compacted.indexOffset
+	me.version = compacted.version
+
+	return stored - written, nil
+}
+
+// countStoredEntries counts the data entries between the bootstrap and the index,
+// including the ones not referenced by the index anymore
+func (me *Folder) countStoredEntries(file afero.File) (int, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	reader := tar.NewReader(file)
+	count := 0
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if header.Name == indexentry+".json" {
+			return count, nil
+		}
+
+		if header.Name != bootstrapentry && strings.HasSuffix(header.Name, ".json") {
+			count++
+		}
+	}
+}
+
+func readEntryAt(file afero.File, offset int64) (*tar.Header, []byte, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	reader := tar.NewReader(file)
+	header, err := reader.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]byte, header.Size)
+	read, err := io.ReadFull(reader, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+
+	return header, data[:read], nil
+}
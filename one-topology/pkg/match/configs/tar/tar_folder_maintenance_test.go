@@ -0,0 +1,106 @@
+//go:build unit
+
+package tar
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/terraform-provider-dynatrace/dynatrace/api"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func genTestFolder(t *testing.T) *Folder {
+	tarFolder, _, err := New(afero.NewMemMapFs(), "test")
+	assert.NilError(t, err)
+
+	values := []string{`{"a":1}`, `{"b":2}`, `{"c":3}`}
+	err = tarFolder.SaveAllCallback(len(values), func(idx int) (api.Stub, []byte, bool, error) {
+		id := string(rune('a' + idx))
+		return api.Stub{ID: id, Name: "name " + id}, []byte(values[idx]), false, nil
+	})
+	assert.NilError(t, err)
+
+	return tarFolder
+}
+
+func TestVerify(t *testing.T) {
+
+	tarFolder := genTestFolder(t)
+
+	err := tarFolder.Save(api.Stub{ID: "stub", Name: "stub"}, nil)
+	assert.NilError(t, err)
+	err = tarFolder.Save(api.Stub{ID: "a", Name: "name a"}, []byte(`{"a":10}`))
+	assert.NilError(t, err)
+	err = tarFolder.Delete("b")
+	assert.NilError(t, err)
+
+	result, err := tarFolder.Verify()
+	assert.NilError(t, err)
+
+	assert.Equal(t, result.Indexed, 3)
+	assert.Equal(t, result.StubsOnly, 1)
+	assert.Equal(t, result.Unreferenced, 2)
+	assert.Equal(t, len(result.Errors), 0)
+
+	reopened, _, err := NewExisting(tarFolder.fs, tarFolder.name[:len(tarFolder.name)-len(".tar")])
+	assert.NilError(t, err)
+
+	_, data, err := reopened.Get("a")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"a":10}`)
+}
+
+func TestVerifyDetectsCorruption(t *testing.T) {
+
+	tarFolder := genTestFolder(t)
+
+	entry := tarFolder.index["c"]
+	entry.Checksum = checksum([]byte("other"))
+	tarFolder.index["c"] = entry
+
+	entry = tarFolder.index["b"]
+	entry.Offset = tarFolder.index["a"].Offset
+	tarFolder.index["b"] = entry
+
+	result, err := tarFolder.Verify()
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Errors), 2)
+}
+
+func TestCompact(t *testing.T) {
+
+	tarFolder := genTestFolder(t)
+
+	err := tarFolder.Delete("b")
+	assert.NilError(t, err)
+	err = tarFolder.Save(api.Stub{ID: "c", Name: "name c"}, []byte(`{"c":30}`))
+	assert.NilError(t, err)
+
+	sizeBefore := getFileSize(t, tarFolder.fs, tarFolder.name)
+
+	dropped, err := tarFolder.Compact()
+	assert.NilError(t, err)
+	assert.Equal(t, dropped, 2)
+	assert.Assert(t, getFileSize(t, tarFolder.fs, tarFolder.name) < sizeBefore)
+
+	result, err := tarFolder.Verify()
+	assert.NilError(t, err)
+	assert.Equal(t, result.Indexed, 2)
+	assert.Equal(t, result.Unreferenced, 0)
+	assert.Equal(t, len(result.Errors), 0)
+
+	_, data, err := tarFolder.Get("c")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"c":30}`)
+
+	exists, err := afero.Exists(tarFolder.fs, tarFolder.name+compactsuffix)
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
+
+func getFileSize(t *testing.T, fs afero.Fs, name string) int64 {
+	info, err := fs.Stat(name)
+	assert.NilError(t, err)
+	return info.Size()
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package tar
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/dynatrace-oss/terraform-provider-dynatrace/dynatrace/api"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+// writeLegacyFolder writes a tar file with a 4-byte bootstrap, as done before the bootstrap was versioned
+func writeLegacyFolder(t *testing.T, fs afero.Fs, name string, id string, data []byte) {
+	file, err := fs.OpenFile(name+".tar", os.O_CREATE|os.O_RDWR, 0644)
+	assert.NilError(t, err)
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	legacy := &Folder{}
+
+	bootstrap := make([]byte, legacybootstrapsize)
+	binary.LittleEndian.PutUint32(bootstrap, 2048)
+	assert.NilError(t, legacy.write(writer, bootstrapentry, bootstrap))
+	assert.NilError(t, legacy.write(writer, id, data))
+
+	index, err := json.Marshal(tarIndex{id: {Stub: api.Stub{ID: id, Name: id}, Offset: firstentryoffset}})
+	assert.NilError(t, err)
+	assert.NilError(t, legacy.write(writer, indexentry, index))
+	assert.NilError(t, writer.Close())
+}
+
+func TestReadLegacyBootstrap(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeLegacyFolder(t, fs, "legacy", "a", []byte(`{"a":1}`))
+
+	tarFolder, exists, err := NewExisting(fs, "legacy")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+	assert.Equal(t, tarFolder.version, uint32(legacybootstrapversion))
+
+	_, data, err := tarFolder.Get("a")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"a":1}`)
+
+	// in place writes keep the legacy format
+	err = tarFolder.Save(api.Stub{ID: "b", Name: "b"}, []byte(`{"b":2}`))
+	assert.NilError(t, err)
+
+	reopened, _, err := NewExisting(fs, "legacy")
+	assert.NilError(t, err)
+	assert.Equal(t, reopened.version, uint32(legacybootstrapversion))
+
+	_, data, err = reopened.Get("b")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"b":2}`)
+}
+
+func TestSaveAllCallbackUpgradesLegacyBootstrap(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeLegacyFolder(t, fs, "legacy", "a", []byte(`{"a":1}`))
+
+	tarFolder, _, err := NewExisting(fs, "legacy")
+	assert.NilError(t, err)
+
+	err = tarFolder.SaveAllCallback(1, func(idx int) (api.Stub, []byte, bool, error) {
+		return api.Stub{ID: "b", Name: "b"}, []byte(`{"b":2}`), false, nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, tarFolder.version, uint32(bootstrapversion))
+
+	reopened, _, err := NewExisting(fs, "legacy")
+	assert.NilError(t, err)
+	assert.Equal(t, reopened.version, uint32(bootstrapversion))
+
+	for id, expected := range map[string]string{"a": `{"a":1}`, "b": `{"b":2}`} {
+		_, data, err := reopened.Get(id)
+		assert.NilError(t, err)
+		assert.Equal(t, string(data), expected)
+	}
+
+	result, err := reopened.Verify()
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Errors), 0)
+}
+
+func TestBootstrapHoldsOffsetsPast4GiB(t *testing.T) {
+	tarFolder := &Folder{version: bootstrapversion, indexOffset: 1 << 33}
+
+	bootstrap, err := tarFolder.encodeBootstrap()
+	assert.NilError(t, err)
+	assert.Equal(t, len(bootstrap), bootstrapsize)
+
+	decoded := &Folder{}
+	assert.NilError(t, decoded.decodeBootstrap(bootstrap))
+	assert.Equal(t, decoded.version, uint32(bootstrapversion))
+	assert.Equal(t, decoded.indexOffset, int64(1<<33))
+
+	legacy := &Folder{version: legacybootstrapversion, indexOffset: 1 << 33}
+	_, err = legacy.encodeBootstrap()
+	assert.ErrorContains(t, err, "legacy 32-bit format")
+}
+
+func TestSaveAllCallbackFailureKeepsCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	tarFolder, _, err := New(fs, "test")
+	assert.NilError(t, err)
+	err = tarFolder.Save(api.Stub{ID: "a", Name: "a"}, []byte(`{"a":1}`))
+	assert.NilError(t, err)
+
+	sizeBefore := getFileSize(t, fs, tarFolder.name)
+
+	err = tarFolder.SaveAllCallback(3, func(idx int) (api.Stub, []byte, bool, error) {
+		if idx == 2 {
+			return api.Stub{}, nil, false, errors.New("interrupted")
+		}
+		return api.Stub{ID: "new", Name: "new"}, []byte(`{"new":1}`), false, nil
+	})
+	assert.ErrorContains(t, err, "interrupted")
+
+	assert.Equal(t, getFileSize(t, fs, tarFolder.name), sizeBefore)
+	_, found := tarFolder.index["new"]
+	assert.Assert(t, !found)
+
+	exists, err := afero.Exists(fs, tarFolder.name+tempsuffix)
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+
+	reopened, _, err := NewExisting(fs, "test")
+	assert.NilError(t, err)
+	stubs, err := reopened.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(stubs), 1)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/processing"
 	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/summary"
 	"github.com/spf13/afero"
 )
 
@@ -40,6 +41,11 @@ func MatchEntities(fs afero.Fs, matchParameters match.MatchParameters, entityPer
 			continue
 		}
 
+		if matchParameters.SkipType(entitiesType) {
+			log.Debug("Skip Type: %s", entitiesType)
+			continue
+		}
+
 		entityProcessingPtr, err := processing.GenEntityProcessing(entityPerTypeSource, entityPerTypeTarget, entitiesType, false)
 		if err != nil {
 			return map[string]string{}, 0, 0, err
@@ -66,5 +72,10 @@ func MatchEntities(fs afero.Fs, matchParameters match.MatchParameters, entityPer
 
 func setStats(stats map[string]string, entitiesType string, output MatchOutputType, entityProcessingPtr *processing.MatchProcessing) map[string]string {
 	stats[entitiesType] = fmt.Sprintf("%65s %10d %12d %10d %10d %10d", entitiesType, len(output.Matches), output.calcMultiMatched(), len(output.UnMatched), entityProcessingPtr.Target.RawMatchList.Len(), entityProcessingPtr.Source.RawMatchList.Len())
+
+	summary.SetCount(entitiesType, "Matched", len(output.Matches))
+	summary.SetCount(entitiesType, "MultiMatched", output.calcMultiMatched())
+	summary.SetCount(entitiesType, "UnMatched", len(output.UnMatched))
+
 	return stats
 }
@@ -73,16 +73,27 @@ func MatchEntitiesHierarchy(fs afero.Fs, matchParameters match.MatchParameters,
 					continue
 				}
 
-				entityMatchesChild, err := readMatchesCurrent(fs, matchParameters, entityTypeChild)
+				skipChild := matchParameters.SkipType(entityTypeChild)
+				skipParent := matchParameters.SkipType(entityTypeParent)
+				if skipChild && skipParent {
+					continue
+				}
+
+				entityMatchesChild, err := readMatchesHierarchy(fs, matchParameters, entityTypeChild)
 				if err != nil {
 					return stats, err
 				}
 
-				entityMatchesParent, err := readMatchesCurrent(fs, matchParameters, entityTypeParent)
+				entityMatchesParent, err := readMatchesHierarchy(fs, matchParameters, entityTypeParent)
 				if err != nil {
 					return stats, err
 				}
 
+				if entityMatchesChild.Type == "" || entityMatchesParent.Type == "" {
+					log.Info("Skipping Hierarchy: Type: %s, Child: %s, Parent: %s, a filtered out type has no previous result", sourceHierarchy.Name, entityTypeChild, entityTypeParent)
+					continue
+				}
+
 				if entityMatchesChild.calcMultiMatched() == 0 && entityMatchesParent.calcMultiMatched() == 0 {
 					continue
 				}
@@ -109,11 +120,16 @@ func MatchEntitiesHierarchy(fs afero.Fs, matchParameters match.MatchParameters,
 
 				entityMatchesParent, entityMatchesChild = runRulesHierarchy(entityProcessingPtrChild, entityProcessingPtrParent, matchParameters, entityMatchesChild, entityMatchesParent, &childIdxToParentIdxSource, &childIdxToParentIdxTarget, sourceHierarchy)
 
-				writeMatches(fs, matchParameters, entityTypeChild, entityMatchesChild)
-				setStats(stats, entityTypeChild, entityMatchesChild, entityProcessingPtrChild)
+				// the results of a filtered out type are left as they were
+				if !skipChild {
+					writeMatches(fs, matchParameters, entityTypeChild, entityMatchesChild)
+					setStats(stats, entityTypeChild, entityMatchesChild, entityProcessingPtrChild)
+				}
 
-				writeMatches(fs, matchParameters, entityTypeParent, entityMatchesParent)
-				setStats(stats, entityTypeParent, entityMatchesParent, entityProcessingPtrParent)
+				if !skipParent {
+					writeMatches(fs, matchParameters, entityTypeParent, entityMatchesParent)
+					setStats(stats, entityTypeParent, entityMatchesParent, entityProcessingPtrParent)
+				}
 
 			}
 		}
@@ -0,0 +1,185 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	entitiesValues "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities/values"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/report"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+)
+
+// WriteReport writes a report sheet per entity type with the final matches, after the hierarchy rules
+func WriteReport(fs afero.Fs, matchParameters match.MatchParameters, entityPerTypeSource project.ConfigsPerType, entityPerTypeTarget project.ConfigsPerType) error {
+
+	sheets := make([]report.Sheet, 0, len(entityPerTypeTarget))
+
+	for entitiesType := range entityPerTypeTarget {
+		if entitiesType == client.TypesAsEntitiesType || matchParameters.SkipType(entitiesType) {
+			continue
+		}
+
+		sourceEntities, err := unmarshalReportEntities(entityPerTypeSource[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to read the source entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		targetEntities, err := unmarshalReportEntities(entityPerTypeTarget[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to read the target entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		output, err := readMatchesCurrent(fs, matchParameters, entitiesType)
+		if err != nil {
+			return err
+		}
+
+		sheets = append(sheets, report.Sheet{
+			Name: entitiesType,
+			Rows: genReportRows(sourceEntities, genDisplayNames(targetEntities), output),
+		})
+	}
+
+	return report.Write(fs, matchParameters.OutputDir, matchParameters.ReportFormat, sheets)
+}
+
+func unmarshalReportEntities(entityConfigs []config.Config) ([]entitiesValues.Value, error) {
+	rawEntityList, err := entitiesValues.UnmarshalEntities(entityConfigs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return *rawEntityList.GetValues(), nil
+}
+
+func genDisplayNames(entities []entitiesValues.Value) map[string]string {
+	displayNames := make(map[string]string, len(entities))
+	for _, value := range entities {
+		if value.DisplayName != nil {
+			displayNames[value.EntityId] = *value.DisplayName
+		}
+	}
+	return displayNames
+}
+
+// genReportRows writes a row per source entity, and a row per candidate for multi matched entities.
+// Source entities left out of the results, by the source selector or the specific actions, have no row.
+func genReportRows(sourceEntities []entitiesValues.Value, targetDisplayNames map[string]string, output MatchOutputType) []report.Row {
+
+	candidates := genMultiMatchCandidates(output)
+	unMatched := genUnMatchedSet(output)
+	rows := make([]report.Row, 0, len(sourceEntities))
+
+	for _, value := range sourceEntities {
+		row := report.Row{
+			SourceId: value.EntityId,
+			Status:   report.StatusUnMatched,
+		}
+		if value.DisplayName != nil {
+			row.SourceName = *value.DisplayName
+		}
+
+		if entityIdTarget, found := output.Matches[value.EntityId]; found {
+			row.TargetId = entityIdTarget
+			row.TargetName = targetDisplayNames[entityIdTarget]
+			row.Status = report.StatusMatched
+			row.MatchTier = output.MatchTiers[value.EntityId]
+		} else if entityIdTargetList, found := candidates[value.EntityId]; found && len(entityIdTargetList) > 0 {
+			for _, entityIdTarget := range entityIdTargetList {
+				multiRow := row
+				multiRow.TargetId = entityIdTarget
+				multiRow.TargetName = targetDisplayNames[entityIdTarget]
+				multiRow.Status = report.StatusMulti
+				rows = append(rows, multiRow)
+			}
+			continue
+		} else if !unMatched[value.EntityId] {
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].SourceId < rows[j].SourceId
+	})
+
+	return rows
+}
+
+// GenEntityStats counts the matches of a type like the stats tables, the target total is not part of the matches
+func GenEntityStats(entitiesType string, output MatchOutputType) report.EntityStats {
+	entityStats := report.EntityStats{
+		Type:         entitiesType,
+		Matched:      len(output.Matches),
+		MultiMatched: output.calcMultiMatched(),
+		UnMatched:    len(output.UnMatched),
+	}
+	entityStats.Source = entityStats.Matched + entityStats.MultiMatched + entityStats.UnMatched
+
+	return entityStats
+}
+
+// WriteHtmlReport writes the stats of the final matches, after the hierarchy rules, as a static html file
+func WriteHtmlReport(fs afero.Fs, matchParameters match.MatchParameters, entityPerTypeSource project.ConfigsPerType, entityPerTypeTarget project.ConfigsPerType) error {
+
+	htmlReport := report.HtmlReport{
+		Name:              matchParameters.Name,
+		MatchType:         matchParameters.Type,
+		SourceEnvironment: matchParameters.Source.Environment,
+		TargetEnvironment: matchParameters.Target.Environment,
+		GeneratedAt:       time.Now().UTC().Format(time.RFC3339),
+		EntityStats:       make([]report.EntityStats, 0, len(entityPerTypeTarget)),
+	}
+
+	for entitiesType := range entityPerTypeTarget {
+		if entitiesType == client.TypesAsEntitiesType || matchParameters.SkipType(entitiesType) {
+			continue
+		}
+
+		sourceEntities, err := unmarshalReportEntities(entityPerTypeSource[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to read the source entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		targetEntities, err := unmarshalReportEntities(entityPerTypeTarget[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to read the target entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		output, err := readMatchesCurrent(fs, matchParameters, entitiesType)
+		if err != nil {
+			return err
+		}
+
+		entityStats := GenEntityStats(entitiesType, output)
+		entityStats.Source = len(sourceEntities)
+		entityStats.Target = len(targetEntities)
+		entityStats.HasTarget = true
+
+		htmlReport.EntityStats = append(htmlReport.EntityStats, entityStats)
+	}
+
+	report.SortEntityStats(htmlReport.EntityStats)
+
+	return report.WriteHtml(fs, matchParameters.HtmlReportFile, htmlReport)
+}
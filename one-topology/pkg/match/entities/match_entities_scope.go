@@ -0,0 +1,128 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entities
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/scope"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+)
+
+// ApplySourceScope moves the source entities out of the scope of the source selector from the matches to OutOfScope.
+// It runs once all the rules, including the hierarchy rules, matched the full source,
+// so that entities out of scope can not take the place of their own matches.
+func ApplySourceScope(fs afero.Fs, matchParameters match.MatchParameters, entityPerTypeSource project.ConfigsPerType) error {
+
+	selector := matchParameters.SourceSelector
+	if !selector.HasEntityCriteria() {
+		return nil
+	}
+
+	hasEntityDetails := false
+	outOfScopeCount := 0
+
+	for entitiesType, entityConfigs := range entityPerTypeSource {
+		if entitiesType == client.TypesAsEntitiesType || matchParameters.SkipType(entitiesType) {
+			continue
+		}
+
+		sourceEntities, err := scope.UnmarshalEntities(entityConfigs)
+		if err != nil {
+			return fmt.Errorf("failed to read the source entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		outOfScope := map[string]bool{}
+		for _, entity := range sourceEntities {
+			if entity.Tags != nil || entity.ManagementZones != nil {
+				hasEntityDetails = true
+			}
+			if !selector.IsEntityInScope(entity) {
+				outOfScope[entity.EntityId] = true
+			}
+		}
+
+		if len(outOfScope) == 0 {
+			continue
+		}
+
+		output, err := readMatchesCurrent(fs, matchParameters, entitiesType)
+		if err != nil {
+			return err
+		}
+
+		output.removeOutOfScope(outOfScope)
+
+		err = writeMatches(fs, matchParameters, entitiesType, output)
+		if err != nil {
+			return fmt.Errorf("failed to persist matches of type: %s, see error: %w", entitiesType, err)
+		}
+
+		log.Info("%s: %d of %d source entities are out of the scope of the source selector", entitiesType, len(outOfScope), len(sourceEntities))
+		outOfScopeCount += len(outOfScope)
+	}
+
+	if selector.NeedsEntityDetails() && !hasEntityDetails {
+		log.Warn("The source selector uses management zones or tags, but the source entities were downloaded without them")
+	}
+
+	log.Info("Excluded %d source entities out of the scope of the source selector", outOfScopeCount)
+
+	return nil
+}
+
+func (me *MatchOutputType) removeOutOfScope(outOfScope map[string]bool) {
+
+	for entityIdSource := range outOfScope {
+		delete(me.Matches, entityIdSource)
+		delete(me.MultiMatched, entityIdSource)
+		delete(me.MatchTiers, entityIdSource)
+	}
+
+	unMatched := make([]string, 0, len(me.UnMatched))
+	for _, entityIdSource := range me.UnMatched {
+		if !outOfScope[entityIdSource] {
+			unMatched = append(unMatched, entityIdSource)
+		}
+	}
+	me.UnMatched = unMatched
+
+	for postProcessId, postProcessSource := range me.PostProcessSource {
+		ids := make([]string, 0, len(postProcessSource.IDs))
+		for _, entityIdSource := range postProcessSource.IDs {
+			if !outOfScope[entityIdSource] {
+				ids = append(ids, entityIdSource)
+			}
+		}
+
+		if len(ids) == 0 {
+			delete(me.PostProcessSource, postProcessId)
+			delete(me.PostProcessTarget, postProcessId)
+			continue
+		}
+		postProcessSource.IDs = ids
+	}
+
+	me.OutOfScope = make([]string, 0, len(outOfScope))
+	for entityIdSource := range outOfScope {
+		me.OutOfScope = append(me.OutOfScope, entityIdSource)
+	}
+	sort.Strings(me.OutOfScope)
+}
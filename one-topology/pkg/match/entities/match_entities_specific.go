@@ -0,0 +1,107 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entities
+
+import (
+	"fmt"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/slices"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+)
+
+func skipEntityStatus(matchParameters match.MatchParameters, status string) bool {
+	if len(matchParameters.SpecificStatuses) == 0 {
+		return false
+	}
+
+	return !slices.Contains(matchParameters.SpecificStatuses, status)
+}
+
+// readMatchesHierarchy reads the matches of a type of a hierarchy.
+// The matches of a filtered out type are not part of the current results, the previous results are used instead.
+func readMatchesHierarchy(fs afero.Fs, matchParameters match.MatchParameters, entitiesType string) (MatchOutputType, error) {
+	if matchParameters.SkipType(entitiesType) {
+		return readMatchesPrev(fs, matchParameters, entitiesType)
+	}
+
+	return readMatchesCurrent(fs, matchParameters, entitiesType)
+}
+
+// ApplySpecificStatuses only keeps the categories of results of the specific actions.
+// It runs once the hierarchy rules used the multi matched and unmatched entities.
+func ApplySpecificStatuses(fs afero.Fs, matchParameters match.MatchParameters, entityPerTypeTarget project.ConfigsPerType) error {
+
+	if len(matchParameters.SpecificStatuses) == 0 {
+		return nil
+	}
+
+	for entitiesType := range entityPerTypeTarget {
+		if entitiesType == client.TypesAsEntitiesType || matchParameters.SkipType(entitiesType) {
+			continue
+		}
+
+		output, err := readMatchesCurrent(fs, matchParameters, entitiesType)
+		if err != nil {
+			return err
+		}
+
+		output.removeSkippedStatuses(matchParameters)
+
+		err = writeMatches(fs, matchParameters, entitiesType, output)
+		if err != nil {
+			return fmt.Errorf("failed to persist matches of type: %s, see error: %w", entitiesType, err)
+		}
+	}
+
+	log.Info("Wrote the %v entity matches only", matchParameters.SpecificStatuses)
+
+	return nil
+}
+
+func (me *MatchOutputType) removeSkippedStatuses(matchParameters match.MatchParameters) {
+
+	if skipEntityStatus(matchParameters, match.STATUS_MATCHED) {
+		for entityIdSource := range me.Matches {
+			delete(me.MatchTiers, entityIdSource)
+		}
+		me.Matches = map[string]string{}
+	}
+
+	if skipEntityStatus(matchParameters, match.STATUS_MULTI_MATCH) {
+		for entityIdSource := range me.MultiMatched {
+			delete(me.MatchTiers, entityIdSource)
+		}
+		me.MultiMatched = map[string][]string{}
+		me.PostProcessSource = map[string]*PostProcessOutput{}
+		me.PostProcessTarget = map[string]*PostProcessOutput{}
+	}
+
+	if skipEntityStatus(matchParameters, match.STATUS_UNMATCHED) {
+		me.UnMatched = []string{}
+	}
+}
+
+// genUnMatchedSet lists the unmatched source entities, entities in no category were left out of the results
+func genUnMatchedSet(output MatchOutputType) map[string]bool {
+	unMatched := make(map[string]bool, len(output.UnMatched))
+	for _, entityIdSource := range output.UnMatched {
+		unMatched[entityIdSource] = true
+	}
+	return unMatched
+}
@@ -0,0 +1,102 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	entitiesValues "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities/values"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func genStatusesOutput() MatchOutputType {
+	return MatchOutputType{
+		Type:         "HOST",
+		Matches:      map[string]string{"HOST-1": "HOST-A"},
+		MultiMatched: map[string][]string{"HOST-2": {"HOST-B", "HOST-C"}},
+		UnMatched:    []string{"HOST-3"},
+		PostProcessSource: map[string]*PostProcessOutput{
+			"0": {IDs: []string{"HOST-4"}},
+		},
+		PostProcessTarget: map[string]*PostProcessOutput{
+			"0": {IDs: []string{"HOST-D"}},
+		},
+		MatchTiers: map[string]string{"HOST-1": matchTierPrevious},
+	}
+}
+
+func TestRemoveSkippedStatuses(t *testing.T) {
+
+	output := genStatusesOutput()
+	output.removeSkippedStatuses(match.MatchParameters{})
+	assert.DeepEqual(t, output, genStatusesOutput())
+
+	output = genStatusesOutput()
+	output.removeSkippedStatuses(match.MatchParameters{SpecificStatuses: []string{match.STATUS_UNMATCHED}})
+	assert.Equal(t, len(output.Matches), 0)
+	assert.Equal(t, len(output.MatchTiers), 0)
+	assert.Equal(t, output.calcMultiMatched(), 0)
+	assert.DeepEqual(t, output.UnMatched, []string{"HOST-3"})
+
+	output = genStatusesOutput()
+	output.removeSkippedStatuses(match.MatchParameters{SpecificStatuses: []string{match.STATUS_MATCHED, match.STATUS_MULTI_MATCH}})
+	assert.DeepEqual(t, output.Matches, map[string]string{"HOST-1": "HOST-A"})
+	assert.Equal(t, output.calcMultiMatched(), 2)
+	assert.Equal(t, len(output.UnMatched), 0)
+}
+
+func TestReadMatchesHierarchy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	matchParameters := match.MatchParameters{
+		OutputDir:     "output",
+		PrevResultDir: "previous",
+		SpecificTypes: []string{"SERVICE"},
+	}
+
+	current := genStatusesOutput()
+	previous := genStatusesOutput()
+	previous.Matches = map[string]string{"HOST-1": "HOST-Z"}
+
+	assert.NilError(t, writeMatches(fs, matchParameters, "HOST", current))
+	assert.NilError(t, writeMatches(fs, match.MatchParameters{OutputDir: "previous"}, "HOST", previous))
+
+	output, err := readMatchesHierarchy(fs, matchParameters, "HOST")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, output.Matches, previous.Matches)
+
+	output, err = readMatchesHierarchy(fs, matchParameters, "PROCESS_GROUP")
+	assert.NilError(t, err)
+	assert.Equal(t, output.Type, "")
+
+	matchParameters.SpecificTypes = []string{"HOST"}
+	output, err = readMatchesHierarchy(fs, matchParameters, "HOST")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, output.Matches, current.Matches)
+}
+
+func TestGenReportRowsLeftOut(t *testing.T) {
+	output := genStatusesOutput()
+	output.removeSkippedStatuses(match.MatchParameters{SpecificStatuses: []string{match.STATUS_UNMATCHED}})
+
+	sourceEntities := []entitiesValues.Value{{EntityId: "HOST-1"}, {EntityId: "HOST-2"}, {EntityId: "HOST-3"}, {EntityId: "HOST-4"}}
+	rows := genReportRows(sourceEntities, map[string]string{}, output)
+
+	assert.Equal(t, len(rows), 1)
+	assert.Equal(t, rows[0].SourceId, "HOST-3")
+}
@@ -0,0 +1,177 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entities
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	entitiesValues "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/entities/values"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/store"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+)
+
+const (
+	storeStatusMatched   = "Matched"
+	storeStatusUnMatched = "UnMatched"
+)
+
+// WriteMatchStore writes the entities of both environments and their final matches, after the hierarchy rules, into the SQLite store
+func WriteMatchStore(fs afero.Fs, matchParameters match.MatchParameters, entityPerTypeSource project.ConfigsPerType, entityPerTypeTarget project.ConfigsPerType) error {
+
+	matchStore, err := store.CreateForMatch(fs, matchParameters)
+	if err != nil {
+		return err
+	}
+	defer matchStore.Close()
+
+	entitiesTypes := make([]string, 0, len(entityPerTypeTarget))
+	for entitiesType := range entityPerTypeTarget {
+		if entitiesType == client.TypesAsEntitiesType || matchParameters.SkipType(entitiesType) {
+			continue
+		}
+		entitiesTypes = append(entitiesTypes, entitiesType)
+	}
+	sort.Strings(entitiesTypes)
+
+	for _, entitiesType := range entitiesTypes {
+
+		sourceEntities, err := writeStoreEntities(matchStore, store.EnvSource, entitiesType, entityPerTypeSource[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to store the source entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		_, err = writeStoreEntities(matchStore, store.EnvTarget, entitiesType, entityPerTypeTarget[entitiesType])
+		if err != nil {
+			return fmt.Errorf("failed to store the target entities of type: %s, see error: %w", entitiesType, err)
+		}
+
+		output, err := readMatchesCurrent(fs, matchParameters, entitiesType)
+		if err != nil {
+			return err
+		}
+
+		err = writeStoreMatches(matchStore, entitiesType, sourceEntities, output)
+		if err != nil {
+			return fmt.Errorf("failed to store the matches of type: %s, see error: %w", entitiesType, err)
+		}
+	}
+
+	err = matchStore.Commit()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Wrote match store %s with %d entity types", matchParameters.SqliteFile, len(entitiesTypes))
+
+	return nil
+}
+
+func writeStoreEntities(matchStore *store.Store, environment string, entitiesType string, entityConfigs []config.Config) ([]entitiesValues.Value, error) {
+
+	rawEntityList, err := entitiesValues.UnmarshalEntities(entityConfigs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range *rawEntityList.GetValues() {
+		err = matchStore.WriteEntity(store.Entity{
+			Environment:  environment,
+			EntityType:   entitiesType,
+			EntityId:     value.EntityId,
+			DisplayName:  value.DisplayName,
+			FirstSeenTms: value.FirstSeenTms,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return *rawEntityList.GetValues(), nil
+}
+
+// writeStoreMatches writes the matches, the multi match candidates and a status for every source entity
+func writeStoreMatches(matchStore *store.Store, entitiesType string, sourceEntities []entitiesValues.Value, output MatchOutputType) error {
+
+	for entityIdSource, entityIdTarget := range output.Matches {
+		err := matchStore.WriteMatch(store.Match{Kind: store.KindEntity, MatchType: entitiesType, SourceId: entityIdSource, TargetId: entityIdTarget})
+		if err != nil {
+			return err
+		}
+	}
+
+	candidates := genMultiMatchCandidates(output)
+	unMatched := genUnMatchedSet(output)
+
+	for entityIdSource, entityIdTargetList := range candidates {
+		for _, entityIdTarget := range entityIdTargetList {
+			err := matchStore.WriteMultiMatchCandidate(store.Match{Kind: store.KindEntity, MatchType: entitiesType, SourceId: entityIdSource, TargetId: entityIdTarget})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, value := range sourceEntities {
+		status := store.Status{
+			Kind:      store.KindEntity,
+			MatchType: entitiesType,
+			SourceId:  value.EntityId,
+			Status:    storeStatusUnMatched,
+		}
+
+		if entityIdTarget, found := output.Matches[value.EntityId]; found {
+			status.TargetId = entityIdTarget
+			status.Status = storeStatusMatched
+		} else if _, found := candidates[value.EntityId]; found {
+			status.MultiMatched = true
+		} else if !unMatched[value.EntityId] {
+			continue
+		}
+
+		err := matchStore.WriteStatus(status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// genMultiMatchCandidates lists the target candidates of every source entity matched more than once.
+// The candidates of entities left by the post processing are the target entities left by the same rules.
+func genMultiMatchCandidates(output MatchOutputType) map[string][]string {
+
+	candidates := make(map[string][]string, len(output.MultiMatched))
+	for entityIdSource, entityIdTargetList := range output.MultiMatched {
+		candidates[entityIdSource] = entityIdTargetList
+	}
+	for postProcessId, postProcessSource := range output.PostProcessSource {
+		entityIdTargetList := []string{}
+		if postProcessTarget, found := output.PostProcessTarget[postProcessId]; found {
+			entityIdTargetList = postProcessTarget.IDs
+		}
+		for _, entityIdSource := range postProcessSource.IDs {
+			candidates[entityIdSource] = entityIdTargetList
+		}
+	}
+
+	return candidates
+}
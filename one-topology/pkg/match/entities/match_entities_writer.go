@@ -142,6 +142,22 @@ type MatchOutputType struct {
 	UnMatched         []string                      `json:"unmatched"`
 	PostProcessSource map[string]*PostProcessOutput `json:"postProcessSource"`
 	PostProcessTarget map[string]*PostProcessOutput `json:"postProcessTarget"`
+	MatchTiers        map[string]string             `json:"matchTiers,omitempty"`
+	OutOfScope        []string                      `json:"outOfScope,omitempty"`
+}
+
+const (
+	matchTierPrevious  = "Previous Match"
+	matchTierFirstSeen = "Most Recent First Seen"
+	matchTierHierarchy = "Hierarchy"
+)
+
+// setMatchTier records how a source entity was matched
+func (me *MatchOutputType) setMatchTier(entityIdSource string, tier string) {
+	if me.MatchTiers == nil {
+		me.MatchTiers = map[string]string{}
+	}
+	me.MatchTiers[entityIdSource] = tier
 }
 
 type MatchKey struct {
@@ -190,6 +206,7 @@ func genOutputPayload(entityProcessingPtr *processing.MatchProcessing, remaining
 		UnMatched:         make([]string, 0, len(*entityProcessingPtr.Source.CurrentRemainingMatch)),
 		PostProcessSource: map[string]*PostProcessOutput{},
 		PostProcessTarget: map[string]*PostProcessOutput{},
+		MatchTiers:        make(map[string]string, len(*matchedEntities)),
 	}
 
 	reverseMatches := map[string]string{}
@@ -222,6 +239,7 @@ func genOutputPayload(entityProcessingPtr *processing.MatchProcessing, remaining
 		}
 
 		matchOutput.Matches[entityIdSource] = entityIdTarget
+		matchOutput.setMatchTier(entityIdSource, entityProcessingPtr.GetMatchTier(sourceI))
 
 	}
 
@@ -232,6 +250,7 @@ func genOutputPayload(entityProcessingPtr *processing.MatchProcessing, remaining
 		}
 
 		matchOutput.Matches[entityIdSourcePrev] = entityIdTargetPrev
+		matchOutput.setMatchTier(entityIdSourcePrev, matchTierPrevious)
 
 		_, found := matchOutput.MultiMatched[entityIdSourcePrev]
 		if found {
@@ -246,6 +265,7 @@ func genOutputPayload(entityProcessingPtr *processing.MatchProcessing, remaining
 		}
 
 		matchOutput.Matches[entityIdSourceFirstSeen] = entityIdTargetFirstSeen
+		matchOutput.setMatchTier(entityIdSourceFirstSeen, matchTierFirstSeen)
 
 		_, found := matchOutput.MultiMatched[entityIdSourceFirstSeen]
 		if found {
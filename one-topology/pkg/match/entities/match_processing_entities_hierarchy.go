@@ -29,20 +29,22 @@ func runRulesHierarchy(entityProcessingPtrChild *processing.MatchProcessing, ent
 
 	_, matchedEntitiesParent, matchedEntitiesChild := ruleMapGenerator.RunHierarchyRuleAll(entityProcessingPtrChild, entityProcessingPtrParent, entityMatchesChild, entityMatchesParent, childIdxToParentIdxSource, childIdxToParentIdxTarget, sourceHierarchy)
 
-	updateMatches(matchedEntitiesParent, entityProcessingPtrParent, &entityMatchesParent)
-	updateMatches(matchedEntitiesChild, entityProcessingPtrChild, &entityMatchesChild)
+	matchTier := matchTierHierarchy + ": " + sourceHierarchy.Name
+	updateMatches(matchedEntitiesParent, entityProcessingPtrParent, &entityMatchesParent, matchTier)
+	updateMatches(matchedEntitiesChild, entityProcessingPtrChild, &entityMatchesChild, matchTier)
 
 	return entityMatchesParent, entityMatchesChild
 
 }
 
-func updateMatches(matchedEntities *map[int]int, entityProcessingPtr *processing.MatchProcessing, entityMatches *MatchOutputType) {
+func updateMatches(matchedEntities *map[int]int, entityProcessingPtr *processing.MatchProcessing, entityMatches *MatchOutputType, matchTier string) {
 	for sourceIdx, targetIdx := range *matchedEntities {
 
 		entityIdSource := (*entityProcessingPtr.Source.RawMatchList.GetValues())[sourceIdx].EntityId
 		entityIdTarget := (*entityProcessingPtr.Target.RawMatchList.GetValues())[targetIdx].EntityId
 
 		entityMatches.Matches[entityIdSource] = entityIdTarget
+		entityMatches.setMatchTier(entityIdSource, matchTier)
 
 		_, found := entityMatches.MultiMatched[entityIdSource]
 		if found {
@@ -15,8 +15,10 @@
 package match
 
 import (
+	"fmt"
 	"runtime"
 	"sort"
+	"strings"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/processing"
@@ -110,6 +112,16 @@ func keepMatches(matchedEntities map[int]int, uniqueMatch []processing.CompareRe
 	return matchedEntities
 }
 
+// genMatchTier labels a rule type by its rank, as rule types are run by descending weight, followed by its rule names
+func genMatchTier(rank int, indexRuleType rules.IndexRuleType) string {
+	ruleNames := make([]string, len(indexRuleType.Rules))
+	for idx, indexRule := range indexRuleType.Rules {
+		ruleNames[idx] = indexRule.Name
+	}
+
+	return fmt.Sprintf("%d: %s", rank+1, strings.Join(ruleNames, ", "))
+}
+
 func (i *IndexRuleMapGenerator) RunIndexRuleAll(matchProcessingPtr *processing.MatchProcessing) (*processing.CompareResultList, *map[int]int) {
 	matchedEntities := map[int]int{}
 	remainingResultsPtr := &processing.CompareResultList{}
@@ -121,7 +133,7 @@ func (i *IndexRuleMapGenerator) RunIndexRuleAll(matchProcessingPtr *processing.M
 
 	allPostProcessLists := []processing.PostProcess{}
 
-	for _, indexRuleType := range ruleTypes {
+	for rank, indexRuleType := range ruleTypes {
 		resultListPtr := processing.NewCompareResultList(&indexRuleType)
 		matchProcessingPtr.PrepareRemainingMatch(true, indexRuleType.IsSeed, remainingResultsPtr)
 
@@ -142,6 +154,11 @@ func (i *IndexRuleMapGenerator) RunIndexRuleAll(matchProcessingPtr *processing.M
 
 		matchedEntities = keepMatches(matchedEntities, uniqueMatchEntities)
 
+		matchTier := genMatchTier(rank, indexRuleType)
+		for _, result := range uniqueMatchEntities {
+			matchProcessingPtr.SetMatchTier(result.LeftId, matchTier)
+		}
+
 		allPostProcessLists = append(allPostProcessLists, resultListPtr.PostProcessList...)
 		runtime.GC()
 	}
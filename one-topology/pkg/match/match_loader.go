@@ -19,11 +19,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/cmd/monaco/cmdutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/errutils"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/slices"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match/scope"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/snapshot"
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v2"
 )
@@ -34,35 +38,45 @@ var validMatchTypes = map[string]bool{
 }
 
 const (
-	ACTION_ADD         = "Add"
-	ACTION_DELETE      = "Delete"
-	ACTION_UPDATE      = "Update"
-	ACTION_IDENTICAL   = "Identical"
-	ACTION_PREEMPTIVE  = "Preemptive"
-	STATUS_MULTI_MATCH = "Multi Matched"
-
-	ACTION_ADD_RUNE         = 'A'
-	ACTION_DELETE_RUNE      = 'D'
-	ACTION_UPDATE_RUNE      = 'U'
-	ACTION_IDENTICAL_RUNE   = 'I'
-	ACTION_PREEMPTIVE_RUNE  = 'P'
-	STATUS_MULTI_MATCH_RUNE = 'M'
+	ACTION_ADD          = "Add"
+	ACTION_DELETE       = "Delete"
+	ACTION_UPDATE       = "Update"
+	ACTION_IDENTICAL    = "Identical"
+	ACTION_PREEMPTIVE   = "Preemptive"
+	ACTION_INCOMPATIBLE = "Incompatible"
+	STATUS_MULTI_MATCH  = "Multi Matched"
+	STATUS_MATCHED      = "Matched"
+	STATUS_UNMATCHED    = "UnMatched"
+
+	ACTION_ADD_RUNE          = 'A'
+	ACTION_DELETE_RUNE       = 'D'
+	ACTION_UPDATE_RUNE       = 'U'
+	ACTION_IDENTICAL_RUNE    = 'I'
+	ACTION_PREEMPTIVE_RUNE   = 'P'
+	ACTION_INCOMPATIBLE_RUNE = 'X'
+	STATUS_MULTI_MATCH_RUNE  = 'M'
 )
 
 var ActionMap = map[string]rune{
-	ACTION_ADD:        ACTION_ADD_RUNE,
-	ACTION_DELETE:     ACTION_DELETE_RUNE,
-	ACTION_UPDATE:     ACTION_UPDATE_RUNE,
-	ACTION_IDENTICAL:  ACTION_IDENTICAL_RUNE,
-	ACTION_PREEMPTIVE: ACTION_PREEMPTIVE_RUNE,
+	ACTION_ADD:          ACTION_ADD_RUNE,
+	ACTION_DELETE:       ACTION_DELETE_RUNE,
+	ACTION_UPDATE:       ACTION_UPDATE_RUNE,
+	ACTION_IDENTICAL:    ACTION_IDENTICAL_RUNE,
+	ACTION_PREEMPTIVE:   ACTION_PREEMPTIVE_RUNE,
+	ACTION_INCOMPATIBLE: ACTION_INCOMPATIBLE_RUNE,
 }
 
+// EntityStatuses are the specific statuses of entity matches, the categories of results to write
+var EntityStatuses = []string{STATUS_MATCHED, STATUS_MULTI_MATCH, STATUS_UNMATCHED}
+
 const SOURCE_ENV = "Source"
 const TARGET_ENV = "Target"
 
 type matchLoaderContext struct {
 	fs            afero.Fs
 	matchFilePath string
+	// snapshots holds the metadata of the snapshots already extracted, by snapshot path
+	snapshots map[string]snapshot.Metadata
 }
 
 type MatchParameters struct {
@@ -76,11 +90,30 @@ type MatchParameters struct {
 	SkipSpecificTypes bool
 	SpecificTypes     []string
 	SpecificActions   []rune
+	SpecificStatuses  []string
 	SelfMatch         bool
+	EmitProjectDir    string
+	SqliteFile        string
+	ReportFormat      string
+	HtmlReportFile    string
+	SourceSelector    *scope.Selector
 	Source            MatchParametersEnv
 	Target            MatchParametersEnv
 }
 
+// SkipType returns whether the config or entities type is filtered out by the specific types of the match file
+func (p MatchParameters) SkipType(typeName string) bool {
+	if len(p.SpecificTypes) == 0 {
+		return false
+	}
+
+	if slices.Contains(p.SpecificTypes, typeName) {
+		return p.SkipSpecificTypes
+	}
+
+	return !p.SkipSpecificTypes
+}
+
 type MatchParametersEnv struct {
 	EnvType     string
 	WorkingDir  string
@@ -89,6 +122,16 @@ type MatchParametersEnv struct {
 	Manifest    manifest.Manifest
 }
 
+// ProjectFolder returns the folder of the project of the environment, as defined in its manifest
+func (e MatchParametersEnv) ProjectFolder() string {
+	projectPath := e.Project
+	if projectDefinition, found := e.Manifest.Projects[e.Project]; found && projectDefinition.Path != "" {
+		projectPath = projectDefinition.Path
+	}
+
+	return filepath.Join(e.WorkingDir, projectPath)
+}
+
 type MatchFileDefinition struct {
 	Name              string            `yaml:"name"`
 	Type              string            `yaml:"type"`
@@ -99,13 +142,17 @@ type MatchFileDefinition struct {
 	SkipSpecificTypes bool              `yaml:"skipSpecificTypes,omitempty"`
 	SpecificTypes     []string          `yaml:"specificTypes,omitempty"`
 	SpecificActions   []string          `yaml:"specificActions,omitempty"`
+	SpecificStatuses  []string          `yaml:"specificStatuses,omitempty"`
 	SelfMatch         bool              `yaml:"selfMatch"`
+	SourceSelector    scope.Definition  `yaml:"sourceSelector,omitempty"`
 	Source            EnvInfoDefinition `yaml:"sourceInfo"`
 	Target            EnvInfoDefinition `yaml:"targetInfo"`
 }
 
 type EnvInfoDefinition struct {
 	ManifestPath string `yaml:"manifestPath"`
+	// SnapshotPath references a snapshot instead of a manifest, whose project and environment are defaulted
+	SnapshotPath string `yaml:"snapshotPath,omitempty"`
 	Project      string `yaml:"project"`
 	Environment  string `yaml:"environment"`
 }
@@ -116,10 +163,18 @@ type MatchEntryParserError struct {
 	Reason string
 }
 
-func getParameterEnv(context *matchLoaderContext, matchInfoDef EnvInfoDefinition, envType string) (MatchParametersEnv, []error) {
+func getParameterEnv(context *matchLoaderContext, matchInfoDef EnvInfoDefinition, envType string, matchType string) (MatchParametersEnv, []error) {
 	matchParametersEnv := MatchParametersEnv{}
 	var errors []error
 
+	if matchInfoDef.SnapshotPath != "" {
+		var err error
+		matchInfoDef, err = resolveSnapshot(context, matchInfoDef, matchType)
+		if err != nil {
+			return matchParametersEnv, []error{fmt.Errorf("%s: %w", envType, err)}
+		}
+	}
+
 	man, err := cmdutils.GetManifest(context.fs, matchInfoDef.ManifestPath)
 	if err != nil {
 		errors = append(errors, err)
@@ -142,6 +197,44 @@ func getParameterEnv(context *matchLoaderContext, matchInfoDef EnvInfoDefinition
 
 }
 
+// resolveSnapshot extracts the snapshot next to it and references its manifest.
+// The project defaults to the project of the match type, and the environment to the one captured by the snapshot
+func resolveSnapshot(context *matchLoaderContext, matchInfoDef EnvInfoDefinition, matchType string) (EnvInfoDefinition, error) {
+	if matchInfoDef.ManifestPath != "" {
+		return EnvInfoDefinition{}, fmt.Errorf("either manifestPath or snapshotPath can be defined, not both")
+	}
+
+	extractionFolder := snapshot.ExtractionFolder(matchInfoDef.SnapshotPath)
+
+	metadata, found := context.snapshots[matchInfoDef.SnapshotPath]
+	if !found {
+		var err error
+		metadata, err = snapshot.Extract(context.fs, matchInfoDef.SnapshotPath, extractionFolder)
+		if err != nil {
+			return EnvInfoDefinition{}, err
+		}
+		context.snapshots[matchInfoDef.SnapshotPath] = metadata
+		log.Info("Using snapshot %s of environment %q (%s), created at %s, extracted into %s",
+			matchInfoDef.SnapshotPath, metadata.Environment, metadata.EnvironmentUrl, metadata.CreatedAt.Format(time.RFC3339), extractionFolder)
+	}
+
+	matchInfoDef.ManifestPath = filepath.Join(extractionFolder, snapshot.ManifestFileName)
+	if matchInfoDef.Project == "" {
+		matchInfoDef.Project = snapshotProjects[matchType]
+	}
+	if matchInfoDef.Environment == "" {
+		matchInfoDef.Environment = metadata.Environment
+	}
+
+	return matchInfoDef, nil
+}
+
+// snapshotProjects are the projects of a snapshot, by match type
+var snapshotProjects = map[string]string{
+	"entities": snapshot.EntitiesProject,
+	"configs":  snapshot.ConfigsProject,
+}
+
 func getMapKeys(theMap map[string]bool) []string {
 	keys := make([]string, len(theMap))
 
@@ -187,6 +280,7 @@ func LoadMatchingParameters(fs afero.Fs, matchFileName string) (matchParameters
 	context := &matchLoaderContext{
 		fs:            fs,
 		matchFilePath: matchFilePath,
+		snapshots:     map[string]snapshot.Metadata{},
 	}
 
 	matchFileDef, err := parseMatchFile(context)
@@ -255,7 +349,9 @@ func LoadMatchingParameters(fs afero.Fs, matchFileName string) (matchParameters
 		matchParameters.SpecificTypes = matchFileDef.SpecificTypes
 	}
 
-	if len(matchFileDef.SpecificActions) > 0 {
+	if len(matchFileDef.SpecificActions) > 0 && matchParameters.Type == "entities" {
+		errors = append(errors, fmt.Errorf("specificActions only apply to configs, use specificStatuses to write some categories of entity matches only"))
+	} else if len(matchFileDef.SpecificActions) > 0 {
 		matchParameters.SpecificActions = make([]rune, len(matchFileDef.SpecificActions))
 		for i, actionLabel := range matchFileDef.SpecificActions {
 
@@ -268,14 +364,33 @@ func LoadMatchingParameters(fs afero.Fs, matchFileName string) (matchParameters
 		}
 	}
 
+	if len(matchFileDef.SpecificStatuses) > 0 && matchParameters.Type != "entities" {
+		errors = append(errors, fmt.Errorf("specificStatuses only apply to entities, use specificActions to write some actions of config matches only"))
+	} else {
+		for _, statusLabel := range matchFileDef.SpecificStatuses {
+			if slices.Contains(EntityStatuses, statusLabel) {
+				matchParameters.SpecificStatuses = append(matchParameters.SpecificStatuses, statusLabel)
+			} else {
+				errors = append(errors, fmt.Errorf("specific status does not exist for entities: %s, expected one of: %s", statusLabel, strings.Join(EntityStatuses, ", ")))
+			}
+		}
+	}
+
+	matchParameters.SourceSelector, err = scope.NewSelector(matchFileDef.SourceSelector)
+	if err != nil {
+		errors = append(errors, err)
+	} else if matchParameters.SourceSelector.HasEntityCriteria() && matchParameters.Type != "entities" {
+		errors = append(errors, fmt.Errorf("managementZones, tags and entityIds of the sourceSelector only apply to entities, use configNamePattern or settingsScopes to scope configs"))
+	}
+
 	var errList []error
-	matchParameters.Source, errList = getParameterEnv(context, matchFileDef.Source, SOURCE_ENV)
+	matchParameters.Source, errList = getParameterEnv(context, matchFileDef.Source, SOURCE_ENV, matchParameters.Type)
 
 	if errList != nil {
 		errors = append(errors, errList...)
 	}
 
-	matchParameters.Target, errList = getParameterEnv(context, matchFileDef.Target, TARGET_ENV)
+	matchParameters.Target, errList = getParameterEnv(context, matchFileDef.Target, TARGET_ENV, matchParameters.Type)
 
 	if errList != nil {
 		errors = append(errors, errList...)
@@ -284,6 +399,7 @@ func LoadMatchingParameters(fs afero.Fs, matchFileName string) (matchParameters
 	matchParameters.SelfMatch = matchFileDef.SelfMatch
 
 	if matchFileDef.Source.ManifestPath == matchFileDef.Target.ManifestPath &&
+		matchFileDef.Source.SnapshotPath == matchFileDef.Target.SnapshotPath &&
 		matchFileDef.Source.Environment == matchFileDef.Target.Environment &&
 		matchFileDef.Source.Project == matchFileDef.Target.Project {
 
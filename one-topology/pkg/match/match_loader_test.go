@@ -28,6 +28,7 @@ import (
 	"gotest.tools/assert"
 
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/manifest"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/snapshot"
 )
 
 const workingDirPath = `/home/test/monaco/match`
@@ -248,3 +249,129 @@ func TestLoadMatchingParameters(t *testing.T) {
 		t.Errorf("LoadMatchingParameters() got = %v, want %v", got, want)
 	}
 }
+
+const rawSnapshotMatchYAMLContent = `name: %s
+type: %s
+outputPath: %s
+sourceInfo:
+  snapshotPath: %s
+targetInfo:
+  snapshotPath: %s
+`
+
+const rawSnapshotManifestYAMLContent = `
+manifestVersion: "1.0"
+projects:
+- name: configs
+- name: entities
+environmentGroups:
+- name: %s
+  environments:
+  - name: %s
+    url:
+      value: %s
+    auth:
+      token:
+        type: %s
+        name: %s
+`
+
+func TestLoadMatchingParametersFromSnapshot(t *testing.T) {
+
+	t.Setenv(tokenName, tokenValue)
+
+	snapshotPath := filepath.Join(workingDir, "snapshots", "tenant.tar.gz")
+	extractionDir := filepath.Join(workingDir, "snapshots", "tenant.tar.gz.extracted")
+	stagingDir := filepath.Join(workingDir, "staging")
+
+	matchFileContent := fmt.Sprintf(rawSnapshotMatchYAMLContent,
+		name, matchType, outputPath, snapshotPath, snapshotPath)
+
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, matchFilePath, []byte(matchFileContent), 0666)
+	assert.NilError(t, err)
+
+	snapshotManifestFileContent := fmt.Sprintf(rawSnapshotManifestYAMLContent,
+		groupName, projectEnvName, tenantUrl, tokenType, tokenName)
+	err = afero.WriteFile(fs, filepath.Join(stagingDir, snapshot.ManifestFileName), []byte(snapshotManifestFileContent), 0666)
+	assert.NilError(t, err)
+
+	_, err = snapshot.Create(fs, stagingDir, snapshotPath, snapshot.Metadata{
+		EnvironmentUrl: tenantUrl,
+		Environment:    projectEnvName,
+	})
+	assert.NilError(t, err)
+
+	got, err := LoadMatchingParameters(fs, matchFilePath)
+	assert.NilError(t, err)
+
+	assert.Equal(t, got.SelfMatch, true)
+	for _, env := range []MatchParametersEnv{got.Source, got.Target} {
+		assert.Equal(t, env.WorkingDir, extractionDir)
+		assert.Equal(t, env.Project, snapshot.EntitiesProject)
+		assert.Equal(t, env.Environment, projectEnvName)
+		assert.Equal(t, env.ProjectFolder(), filepath.Join(extractionDir, snapshot.EntitiesProject))
+		assert.Equal(t, env.Manifest.Environments[projectEnvName].URL.Value, tenantUrl)
+	}
+}
+
+func TestLoadMatchingParametersRejectsManifestAndSnapshot(t *testing.T) {
+
+	matchFileContent := fmt.Sprintf(rawMatchYAMLContent+"  snapshotPath: snapshot.tar.gz\n",
+		name, matchType, outputPath,
+		sourceManifestPath, projectEnvName, projectEnvName,
+		targetManifestPath, projectEnvName, projectEnvName)
+
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, matchFilePath, []byte(matchFileContent), 0666)
+	assert.NilError(t, err)
+
+	_, err = LoadMatchingParameters(fs, matchFilePath)
+	assert.ErrorContains(t, err, "Could not load Config Parameters")
+}
+
+func TestMatchParametersSkipType(t *testing.T) {
+	assert.Assert(t, !MatchParameters{}.SkipType("HOST"))
+
+	specificTypes := MatchParameters{SpecificTypes: []string{"HOST"}}
+	assert.Assert(t, !specificTypes.SkipType("HOST"))
+	assert.Assert(t, specificTypes.SkipType("PROCESS_GROUP"))
+
+	specificTypes.SkipSpecificTypes = true
+	assert.Assert(t, specificTypes.SkipType("HOST"))
+	assert.Assert(t, !specificTypes.SkipType("PROCESS_GROUP"))
+}
+
+func TestLoadMatchingParametersEntityCriteriaOnlyForEntities(t *testing.T) {
+
+	t.Setenv(tokenName, tokenValue)
+
+	manifestFileContent := fmt.Sprintf(rawManifestYAMLContent,
+		projectEnvName, groupName, projectEnvName, tenantUrl, tokenType, tokenName)
+
+	for _, matchType := range []string{"entities", "configs"} {
+		t.Run(matchType, func(t *testing.T) {
+			matchFileContent := fmt.Sprintf(rawMatchYAMLContent+"sourceSelector:\n  managementZones:\n  - zone\n",
+				name, matchType, outputPath,
+				sourceManifestPath, projectEnvName, projectEnvName,
+				targetManifestPath, projectEnvName, projectEnvName)
+
+			fs := afero.NewMemMapFs()
+			for path, content := range map[string]string{
+				matchFilePath:      matchFileContent,
+				sourceManifestPath: manifestFileContent,
+				targetManifestPath: manifestFileContent,
+			} {
+				err := afero.WriteFile(fs, path, []byte(content), 0666)
+				assert.NilError(t, err)
+			}
+
+			_, err := LoadMatchingParameters(fs, matchFilePath)
+			if matchType == "entities" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "Could not load Config Parameters")
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ type MatchProcessing struct {
 	Source     MatchProcessingEnv
 	Target     MatchProcessingEnv
 	matchedMap map[int]int
+	matchTiers map[int]string
 }
 
 type RawMatchList interface {
@@ -128,6 +129,19 @@ func (e *MatchProcessing) GetType() string {
 	return ""
 }
 
+// SetMatchTier records the rule tier that matched a source item
+func (e *MatchProcessing) SetMatchTier(sourceIdx int, tier string) {
+	if e.matchTiers == nil {
+		e.matchTiers = map[int]string{}
+	}
+	e.matchTiers[sourceIdx] = tier
+}
+
+// GetMatchTier returns the rule tier that matched a source item, empty if it was not matched by a rule
+func (e *MatchProcessing) GetMatchTier(sourceIdx int) string {
+	return e.matchTiers[sourceIdx]
+}
+
 func (e *MatchProcessing) AdjustremainingMatch(uniqueMatch *[]CompareResult) {
 
 	sort.Sort(ByLeft(*uniqueMatch))
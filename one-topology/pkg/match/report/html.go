@@ -0,0 +1,145 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/spf13/afero"
+)
+
+// the template holds its styles and scripts inline, so that the report works offline as a single file
+//
+//go:embed html_report.gohtml
+var htmlTemplateContent string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateContent))
+
+const StatusUnknown = "Unknown"
+
+// HtmlReport is the content of the static html report of a match run
+type HtmlReport struct {
+	Name              string
+	MatchType         string
+	SourceEnvironment string
+	TargetEnvironment string
+	GeneratedAt       string
+	EntityStats       []EntityStats
+	ConfigTypes       []HtmlConfigType
+	Entities          []HtmlEntity
+}
+
+// EntityStats holds the numbers of the entities stats table of a type.
+// The target total is only known when the entities were matched in the same run.
+type EntityStats struct {
+	Type         string
+	Matched      int
+	MultiMatched int
+	UnMatched    int
+	Target       int
+	HasTarget    bool
+	Source       int
+}
+
+type HtmlConfigType struct {
+	Type   string
+	Anchor string
+	Stats  []HtmlStat
+	Rows   []HtmlConfigRow
+}
+
+type HtmlStat struct {
+	Label string
+	Count int
+}
+
+// HtmlConfigRow is a report row, with the pretty printed payloads of Update rows and the source entities it references
+type HtmlConfigRow struct {
+	Row
+	Anchor     string
+	SourceJson string
+	TargetJson string
+	Entities   []HtmlLink
+}
+
+// HtmlEntity is a source entity referenced by the configs, linked back to them
+type HtmlEntity struct {
+	EntityId string
+	Anchor   string
+	Type     string
+	Status   string
+	TargetId string
+	Configs  []HtmlLink
+}
+
+type HtmlLink struct {
+	Label  string
+	Anchor string
+}
+
+func GenConfigAnchor(configType string, idx int) string {
+	return fmt.Sprintf("config-%s-%d", configType, idx)
+}
+
+func GenEntityAnchor(entityId string) string {
+	return fmt.Sprintf("entity-%s", entityId)
+}
+
+// PrettyJson indents a JSON payload, it is returned as is if it is not valid JSON
+func PrettyJson(value string) string {
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(value), "", "  ") != nil {
+		return value
+	}
+	return indented.String()
+}
+
+// SortEntityStats sorts the stats by type, like the stats tables of the logs
+func SortEntityStats(entityStats []EntityStats) {
+	sort.Slice(entityStats, func(i, j int) bool {
+		return entityStats[i].Type < entityStats[j].Type
+	})
+}
+
+// WriteHtml writes the report as a single static html file
+func WriteHtml(fs afero.Fs, htmlFile string, htmlReport HtmlReport) error {
+
+	var content bytes.Buffer
+	err := htmlTemplate.Execute(&content, htmlReport)
+	if err != nil {
+		return fmt.Errorf("failed to generate the html report, see error: %w", err)
+	}
+
+	err = fs.MkdirAll(filepath.Dir(htmlFile), 0777)
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, htmlFile, content.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the html report %s, see error: %w", htmlFile, err)
+	}
+
+	log.Info("Wrote html report to '%s'", htmlFile)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWriteHtml(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	htmlReport := HtmlReport{
+		Name:        "test",
+		EntityStats: []EntityStats{{Type: "HOST", Matched: 1, Source: 1}},
+		ConfigTypes: []HtmlConfigType{{
+			Type:   "alerting-profile",
+			Anchor: "type-alerting-profile",
+			Stats:  []HtmlStat{{Label: "Update", Count: 1}},
+			Rows: []HtmlConfigRow{{
+				Row:        Row{SourceId: "s1", SourceName: "<script>", TargetId: "t1", Status: "Update"},
+				Anchor:     GenConfigAnchor("alerting-profile", 0),
+				SourceJson: PrettyJson(`{"a":1}`),
+				TargetJson: PrettyJson(`{"a":2}`),
+				Entities:   []HtmlLink{{Label: "HOST-1", Anchor: GenEntityAnchor("HOST-1")}},
+			}},
+		}},
+		Entities: []HtmlEntity{{EntityId: "HOST-1", Anchor: GenEntityAnchor("HOST-1"), Status: StatusMatched}},
+	}
+
+	err := WriteHtml(fs, "output/match.html", htmlReport)
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, "output/match.html")
+	assert.NilError(t, err)
+	html := string(content)
+
+	assert.Assert(t, strings.Contains(html, `<tr id="config-alerting-profile-0" data-status="Update">`))
+	assert.Assert(t, strings.Contains(html, `<a href="#entity-HOST-1">HOST-1</a>`))
+	assert.Assert(t, strings.Contains(html, `<tr id="entity-HOST-1" data-status="Matched">`))
+	assert.Assert(t, strings.Contains(html, "&lt;script&gt;"))
+	assert.Assert(t, !strings.Contains(html, "<td><script></td>"))
+}
+
+func TestPrettyJson(t *testing.T) {
+	assert.Equal(t, PrettyJson(`{"a":[1]}`), "{\n  \"a\": [\n    1\n  ]\n}")
+	assert.Equal(t, PrettyJson(`not json`), "not json")
+}
@@ -0,0 +1,300 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the match results as spreadsheets, with one sheet per entity type or config schema.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/spf13/afero"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCsv  = "csv"
+	FormatXlsx = "xlsx"
+)
+
+var Formats = []string{FormatCsv, FormatXlsx}
+
+const reportDir = "report"
+const xlsxFileName = "report.xlsx"
+
+// excel limits the length of sheet names and forbids some characters in them
+const maxSheetNameLength = 31
+
+var invalidSheetNameChars = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_")
+
+const (
+	StatusMulti     = "Multi"
+	StatusMatched   = "Matched"
+	StatusUnMatched = "UnMatched"
+)
+
+var header = []string{"Source ID", "Source Name", "Target ID", "Target Name", "Status", "Matching Rule Tier"}
+var headerConfigs = append(append([]string{}, header...), "Diff Summary")
+
+// Row is a single source or target item of a sheet
+type Row struct {
+	SourceId    string
+	SourceName  string
+	TargetId    string
+	TargetName  string
+	Status      string
+	MatchTier   string
+	DiffSummary string
+}
+
+// Sheet holds the rows of an entity type or config schema
+type Sheet struct {
+	Name     string
+	IsConfig bool
+	Rows     []Row
+}
+
+func IsValidFormat(format string) bool {
+	for _, validFormat := range Formats {
+		if format == validFormat {
+			return true
+		}
+	}
+	return false
+}
+
+func (sheet Sheet) header() []string {
+	if sheet.IsConfig {
+		return headerConfigs
+	}
+	return header
+}
+
+func (sheet Sheet) records() [][]string {
+	records := make([][]string, 0, len(sheet.Rows)+1)
+	records = append(records, sheet.header())
+
+	for _, row := range sheet.Rows {
+		record := []string{row.SourceId, row.SourceName, row.TargetId, row.TargetName, row.Status, row.MatchTier}
+		if sheet.IsConfig {
+			record = append(record, row.DiffSummary)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// Write writes the sheets into the report folder of outputDir, as one csv file per sheet or as a single xlsx workbook
+func Write(fs afero.Fs, outputDir string, format string, sheets []Sheet) error {
+
+	sort.Slice(sheets, func(i, j int) bool {
+		return sheets[i].Name < sheets[j].Name
+	})
+
+	fullReportDir := path.Join(outputDir, reportDir)
+
+	err := fs.MkdirAll(fullReportDir, 0777)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatCsv:
+		err = writeCsv(fs, fullReportDir, sheets)
+	case FormatXlsx:
+		err = writeXlsx(fs, fullReportDir, sheets)
+	default:
+		err = fmt.Errorf("unsupported report format %s, supported formats are: %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info("Wrote %s report with %d sheets to '%s'", format, len(sheets), fullReportDir)
+
+	return nil
+}
+
+func writeCsv(fs afero.Fs, fullReportDir string, sheets []Sheet) error {
+	for _, sheet := range sheets {
+		fullCsvPath := filepath.Join(fullReportDir, fmt.Sprintf("%s.csv", config.Sanitize(sheet.Name)))
+
+		file, err := fs.Create(fullCsvPath)
+		if err != nil {
+			return err
+		}
+
+		writer := csv.NewWriter(file)
+		err = writer.WriteAll(sheet.records())
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write report %s, see error: %w", fullCsvPath, err)
+		}
+	}
+
+	return nil
+}
+
+func writeXlsx(fs afero.Fs, fullReportDir string, sheets []Sheet) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	defaultSheetName := workbook.GetSheetName(0)
+	sheetNames := genSheetNames(sheets)
+
+	for idx, sheet := range sheets {
+		_, err := workbook.NewSheet(sheetNames[idx])
+		if err != nil {
+			return err
+		}
+
+		streamWriter, err := workbook.NewStreamWriter(sheetNames[idx])
+		if err != nil {
+			return err
+		}
+
+		for rowIdx, record := range sheet.records() {
+			cell, err := excelize.CoordinatesToCellName(1, rowIdx+1)
+			if err != nil {
+				return err
+			}
+
+			values := make([]interface{}, len(record))
+			for valueIdx, value := range record {
+				values[valueIdx] = value
+			}
+
+			err = streamWriter.SetRow(cell, values)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = streamWriter.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(sheets) > 0 {
+		err := workbook.DeleteSheet(defaultSheetName)
+		if err != nil {
+			return err
+		}
+	}
+
+	fullXlsxPath := filepath.Join(fullReportDir, xlsxFileName)
+
+	file, err := fs.Create(fullXlsxPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = workbook.WriteTo(file)
+	if err != nil {
+		return fmt.Errorf("failed to write report %s, see error: %w", fullXlsxPath, err)
+	}
+
+	return nil
+}
+
+// genSheetNames shortens the sheet names to the excel limit, keeping them unique
+func genSheetNames(sheets []Sheet) []string {
+	sheetNames := make([]string, len(sheets))
+	usedNames := map[string]bool{}
+
+	for idx, sheet := range sheets {
+		baseName := invalidSheetNameChars.Replace(sheet.Name)
+		if baseName == "" {
+			baseName = "sheet"
+		}
+
+		sheetName := truncate(baseName, maxSheetNameLength)
+		for i := 2; usedNames[strings.ToLower(sheetName)]; i++ {
+			suffix := fmt.Sprintf("~%d", i)
+			sheetName = truncate(baseName, maxSheetNameLength-len(suffix)) + suffix
+		}
+
+		usedNames[strings.ToLower(sheetName)] = true
+		sheetNames[idx] = sheetName
+	}
+
+	return sheetNames
+}
+
+// truncate keeps the first maxLength characters of value, excel counts the length of sheet names in characters
+func truncate(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength])
+}
+
+// DiffSummary lists the top level properties that differ between two JSON payloads
+func DiffSummary(sourceJson string, targetJson string) string {
+	var source map[string]interface{}
+	var target map[string]interface{}
+
+	if json.Unmarshal([]byte(sourceJson), &source) != nil || json.Unmarshal([]byte(targetJson), &target) != nil {
+		return ""
+	}
+
+	changed := []string{}
+	sourceOnly := []string{}
+	targetOnly := []string{}
+
+	for key, sourceValue := range source {
+		targetValue, found := target[key]
+		if !found {
+			sourceOnly = append(sourceOnly, key)
+		} else if !reflect.DeepEqual(sourceValue, targetValue) {
+			changed = append(changed, key)
+		}
+	}
+
+	for key := range target {
+		if _, found := source[key]; !found {
+			targetOnly = append(targetOnly, key)
+		}
+	}
+
+	parts := []string{}
+	for _, part := range []struct {
+		label string
+		keys  []string
+	}{
+		{"changed", changed},
+		{"only in source", sourceOnly},
+		{"only in target", targetOnly},
+	} {
+		if len(part.keys) == 0 {
+			continue
+		}
+		sort.Strings(part.keys)
+		parts = append(parts, fmt.Sprintf("%s: %s", part.label, strings.Join(part.keys, ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,93 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package report
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+	"github.com/xuri/excelize/v2"
+	"gotest.tools/assert"
+)
+
+var testSheets = []Sheet{
+	{Name: "HOST", Rows: []Row{{SourceId: "HOST-1", SourceName: "host", TargetId: "HOST-2", TargetName: "host", Status: StatusMatched, MatchTier: "1: Name"}}},
+	{Name: "builtin:alerting.profile", IsConfig: true, Rows: []Row{{SourceId: "s1", SourceName: "profile", Status: "Add"}}},
+}
+
+func TestWriteCsv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := Write(fs, "output", FormatCsv, testSheets)
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, filepath.Join("output", reportDir, "HOST.csv"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "Source ID,Source Name,Target ID,Target Name,Status,Matching Rule Tier\nHOST-1,host,HOST-2,host,Matched,1: Name\n")
+
+	content, err = afero.ReadFile(fs, filepath.Join("output", reportDir, "builtinalerting.profile.csv"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "Source ID,Source Name,Target ID,Target Name,Status,Matching Rule Tier,Diff Summary\ns1,profile,,,Add,,\n")
+}
+
+func TestWriteXlsx(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := Write(fs, "output", FormatXlsx, testSheets)
+	assert.NilError(t, err)
+
+	file, err := fs.Open(filepath.Join("output", reportDir, xlsxFileName))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	workbook, err := excelize.OpenReader(file)
+	assert.NilError(t, err)
+	defer workbook.Close()
+
+	assert.DeepEqual(t, workbook.GetSheetList(), []string{"HOST", "builtin_alerting.profile"})
+
+	rows, err := workbook.GetRows("HOST")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rows, [][]string{header, {"HOST-1", "host", "HOST-2", "host", StatusMatched, "1: Name"}})
+}
+
+func TestGenSheetNames(t *testing.T) {
+	longName := strings.Repeat("a", 40)
+
+	sheetNames := genSheetNames([]Sheet{{Name: longName}, {Name: longName + "b"}, {Name: "x/y"}, {Name: ""}})
+
+	assert.DeepEqual(t, sheetNames, []string{strings.Repeat("a", 31), strings.Repeat("a", 29) + "~2", "x_y", "sheet"})
+}
+
+func TestGenSheetNamesKeepsCharactersWhole(t *testing.T) {
+	longName := strings.Repeat("é", 40)
+	sheetNames := genSheetNames([]Sheet{{Name: longName}, {Name: longName + "b"}})
+
+	assert.DeepEqual(t, sheetNames, []string{strings.Repeat("é", 31), strings.Repeat("é", 29) + "~2"})
+	for _, sheetName := range sheetNames {
+		assert.Assert(t, utf8.ValidString(sheetName))
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	assert.Equal(t, DiffSummary(`{"a":1,"b":{"c":1},"d":1}`, `{"a":1,"b":{"c":2},"e":1}`), "changed: b; only in source: d; only in target: e")
+	assert.Equal(t, DiffSummary(`{"a":1}`, `{"a":1}`), "")
+	assert.Equal(t, DiffSummary(`not json`, `{}`), "")
+}
@@ -0,0 +1,254 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scope restricts a match to a slice of the source environment.
+//
+// Every criterion of a selector that is set must match, a list criterion matches when any of its values matches.
+// Management zones, tags and entity ids select entities, the config name pattern and settings scopes select configs.
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+)
+
+// Definition is the sourceSelector of the match file
+type Definition struct {
+	ManagementZones   []string `yaml:"managementZones,omitempty"`
+	Tags              []string `yaml:"tags,omitempty"`
+	EntityIds         []string `yaml:"entityIds,omitempty"`
+	ConfigNamePattern string   `yaml:"configNamePattern,omitempty"`
+	SettingsScopes    []string `yaml:"settingsScopes,omitempty"`
+}
+
+type Selector struct {
+	managementZones   map[string]bool
+	tags              []tagExpression
+	entityIds         map[string]bool
+	configNamePattern *regexp.Regexp
+	settingsScopes    []string
+}
+
+// tagExpression is a tag as written in Dynatrace: [context]key:value, the context and the value are optional
+type tagExpression struct {
+	context  string
+	key      string
+	value    string
+	hasValue bool
+}
+
+// Entity holds the fields of a downloaded entity used by the selector.
+// Tags and management zones are nil when they were not downloaded.
+type Entity struct {
+	EntityId        string            `json:"entityId"`
+	Tags            *[]Tag            `json:"tags"`
+	ManagementZones *[]ManagementZone `json:"managementZones"`
+}
+
+type Tag struct {
+	Context string  `json:"context"`
+	Key     string  `json:"key"`
+	Value   *string `json:"value"`
+}
+
+type ManagementZone struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewSelector returns nil when no criterion is set, so that everything is in scope
+func NewSelector(definition Definition) (*Selector, error) {
+
+	if len(definition.ManagementZones) == 0 && len(definition.Tags) == 0 && len(definition.EntityIds) == 0 &&
+		definition.ConfigNamePattern == "" && len(definition.SettingsScopes) == 0 {
+		return nil, nil
+	}
+
+	selector := &Selector{
+		settingsScopes: definition.SettingsScopes,
+	}
+
+	if len(definition.ManagementZones) > 0 {
+		selector.managementZones = toSet(definition.ManagementZones)
+	}
+
+	if len(definition.EntityIds) > 0 {
+		selector.entityIds = toSet(definition.EntityIds)
+	}
+
+	for _, tag := range definition.Tags {
+		expression, err := parseTagExpression(tag)
+		if err != nil {
+			return nil, err
+		}
+		selector.tags = append(selector.tags, expression)
+	}
+
+	if definition.ConfigNamePattern != "" {
+		pattern, err := regexp.Compile(definition.ConfigNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configNamePattern %s in sourceSelector: %w", definition.ConfigNamePattern, err)
+		}
+		selector.configNamePattern = pattern
+	}
+
+	return selector, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func parseTagExpression(tag string) (tagExpression, error) {
+	expression := tagExpression{}
+	rest := strings.TrimSpace(tag)
+
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return tagExpression{}, fmt.Errorf("invalid tag %s in sourceSelector: the context is not closed", tag)
+		}
+		expression.context = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	expression.key, expression.value, expression.hasValue = strings.Cut(rest, ":")
+	if expression.key == "" {
+		return tagExpression{}, fmt.Errorf("invalid tag %s in sourceSelector: the key is empty", tag)
+	}
+
+	return expression, nil
+}
+
+func (me tagExpression) matches(tag Tag) bool {
+	if me.key != tag.Key {
+		return false
+	}
+	if me.context != "" && me.context != tag.Context {
+		return false
+	}
+	if me.hasValue && (tag.Value == nil || *tag.Value != me.value) {
+		return false
+	}
+	return true
+}
+
+// HasEntityCriteria is true when the selector restricts the source entities
+func (me *Selector) HasEntityCriteria() bool {
+	return me != nil && (me.managementZones != nil || me.tags != nil || me.entityIds != nil)
+}
+
+// NeedsEntityDetails is true when the selector needs the tags or management zones of the entities
+func (me *Selector) NeedsEntityDetails() bool {
+	return me != nil && (me.managementZones != nil || me.tags != nil)
+}
+
+// HasConfigCriteria is true when the selector restricts the source configs
+func (me *Selector) HasConfigCriteria() bool {
+	return me != nil && (me.configNamePattern != nil || len(me.settingsScopes) > 0)
+}
+
+func (me *Selector) IsEntityInScope(entity Entity) bool {
+	if !me.HasEntityCriteria() {
+		return true
+	}
+
+	if me.entityIds != nil && !me.entityIds[entity.EntityId] {
+		return false
+	}
+
+	if me.managementZones != nil && !me.isInManagementZones(entity) {
+		return false
+	}
+
+	if me.tags != nil && !me.hasTag(entity) {
+		return false
+	}
+
+	return true
+}
+
+func (me *Selector) isInManagementZones(entity Entity) bool {
+	if entity.ManagementZones == nil {
+		return false
+	}
+	for _, managementZone := range *entity.ManagementZones {
+		if me.managementZones[managementZone.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (me *Selector) hasTag(entity Entity) bool {
+	if entity.Tags == nil {
+		return false
+	}
+	for _, tag := range *entity.Tags {
+		for _, expression := range me.tags {
+			if expression.matches(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsConfigInScope checks the name of a config, and the scope of settings, configs without a name are out of scope of a name pattern.
+// Settings scopes are selected like for the download: exactly, or by scope type prefix when ending with '-', e.g. HOST-
+func (me *Selector) IsConfigInScope(name string, hasName bool, settingsScope string, hasSettingsScope bool) bool {
+	if !me.HasConfigCriteria() {
+		return true
+	}
+
+	if me.configNamePattern != nil && (!hasName || !me.configNamePattern.MatchString(name)) {
+		return false
+	}
+
+	if hasSettingsScope && !client.ScopeMatches(settingsScope, me.settingsScopes) {
+		return false
+	}
+
+	return true
+}
+
+// UnmarshalEntities reads the fields used by the selector from the downloaded entities of a type
+func UnmarshalEntities(entityConfigs []config.Config) ([]Entity, error) {
+	entities := []Entity{}
+
+	if len(entityConfigs) == 0 {
+		return entities, nil
+	}
+
+	templateBytes, err := entityConfigs[0].LoadTemplateBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(templateBytes, &entities)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
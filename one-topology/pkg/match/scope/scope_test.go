@@ -0,0 +1,162 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package scope
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func strPtr(value string) *string {
+	return &value
+}
+
+func TestNewSelector(t *testing.T) {
+	selector, err := NewSelector(Definition{})
+	assert.NilError(t, err)
+	assert.Assert(t, selector == nil)
+	assert.Assert(t, !selector.HasEntityCriteria())
+	assert.Assert(t, !selector.HasConfigCriteria())
+	assert.Assert(t, selector.IsEntityInScope(Entity{EntityId: "HOST-1"}))
+	assert.Assert(t, selector.IsConfigInScope("name", true, "", false))
+
+	_, err = NewSelector(Definition{ConfigNamePattern: "("})
+	assert.ErrorContains(t, err, "invalid configNamePattern")
+
+	_, err = NewSelector(Definition{Tags: []string{"[AWS"}})
+	assert.ErrorContains(t, err, "the context is not closed")
+
+	_, err = NewSelector(Definition{Tags: []string{":value"}})
+	assert.ErrorContains(t, err, "the key is empty")
+}
+
+func TestParseTagExpression(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected tagExpression
+	}{
+		{"team", tagExpression{key: "team"}},
+		{"team:ops", tagExpression{key: "team", value: "ops", hasValue: true}},
+		{"[AWS]team:ops", tagExpression{context: "AWS", key: "team", value: "ops", hasValue: true}},
+		{"team:", tagExpression{key: "team", value: "", hasValue: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			expression, err := parseTagExpression(tt.tag)
+			assert.NilError(t, err)
+			assert.Equal(t, expression, tt.expected)
+		})
+	}
+}
+
+func TestIsEntityInScope(t *testing.T) {
+	selector, err := NewSelector(Definition{
+		ManagementZones: []string{"prod", "staging"},
+		Tags:            []string{"[AWS]team:ops", "owner"},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, selector.HasEntityCriteria())
+	assert.Assert(t, selector.NeedsEntityDetails())
+	assert.Assert(t, !selector.HasConfigCriteria())
+
+	tests := []struct {
+		name     string
+		entity   Entity
+		expected bool
+	}{
+		{
+			"management zone and tag with context",
+			Entity{
+				EntityId:        "HOST-1",
+				ManagementZones: &[]ManagementZone{{Id: "1", Name: "prod"}},
+				Tags:            &[]Tag{{Context: "AWS", Key: "team", Value: strPtr("ops")}},
+			},
+			true,
+		},
+		{
+			"tag without value",
+			Entity{
+				EntityId:        "HOST-2",
+				ManagementZones: &[]ManagementZone{{Id: "2", Name: "staging"}},
+				Tags:            &[]Tag{{Context: "CONTEXTLESS", Key: "owner"}},
+			},
+			true,
+		},
+		{
+			"wrong tag context",
+			Entity{
+				EntityId:        "HOST-3",
+				ManagementZones: &[]ManagementZone{{Id: "1", Name: "prod"}},
+				Tags:            &[]Tag{{Context: "CONTEXTLESS", Key: "team", Value: strPtr("ops")}},
+			},
+			false,
+		},
+		{
+			"other management zone",
+			Entity{
+				EntityId:        "HOST-4",
+				ManagementZones: &[]ManagementZone{{Id: "3", Name: "dev"}},
+				Tags:            &[]Tag{{Context: "CONTEXTLESS", Key: "owner"}},
+			},
+			false,
+		},
+		{
+			"details not downloaded",
+			Entity{EntityId: "HOST-5"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, selector.IsEntityInScope(tt.entity), tt.expected)
+		})
+	}
+}
+
+func TestIsEntityInScopeByIds(t *testing.T) {
+	selector, err := NewSelector(Definition{EntityIds: []string{"HOST-1"}})
+	assert.NilError(t, err)
+	assert.Assert(t, !selector.NeedsEntityDetails())
+
+	assert.Assert(t, selector.IsEntityInScope(Entity{EntityId: "HOST-1"}))
+	assert.Assert(t, !selector.IsEntityInScope(Entity{EntityId: "HOST-2"}))
+}
+
+func TestIsConfigInScope(t *testing.T) {
+	selector, err := NewSelector(Definition{
+		ConfigNamePattern: "^prod-",
+		SettingsScopes:    []string{"HOST-", "environment"},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, selector.HasConfigCriteria())
+	assert.Assert(t, !selector.HasEntityCriteria())
+
+	assert.Assert(t, selector.IsConfigInScope("prod-alerting", true, "", false))
+	assert.Assert(t, selector.IsConfigInScope("prod-alerting", true, "HOST-1234", true))
+	assert.Assert(t, selector.IsConfigInScope("prod-alerting", true, "environment", true))
+	assert.Assert(t, !selector.IsConfigInScope("prod-alerting", true, "PROCESS_GROUP-1234", true))
+	assert.Assert(t, !selector.IsConfigInScope("dev-alerting", true, "HOST-1234", true))
+	assert.Assert(t, !selector.IsConfigInScope("", false, "HOST-1234", true))
+
+	selector, err = NewSelector(Definition{SettingsScopes: []string{"HOST-1"}})
+	assert.NilError(t, err)
+	assert.Assert(t, selector.IsConfigInScope("", false, "HOST-1", true))
+	assert.Assert(t, !selector.IsConfigInScope("", false, "HOST-12", true))
+}
@@ -0,0 +1,75 @@
+-- Schema of the SQLite store written by `monaco match --sqlite <file>`.
+--
+-- A store holds the results of a single match run, it is recreated on every run.
+-- All ids are the raw Dynatrace ids: entity ids, classic config ids or settings object ids.
+-- kind is either 'entity' or 'config', match_type is the entity type, classic api or settings schema id.
+-- environment is either 'source' or 'target'.
+
+-- run describes the match run that wrote the store, it holds a single row
+CREATE TABLE run (
+    name               TEXT NOT NULL, -- name of the match file
+    match_type         TEXT NOT NULL, -- 'entities' or 'configs'
+    source_environment TEXT NOT NULL,
+    target_environment TEXT NOT NULL,
+    created_at         TEXT NOT NULL  -- RFC 3339 timestamp
+);
+
+-- entities holds the entities of both environments, written by entities runs
+CREATE TABLE entities (
+    environment    TEXT NOT NULL,
+    entity_type    TEXT NOT NULL,
+    entity_id      TEXT NOT NULL,
+    display_name   TEXT,
+    first_seen_tms INTEGER,
+    PRIMARY KEY (environment, entity_id)
+);
+
+-- configs holds the configs of both environments that are part of the match payload, written by configs runs
+CREATE TABLE configs (
+    environment TEXT NOT NULL,
+    config_type TEXT NOT NULL,
+    config_id   TEXT NOT NULL,
+    name        TEXT NOT NULL, -- key of the config in the match payload: its name, followed by its scope for settings
+    value       TEXT NOT NULL, -- JSON payload, as downloaded
+    PRIMARY KEY (environment, config_type, config_id)
+);
+
+-- matches holds the unique source to target matches.
+-- Configs runs also hold the entity matches they used to replace the entity ids.
+CREATE TABLE matches (
+    kind       TEXT NOT NULL,
+    match_type TEXT NOT NULL,
+    source_id  TEXT NOT NULL,
+    target_id  TEXT NOT NULL,
+    PRIMARY KEY (kind, match_type, source_id)
+);
+
+-- multi_match_candidates holds every target candidate of the source ids that matched more than one target
+CREATE TABLE multi_match_candidates (
+    kind       TEXT NOT NULL,
+    match_type TEXT NOT NULL,
+    source_id  TEXT NOT NULL,
+    target_id  TEXT NOT NULL,
+    PRIMARY KEY (kind, match_type, source_id, target_id)
+);
+
+-- statuses holds the outcome of the match for every source id, and for configs, every target id to delete
+CREATE TABLE statuses (
+    kind          TEXT NOT NULL,
+    match_type    TEXT NOT NULL,
+    source_id     TEXT,                       -- NULL for configs to Delete
+    target_id     TEXT,                       -- NULL when not matched, one row per candidate for multi matched configs
+    status        TEXT NOT NULL,              -- Add, Update, Delete, Identical or Incompatible for configs, Matched or UnMatched for entities
+    multi_matched INTEGER NOT NULL DEFAULT 0  -- 1 when the source id matched more than one target
+);
+
+CREATE INDEX statuses_source_idx ON statuses (kind, match_type, source_id);
+CREATE INDEX statuses_target_idx ON statuses (kind, match_type, target_id);
+
+-- unresolved_references holds the entity ids referenced by configs to Add or Update that do not exist in the target
+CREATE TABLE unresolved_references (
+    config_type TEXT NOT NULL,
+    config_id   TEXT NOT NULL, -- source config id
+    entity_id   TEXT NOT NULL,
+    PRIMARY KEY (config_type, config_id, entity_id)
+);
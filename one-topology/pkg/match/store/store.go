@@ -0,0 +1,316 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package store writes the results of a match run into a single embedded SQLite file.
+// The tables are documented in schema.sql.
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/match"
+	"github.com/spf13/afero"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed schema.sql
+var Schema string
+
+const (
+	KindEntity = "entity"
+	KindConfig = "config"
+
+	EnvSource = "source"
+	EnvTarget = "target"
+)
+
+const (
+	insertRun                 = "INSERT INTO run (name, match_type, source_environment, target_environment, created_at) VALUES (?, ?, ?, ?, ?)"
+	insertEntity              = "INSERT OR REPLACE INTO entities (environment, entity_type, entity_id, display_name, first_seen_tms) VALUES (?, ?, ?, ?, ?)"
+	insertConfig              = "INSERT OR IGNORE INTO configs (environment, config_type, config_id, name, value) VALUES (?, ?, ?, ?, ?)"
+	insertMatch               = "INSERT OR REPLACE INTO matches (kind, match_type, source_id, target_id) VALUES (?, ?, ?, ?)"
+	insertMultiMatchCandidate = "INSERT OR IGNORE INTO multi_match_candidates (kind, match_type, source_id, target_id) VALUES (?, ?, ?, ?)"
+	insertStatus              = "INSERT INTO statuses (kind, match_type, source_id, target_id, status, multi_matched) VALUES (?, ?, ?, ?, ?, ?)"
+	insertUnresolvedReference = "INSERT OR IGNORE INTO unresolved_references (config_type, config_id, entity_id) VALUES (?, ?, ?)"
+)
+
+type Run struct {
+	Name              string
+	MatchType         string
+	SourceEnvironment string
+	TargetEnvironment string
+	CreatedAt         string
+}
+
+type Entity struct {
+	Environment  string
+	EntityType   string
+	EntityId     string
+	DisplayName  *string
+	FirstSeenTms *float64
+}
+
+type Config struct {
+	Environment string
+	ConfigType  string
+	ConfigId    string
+	Name        string
+	Value       string
+}
+
+// Match is used for both the unique matches and the multi match candidates
+type Match struct {
+	Kind      string
+	MatchType string
+	SourceId  string
+	TargetId  string
+}
+
+type Status struct {
+	Kind         string
+	MatchType    string
+	SourceId     string
+	TargetId     string
+	Status       string
+	MultiMatched bool
+}
+
+type UnresolvedReference struct {
+	ConfigType string
+	ConfigId   string
+	EntityId   string
+}
+
+// Store writes all rows in a single transaction, they are only visible once committed
+type Store struct {
+	mu         sync.Mutex
+	db         *sql.DB
+	tx         *sql.Tx
+	statements map[string]*sql.Stmt
+}
+
+// sqliteHeader starts every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// Create replaces the store at path with an empty store. Any other file at path is left untouched and an error is returned.
+// The folder and the previous store are handled through fs, but the SQLite driver opens path by itself,
+// so fs has to resolve paths like the OS file system does, e.g. afero.NewOsFs()
+func Create(fs afero.Fs, path string) (*Store, error) {
+
+	err := fs.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	err = removeStore(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(Schema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create the schema of the store %s: %w", path, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db, tx: tx, statements: map[string]*sql.Stmt{}}, nil
+}
+
+// removeStore removes the previous store at path, if any, and refuses to remove anything else than a SQLite file
+func removeStore(fs afero.Fs, path string) error {
+	info, err := fs.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("could not replace the store %s, it is a folder", path)
+	}
+
+	isStore, err := isSqliteFile(fs, path)
+	if err != nil {
+		return fmt.Errorf("could not replace the store %s: %w", path, err)
+	}
+	if !isStore && info.Size() > 0 {
+		return fmt.Errorf("could not replace the store %s, it is not a SQLite file", path)
+	}
+
+	err = fs.Remove(path)
+	if err != nil {
+		return fmt.Errorf("could not replace the store %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func isSqliteFile(fs afero.Fs, path string) (bool, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(file, header)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(header, sqliteHeader), nil
+}
+
+// CreateForMatch replaces the store of the match run and writes its run row
+func CreateForMatch(fs afero.Fs, matchParameters match.MatchParameters) (*Store, error) {
+
+	matchStore, err := Create(fs, matchParameters.SqliteFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = matchStore.WriteRun(Run{
+		Name:              matchParameters.Name,
+		MatchType:         matchParameters.Type,
+		SourceEnvironment: matchParameters.Source.Environment,
+		TargetEnvironment: matchParameters.Target.Environment,
+		CreatedAt:         time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		matchStore.Close()
+		return nil, err
+	}
+
+	return matchStore, nil
+}
+
+// Commit makes the written rows visible and closes the store
+func (me *Store) Commit() error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.tx == nil {
+		return errors.New("the store is already closed")
+	}
+
+	err := me.tx.Commit()
+	me.tx = nil
+	if err != nil {
+		me.db.Close()
+		return err
+	}
+
+	return me.db.Close()
+}
+
+// Close drops the rows written since Create if the store was not committed
+func (me *Store) Close() error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.tx == nil {
+		return nil
+	}
+
+	me.tx.Rollback()
+	me.tx = nil
+
+	return me.db.Close()
+}
+
+func (me *Store) exec(query string, args ...any) error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.tx == nil {
+		return errors.New("the store is already closed")
+	}
+
+	statement, found := me.statements[query]
+	if !found {
+		var err error
+		statement, err = me.tx.Prepare(query)
+		if err != nil {
+			return err
+		}
+		me.statements[query] = statement
+	}
+
+	_, err := statement.Exec(args...)
+	return err
+}
+
+func (me *Store) WriteRun(run Run) error {
+	return me.exec(insertRun, run.Name, run.MatchType, run.SourceEnvironment, run.TargetEnvironment, run.CreatedAt)
+}
+
+func (me *Store) WriteEntity(entity Entity) error {
+	var firstSeenTms *int64
+	if entity.FirstSeenTms != nil {
+		value := int64(*entity.FirstSeenTms)
+		firstSeenTms = &value
+	}
+
+	return me.exec(insertEntity, entity.Environment, entity.EntityType, entity.EntityId, entity.DisplayName, firstSeenTms)
+}
+
+func (me *Store) WriteConfig(config Config) error {
+	return me.exec(insertConfig, config.Environment, config.ConfigType, config.ConfigId, config.Name, config.Value)
+}
+
+func (me *Store) WriteMatch(matchRow Match) error {
+	return me.exec(insertMatch, matchRow.Kind, matchRow.MatchType, matchRow.SourceId, matchRow.TargetId)
+}
+
+func (me *Store) WriteMultiMatchCandidate(matchRow Match) error {
+	return me.exec(insertMultiMatchCandidate, matchRow.Kind, matchRow.MatchType, matchRow.SourceId, matchRow.TargetId)
+}
+
+func (me *Store) WriteStatus(status Status) error {
+	return me.exec(insertStatus, status.Kind, status.MatchType, nullIfEmpty(status.SourceId), nullIfEmpty(status.TargetId), status.Status, status.MultiMatched)
+}
+
+func (me *Store) WriteUnresolvedReference(reference UnresolvedReference) error {
+	return me.exec(insertUnresolvedReference, reference.ConfigType, reference.ConfigId, reference.EntityId)
+}
+
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,107 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package store
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func countRows(t *testing.T, path string, query string) int {
+	db, err := sql.Open("sqlite", path)
+	assert.NilError(t, err)
+	defer db.Close()
+
+	count := 0
+	err = db.QueryRow(query).Scan(&count)
+	assert.NilError(t, err)
+
+	return count
+}
+
+func TestStoreCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results", "match.db")
+
+	matchStore, err := Create(afero.NewOsFs(), path)
+	assert.NilError(t, err)
+
+	name := "host-1"
+	firstSeen := 1690000000000.0
+	assert.NilError(t, matchStore.WriteEntity(Entity{Environment: EnvSource, EntityType: "HOST", EntityId: "HOST-0000000000000001", DisplayName: &name, FirstSeenTms: &firstSeen}))
+	assert.NilError(t, matchStore.WriteEntity(Entity{Environment: EnvTarget, EntityType: "HOST", EntityId: "HOST-0000000000000002"}))
+	assert.NilError(t, matchStore.WriteMatch(Match{Kind: KindEntity, MatchType: "HOST", SourceId: "HOST-0000000000000001", TargetId: "HOST-0000000000000002"}))
+	assert.NilError(t, matchStore.WriteStatus(Status{Kind: KindEntity, MatchType: "HOST", SourceId: "HOST-0000000000000001", TargetId: "HOST-0000000000000002", Status: "Matched"}))
+	assert.NilError(t, matchStore.WriteStatus(Status{Kind: KindConfig, MatchType: "alerting-profile", TargetId: "b", Status: "Delete"}))
+	assert.NilError(t, matchStore.Commit())
+
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM entities"), 2)
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM entities WHERE display_name IS NULL"), 1)
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM matches m JOIN entities e ON e.entity_id = m.target_id AND e.environment = 'target'"), 1)
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM statuses WHERE source_id IS NULL"), 1)
+	assert.Equal(t, countRows(t, path, "SELECT first_seen_tms FROM entities WHERE first_seen_tms IS NOT NULL"), 1690000000000)
+
+	// a new run replaces the store
+	matchStore, err = Create(afero.NewOsFs(), path)
+	assert.NilError(t, err)
+	assert.NilError(t, matchStore.Commit())
+
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM entities"), 0)
+}
+
+func TestStoreCloseWithoutCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "match.db")
+
+	matchStore, err := Create(afero.NewOsFs(), path)
+	assert.NilError(t, err)
+
+	assert.NilError(t, matchStore.WriteConfig(Config{Environment: EnvSource, ConfigType: "alerting-profile", ConfigId: "a", Name: "a", Value: "{}"}))
+	assert.NilError(t, matchStore.Close())
+
+	assert.Equal(t, countRows(t, path, "SELECT COUNT(*) FROM configs"), 0)
+	assert.ErrorContains(t, matchStore.WriteConfig(Config{}), "already closed")
+}
+
+func TestCreateRefusesToReplaceOtherFiles(t *testing.T) {
+	fs := afero.NewOsFs()
+	dir := t.TempDir()
+
+	notAStore := filepath.Join(dir, "notes.txt")
+	assert.NilError(t, afero.WriteFile(fs, notAStore, []byte("do not delete"), 0644))
+	_, err := Create(fs, notAStore)
+	assert.ErrorContains(t, err, "not a SQLite file")
+	content, err := afero.ReadFile(fs, notAStore)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "do not delete")
+
+	folder := filepath.Join(dir, "results")
+	assert.NilError(t, fs.MkdirAll(folder, 0777))
+	_, err = Create(fs, folder)
+	assert.ErrorContains(t, err, "it is a folder")
+
+	empty := filepath.Join(dir, "empty.db")
+	assert.NilError(t, afero.WriteFile(fs, empty, []byte{}, 0644))
+	matchStore, err := Create(fs, empty)
+	assert.NilError(t, err)
+	assert.NilError(t, matchStore.Commit())
+	_, err = os.Stat(empty)
+	assert.NilError(t, err)
+}
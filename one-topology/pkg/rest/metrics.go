@@ -0,0 +1,33 @@
+/*
+ * @license
+ * Copyright 2023 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import "sync/atomic"
+
+// apiCallCount counts every HTTP request sent, including the retries and the requests repeated after a rate limit
+var apiCallCount atomic.Int64
+
+// retryCount counts the requests repeated after a failure or a rate limit
+var retryCount atomic.Int64
+
+func ApiCallCount() int64 {
+	return apiCallCount.Load()
+}
+
+func RetryCount() int64 {
+	return retryCount.Load()
+}
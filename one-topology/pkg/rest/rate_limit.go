@@ -77,6 +77,7 @@ func (s *simpleSleepRateLimitStrategy) executeRequest(timelineProvider timeutils
 
 		// Checking again:
 		currentIteration++
+		retryCount.Add(1)
 
 		response, err = callback()
 		if err != nil {
@@ -128,6 +128,7 @@ func executeRequest(client *http.Client, request *http.Request) (Response, error
 	rateLimitStrategy := createRateLimitStrategy()
 
 	response, err := rateLimitStrategy.executeRequest(timeutils.NewTimelineProvider(), func() (Response, error) {
+		apiCallCount.Add(1)
 		resp, err := client.Do(request)
 		if err != nil {
 			log.Error("HTTP Request failed with Error: " + err.Error())
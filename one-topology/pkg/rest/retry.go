@@ -63,6 +63,7 @@ func GetWithRetry(client *http.Client, url string, settings RetrySetting) (resp
 		for i := 0; i < settings.MaxRetries; i++ {
 			log.Warn("Retrying failed GET request %s with error (HTTP %d)", url, resp.StatusCode)
 			time.Sleep(settings.WaitTime)
+			retryCount.Add(1)
 			resp, err = Get(client, url)
 			if err == nil && resp.IsSuccess() {
 				return resp, err
@@ -85,6 +86,7 @@ func SendWithRetry(client *http.Client, restCall SendingRequest, objectName stri
 	for i := 0; i < setting.MaxRetries; i++ {
 		log.Warn("Failed to upsert config %q. Waiting for %s before retrying...", objectName, setting.WaitTime)
 		time.Sleep(setting.WaitTime)
+		retryCount.Add(1)
 		resp, err = restCall(client, path, body)
 		if err == nil && resp.IsSuccess() {
 			return resp, err
@@ -0,0 +1,395 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// FormatVersion is the version of the snapshot archives written by this version of monaco
+const FormatVersion = 1
+
+const (
+	// MetadataFileName is the first entry of a snapshot archive, describing the captured environment and its files
+	MetadataFileName = "snapshot.json"
+	// ManifestFileName is the manifest of a snapshot, referencing its configs and entities projects
+	ManifestFileName = "manifest.yaml"
+	// ConfigsProject holds the configs and the schema definitions of the environment
+	ConfigsProject = "configs"
+	// EntitiesProject holds the entities of the environment
+	EntitiesProject = "entities"
+)
+
+// Metadata describes the capture of an environment in a snapshot
+type Metadata struct {
+	FormatVersion  int                 `json:"formatVersion"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	ToolVersion    string              `json:"toolVersion"`
+	EnvironmentUrl string              `json:"environmentUrl"`
+	ClusterVersion string              `json:"clusterVersion,omitempty"`
+	Environment    string              `json:"environment"`
+	Downloads      map[string]Download `json:"downloads"`
+	// Checksums holds the sha256 of every file of the snapshot, by slash separated path within the archive
+	Checksums map[string]string `json:"checksums"`
+	// ArchiveChecksum is the sha256 of the archive a folder was extracted from. It is only set in the metadata
+	// written into an extraction folder, where it marks the folder as extracted, and is empty while extracting
+	ArchiveChecksum string `json:"archiveChecksum,omitempty"`
+}
+
+// Download is the window in which a part of the snapshot, e.g. the configs or the entities, was downloaded
+type Download struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// TimeFromMinutes and TimeToMinutes are the timeframe of the entities that were downloaded
+	TimeFromMinutes int `json:"timeFromMinutes,omitempty"`
+	TimeToMinutes   int `json:"timeToMinutes,omitempty"`
+}
+
+// Create archives all files of the folder as a gzipped tar, preceded by the metadata and the checksums of the files
+func Create(fs afero.Fs, folder string, archivePath string, metadata Metadata) (Metadata, error) {
+	files, err := listFiles(fs, folder)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	metadata.FormatVersion = FormatVersion
+	metadata.Checksums = make(map[string]string, len(files))
+	for _, name := range files {
+		data, err := afero.ReadFile(fs, filepath.Join(folder, filepath.FromSlash(name)))
+		if err != nil {
+			return Metadata{}, err
+		}
+		metadata.Checksums[name] = checksum(data)
+	}
+
+	metadataBytes, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if dir := filepath.Dir(archivePath); dir != "." {
+		if err := fs.MkdirAll(dir, 0777); err != nil {
+			return Metadata{}, err
+		}
+	}
+
+	archive, err := fs.OpenFile(archivePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to create snapshot %s, see error: %w", archivePath, err)
+	}
+	defer archive.Close()
+
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+
+	err = writeEntry(tw, MetadataFileName, metadataBytes, metadata.CreatedAt)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	for _, name := range files {
+		data, err := afero.ReadFile(fs, filepath.Join(folder, filepath.FromSlash(name)))
+		if err != nil {
+			return Metadata{}, err
+		}
+		if err := writeEntry(tw, name, data, metadata.CreatedAt); err != nil {
+			return Metadata{}, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return Metadata{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return Metadata{}, err
+	}
+
+	return metadata, archive.Close()
+}
+
+// ReadMetadata reads the metadata of a snapshot, without extracting it
+func ReadMetadata(fs afero.Fs, archivePath string) (Metadata, error) {
+	archive, err := fs.Open(archivePath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer archive.Close()
+
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("snapshot %s is not a gzipped archive, see error: %w", archivePath, err)
+	}
+
+	return readMetadata(tar.NewReader(gz), archivePath)
+}
+
+// Extract extracts a snapshot into the target folder and writes its metadata, with the checksum of the archive, into it.
+// A target folder that was already extracted from the same archive is reused as is, one extracted from another archive
+// is replaced. Other existing folders are never deleted, the extraction fails instead.
+// The files are verified against the checksums of the metadata
+func Extract(fs afero.Fs, archivePath string, targetFolder string) (Metadata, error) {
+	archiveChecksum, err := fileChecksum(fs, archivePath)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	previous, err := readExtractionMarker(fs, targetFolder)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if previous != nil && previous.ArchiveChecksum == archiveChecksum {
+		return *previous, nil
+	}
+
+	archive, err := fs.Open(archivePath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer archive.Close()
+
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("snapshot %s is not a gzipped archive, see error: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+
+	metadata, err := readMetadata(tr, archivePath)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if previous != nil {
+		if err := fs.RemoveAll(targetFolder); err != nil {
+			return Metadata{}, err
+		}
+	}
+
+	// the marker is written first, so that the folder of an interrupted extraction is replaced by the next one
+	err = writeExtractionMarker(fs, targetFolder, metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	extracted := make(map[string]bool, len(metadata.Checksums))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Metadata{}, fmt.Errorf("failed to read snapshot %s, see error: %w", archivePath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := sanitizeEntryName(header.Name)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("invalid entry in snapshot %s: %w", archivePath, err)
+		}
+
+		expected, found := metadata.Checksums[name]
+		if !found {
+			return Metadata{}, fmt.Errorf("file %s of snapshot %s has no checksum", name, archivePath)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return Metadata{}, err
+		}
+		if actual := checksum(data); actual != expected {
+			return Metadata{}, fmt.Errorf("checksum of file %s of snapshot %s does not match, expected %s but was %s", name, archivePath, expected, actual)
+		}
+
+		file := filepath.Join(targetFolder, filepath.FromSlash(name))
+		if err := fs.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			return Metadata{}, err
+		}
+		if err := afero.WriteFile(fs, file, data, 0644); err != nil {
+			return Metadata{}, err
+		}
+		extracted[name] = true
+	}
+
+	for name := range metadata.Checksums {
+		if !extracted[name] {
+			return Metadata{}, fmt.Errorf("file %s is missing from snapshot %s", name, archivePath)
+		}
+	}
+
+	metadata.ArchiveChecksum = archiveChecksum
+	err = writeExtractionMarker(fs, targetFolder, metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// ExtractionFolder returns the folder next to the snapshot where it is extracted to, e.g. tenant.tar.gz.extracted
+func ExtractionFolder(archivePath string) string {
+	return archivePath + ".extracted"
+}
+
+// readExtractionMarker returns the metadata written into a folder by Extract, or nil if the folder does not exist.
+// An error is returned if the folder exists but was not extracted from a snapshot, as it must not be replaced
+func readExtractionMarker(fs afero.Fs, folder string) (*Metadata, error) {
+	exists, err := afero.DirExists(fs, folder)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if fileExists, _ := afero.Exists(fs, folder); fileExists {
+			return nil, fmt.Errorf("can not extract the snapshot into %s, a file of that name exists", folder)
+		}
+		return nil, nil
+	}
+
+	data, err := afero.ReadFile(fs, filepath.Join(folder, MetadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("refusing to replace folder %s, it has no %s and was not extracted from a snapshot", folder, MetadataFileName)
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("refusing to replace folder %s, its %s can not be parsed, see error: %w", folder, MetadataFileName, err)
+	}
+
+	return &metadata, nil
+}
+
+func writeExtractionMarker(fs afero.Fs, folder string, metadata Metadata) error {
+	if err := fs.MkdirAll(folder, 0777); err != nil {
+		return err
+	}
+
+	metadataBytes, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, filepath.Join(folder, MetadataFileName), metadataBytes, 0644)
+}
+
+func readMetadata(tr *tar.Reader, archivePath string) (Metadata, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to read snapshot %s, see error: %w", archivePath, err)
+	}
+	if header.Name != MetadataFileName {
+		return Metadata{}, fmt.Errorf("snapshot %s does not start with %s, found %s", archivePath, MetadataFileName, header.Name)
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return Metadata{}, fmt.Errorf("failed to parse the metadata of snapshot %s, see error: %w", archivePath, err)
+	}
+
+	if metadata.FormatVersion < 1 || metadata.FormatVersion > FormatVersion {
+		return Metadata{}, fmt.Errorf("snapshot %s has format version %d, but this version of monaco supports up to %d", archivePath, metadata.FormatVersion, FormatVersion)
+	}
+
+	return metadata, nil
+}
+
+// listFiles returns the slash separated paths of all files of the folder, relative to it and sorted
+func listFiles(fs afero.Fs, folder string) ([]string, error) {
+	var files []string
+	err := afero.Walk(fs, folder, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(folder, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of %s, see error: %w", folder, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
+
+// sanitizeEntryName rejects the entries that would be extracted outside the target folder
+func sanitizeEntryName(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") || cleaned == MetadataFileName {
+		return "", fmt.Errorf("entry %q is not allowed", name)
+	}
+	return cleaned, nil
+}
+
+func fileChecksum(fs afero.Fs, path string) (string, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read snapshot %s, see error: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,203 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeStaging(t *testing.T, fs afero.Fs) {
+	files := map[string]string{
+		"staging/manifest.yaml":                        "manifestVersion: 1.0",
+		"staging/configs/alerting-profile/config.yaml": "configs: []",
+		"staging/configs/schemas/builtin_tags.json":    `{"schemaId":"builtin:tags"}`,
+		"staging/entities/HOST/config.yaml":            "configs: []",
+	}
+	for file, content := range files {
+		require.NoError(t, afero.WriteFile(fs, file, []byte(content), 0644))
+	}
+}
+
+func TestCreateAndExtract(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeStaging(t, fs)
+
+	createdAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	created, err := Create(fs, "staging", "out/tenant.tar.gz", Metadata{
+		CreatedAt:      createdAt,
+		ToolVersion:    "test",
+		EnvironmentUrl: "https://tenant.live.dynatrace.com",
+		ClusterVersion: "1.270.0",
+		Environment:    "tenant",
+		Downloads: map[string]Download{
+			EntitiesProject: {Start: createdAt, End: createdAt, TimeFromMinutes: 60, TimeToMinutes: 0},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, created.FormatVersion)
+	assert.Len(t, created.Checksums, 4)
+
+	metadata, err := ReadMetadata(fs, "out/tenant.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, created, metadata)
+
+	extracted, err := Extract(fs, "out/tenant.tar.gz", ExtractionFolder("out/tenant.tar.gz"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, extracted.ArchiveChecksum)
+	extracted.ArchiveChecksum = ""
+	assert.Equal(t, created, extracted)
+
+	content, err := afero.ReadFile(fs, filepath.Join("out", "tenant.tar.gz.extracted", "configs", "schemas", "builtin_tags.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"schemaId":"builtin:tags"}`, string(content))
+
+	exists, err := afero.Exists(fs, filepath.Join("out", "tenant.tar.gz.extracted", MetadataFileName))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestExtractReusesTheFolderOfTheSameArchive(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeStaging(t, fs)
+
+	_, err := Create(fs, "staging", "tenant.tar.gz", Metadata{Environment: "tenant"})
+	require.NoError(t, err)
+
+	first, err := Extract(fs, "tenant.tar.gz", "extracted")
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, filepath.Join("extracted", "manifest.yaml"), []byte("kept"), 0644))
+
+	second, err := Extract(fs, "tenant.tar.gz", "extracted")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	content, err := afero.ReadFile(fs, filepath.Join("extracted", "manifest.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(content), "the folder is not extracted again")
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join("staging", "manifest.yaml"), []byte("manifestVersion: 2.0"), 0644))
+	_, err = Create(fs, "staging", "tenant.tar.gz", Metadata{Environment: "tenant"})
+	require.NoError(t, err)
+
+	third, err := Extract(fs, "tenant.tar.gz", "extracted")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ArchiveChecksum, third.ArchiveChecksum)
+	content, err = afero.ReadFile(fs, filepath.Join("extracted", "manifest.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "manifestVersion: 2.0", string(content), "the folder of another archive is replaced")
+}
+
+func TestExtractRefusesToReplaceOtherFolders(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeStaging(t, fs)
+
+	_, err := Create(fs, "staging", "download.tar.gz", Metadata{Environment: "tenant"})
+	require.NoError(t, err)
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join("download", "user-file.txt"), []byte("mine"), 0644))
+
+	_, err = Extract(fs, "download.tar.gz", "download")
+	assert.Error(t, err)
+
+	content, err := afero.ReadFile(fs, filepath.Join("download", "user-file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(content))
+}
+
+func writeArchive(t *testing.T, fs afero.Fs, archivePath string, entries [][2]string) {
+	file, err := fs.Create(archivePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		require.NoError(t, writeEntry(tw, entry[0], []byte(entry[1]), time.Now()))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestExtractRejectsInvalidSnapshots(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries [][2]string
+	}{
+		{
+			name: "tampered file",
+			entries: [][2]string{
+				{MetadataFileName, `{"formatVersion":1,"checksums":{"manifest.yaml":"` + checksum([]byte("original")) + `"}}`},
+				{"manifest.yaml", "tampered"},
+			},
+		},
+		{
+			name: "missing file",
+			entries: [][2]string{
+				{MetadataFileName, `{"formatVersion":1,"checksums":{"manifest.yaml":"` + checksum([]byte("original")) + `"}}`},
+			},
+		},
+		{
+			name: "file without checksum",
+			entries: [][2]string{
+				{MetadataFileName, `{"formatVersion":1,"checksums":{}}`},
+				{"manifest.yaml", "original"},
+			},
+		},
+		{
+			name: "file outside of the target folder",
+			entries: [][2]string{
+				{MetadataFileName, `{"formatVersion":1,"checksums":{"../manifest.yaml":"` + checksum([]byte("original")) + `"}}`},
+				{"../manifest.yaml", "original"},
+			},
+		},
+		{
+			name: "unsupported format version",
+			entries: [][2]string{
+				{MetadataFileName, `{"formatVersion":2,"checksums":{}}`},
+			},
+		},
+		{
+			name: "metadata is not the first entry",
+			entries: [][2]string{
+				{"manifest.yaml", "original"},
+				{MetadataFileName, `{"formatVersion":1,"checksums":{}}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			writeArchive(t, fs, "snapshot.tar.gz", tt.entries)
+
+			_, err := Extract(fs, "snapshot.tar.gz", "extracted")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExtractionFolder(t *testing.T) {
+	assert.Equal(t, filepath.Join("snapshots", "tenant.tar.gz.extracted"), ExtractionFolder(filepath.Join("snapshots", "tenant.tar.gz")))
+	assert.Equal(t, "tenant.extracted", ExtractionFolder("tenant"))
+}
@@ -0,0 +1,100 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/maps"
+)
+
+const metricPrefix = "monaco_"
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+type metric struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+// FormatPrometheus formats the summary in the Prometheus text exposition format, every metric is a gauge of the last run
+func FormatPrometheus(summary Summary) string {
+	command := [2]string{"command", summary.Command}
+
+	metrics := []metric{
+		{name: "run_duration_seconds", help: "Duration of the run", samples: []metricSample{{labels: [][2]string{command}, value: summary.DurationSeconds}}},
+		{name: "phase_duration_seconds", help: "Duration of each phase of the run"},
+		{name: "items", help: "Number of items per type and action or status"},
+		{name: "processed_items", help: "Number of items processed by the run"},
+		{name: "processed_items_per_second", help: "Number of items processed per second over the whole run"},
+		{name: "api_calls", help: "Number of HTTP requests sent, including retries", samples: []metricSample{{labels: [][2]string{command}, value: float64(summary.ApiCalls)}}},
+		{name: "api_retries", help: "Number of HTTP requests repeated after a failure or a rate limit", samples: []metricSample{{labels: [][2]string{command}, value: float64(summary.Retries)}}},
+		{name: "peak_memory_bytes", help: "Peak memory obtained from the system", samples: []metricSample{{labels: [][2]string{command}, value: float64(summary.PeakMemoryBytes)}}},
+	}
+
+	for _, phase := range summary.Phases {
+		metrics[1].samples = append(metrics[1].samples, metricSample{labels: [][2]string{command, {"phase", phase.Name}}, value: phase.DurationSeconds})
+	}
+
+	for _, typeName := range sortedKeys(summary.Counts) {
+		for _, action := range sortedKeys(summary.Counts[typeName]) {
+			metrics[2].samples = append(metrics[2].samples, metricSample{labels: [][2]string{command, {"type", typeName}, {"action", action}}, value: float64(summary.Counts[typeName][action])})
+		}
+	}
+
+	for _, unit := range sortedKeys(summary.Totals) {
+		metrics[3].samples = append(metrics[3].samples, metricSample{labels: [][2]string{command, {"unit", unit}}, value: float64(summary.Totals[unit])})
+	}
+
+	for _, unit := range sortedKeys(summary.PerSecond) {
+		metrics[4].samples = append(metrics[4].samples, metricSample{labels: [][2]string{command, {"unit", unit}}, value: summary.PerSecond[unit]})
+	}
+
+	var builder strings.Builder
+	for _, metric := range metrics {
+		if len(metric.samples) == 0 {
+			continue
+		}
+
+		name := metricPrefix + metric.name
+		fmt.Fprintf(&builder, "# HELP %s %s\n", name, metric.help)
+		fmt.Fprintf(&builder, "# TYPE %s gauge\n", name)
+
+		for _, sample := range metric.samples {
+			labels := make([]string, len(sample.labels))
+			for idx, label := range sample.labels {
+				labels[idx] = fmt.Sprintf(`%s="%s"`, label[0], labelValueEscaper.Replace(label[1]))
+			}
+			fmt.Fprintf(&builder, "%s{%s} %s\n", name, strings.Join(labels, ","), strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+
+	return builder.String()
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := maps.Keys(values)
+	sort.Strings(keys)
+	return keys
+}
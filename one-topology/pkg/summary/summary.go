@@ -0,0 +1,264 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package summary records the statistics of a download or match run, and writes them as a machine-readable summary.json.
+// The statistics of the current run are recorded through the package level functions, like the logs.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/rest"
+	"github.com/spf13/afero"
+)
+
+const FileName = "summary.json"
+
+const memorySampleInterval = 250 * time.Millisecond
+
+// Summary is the content of summary.json
+type Summary struct {
+	Command         string                    `json:"command"`
+	StartedAt       string                    `json:"startedAt"`
+	FinishedAt      string                    `json:"finishedAt"`
+	DurationSeconds float64                   `json:"durationSeconds"`
+	Phases          []Phase                   `json:"phases"`
+	Counts          map[string]map[string]int `json:"counts"`
+	Totals          map[string]int            `json:"totals"`
+	PerSecond       map[string]float64        `json:"perSecond"`
+	ApiCalls        int64                     `json:"apiCalls"`
+	Retries         int64                     `json:"retries"`
+	PeakMemoryBytes uint64                    `json:"peakMemoryBytes"`
+}
+
+type Phase struct {
+	Name            string  `json:"name"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// Recorder collects the statistics of a run, from Start to Finish
+type Recorder struct {
+	mu              sync.Mutex
+	summary         Summary
+	startTime       time.Time
+	phaseStartTime  time.Time
+	phaseName       string
+	apiCallsStart   int64
+	retriesStart    int64
+	peakMemoryBytes uint64
+	stopSampling    chan struct{}
+	samplingDone    chan struct{}
+}
+
+var current = &Recorder{}
+
+// NewRecorder starts recording the statistics of a run of the command, and samples the memory until Finish
+func NewRecorder(command string) *Recorder {
+	recorder := &Recorder{
+		summary: Summary{
+			Command: command,
+			Phases:  []Phase{},
+			Counts:  map[string]map[string]int{},
+			Totals:  map[string]int{},
+		},
+		startTime:     time.Now(),
+		apiCallsStart: rest.ApiCallCount(),
+		retriesStart:  rest.RetryCount(),
+		stopSampling:  make(chan struct{}),
+		samplingDone:  make(chan struct{}),
+	}
+
+	go recorder.sampleMemory()
+
+	return recorder
+}
+
+func (me *Recorder) sampleMemory() {
+	defer close(me.samplingDone)
+
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		me.recordMemory()
+
+		select {
+		case <-me.stopSampling:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (me *Recorder) recordMemory() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	memoryBytes := memStats.Sys - memStats.HeapReleased
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if memoryBytes > me.peakMemoryBytes {
+		me.peakMemoryBytes = memoryBytes
+	}
+}
+
+// StartPhase ends the current phase, if any, and starts timing the next one
+func (me *Recorder) StartPhase(name string) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	me.endPhase()
+	me.phaseName = name
+	me.phaseStartTime = time.Now()
+}
+
+func (me *Recorder) endPhase() {
+	if me.phaseName == "" {
+		return
+	}
+
+	me.summary.Phases = append(me.summary.Phases, Phase{Name: me.phaseName, DurationSeconds: time.Since(me.phaseStartTime).Seconds()})
+	me.phaseName = ""
+}
+
+// SetCount sets the count of an action or status of a type, later phases overwrite the counts of earlier ones
+func (me *Recorder) SetCount(typeName string, action string, count int) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.summary.Counts == nil {
+		return
+	}
+
+	counts, found := me.summary.Counts[typeName]
+	if !found {
+		counts = map[string]int{}
+		me.summary.Counts[typeName] = counts
+	}
+	counts[action] = count
+}
+
+// SetTotal sets the number of items processed by the run, the throughput is calculated for every total
+func (me *Recorder) SetTotal(unit string, count int) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.summary.Totals == nil {
+		return
+	}
+
+	me.summary.Totals[unit] = count
+}
+
+// Finish ends the current phase and the memory sampling, and returns the summary of the run
+func (me *Recorder) Finish() Summary {
+	if me.stopSampling != nil {
+		close(me.stopSampling)
+		<-me.samplingDone
+		me.stopSampling = nil
+	}
+	me.recordMemory()
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	me.endPhase()
+
+	finishTime := time.Now()
+	duration := finishTime.Sub(me.startTime).Seconds()
+
+	me.summary.StartedAt = me.startTime.UTC().Format(time.RFC3339)
+	me.summary.FinishedAt = finishTime.UTC().Format(time.RFC3339)
+	me.summary.DurationSeconds = duration
+	me.summary.ApiCalls = rest.ApiCallCount() - me.apiCallsStart
+	me.summary.Retries = rest.RetryCount() - me.retriesStart
+	me.summary.PeakMemoryBytes = me.peakMemoryBytes
+
+	me.summary.PerSecond = make(map[string]float64, len(me.summary.Totals))
+	for unit, count := range me.summary.Totals {
+		if duration > 0 {
+			me.summary.PerSecond[unit] = float64(count) / duration
+		}
+	}
+
+	return me.summary
+}
+
+// Start starts recording the current run of the command
+func Start(command string) {
+	current = NewRecorder(command)
+}
+
+func StartPhase(name string) {
+	current.StartPhase(name)
+}
+
+func SetCount(typeName string, action string, count int) {
+	current.SetCount(typeName, action, count)
+}
+
+func SetTotal(unit string, count int) {
+	current.SetTotal(unit, count)
+}
+
+// Finish returns the summary of the current run
+func Finish() Summary {
+	return current.Finish()
+}
+
+// Write writes summary.json into the folder, and the Prometheus text format file if metricsFile is set
+func Write(fs afero.Fs, folder string, metricsFile string, summary Summary) error {
+
+	err := fs.MkdirAll(folder, 0777)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	summaryFile := filepath.Join(folder, FileName)
+	err = afero.WriteFile(fs, summaryFile, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the run summary %s, see error: %w", summaryFile, err)
+	}
+	log.Info("Wrote run summary to '%s'", summaryFile)
+
+	if metricsFile == "" {
+		return nil
+	}
+
+	err = fs.MkdirAll(filepath.Dir(metricsFile), 0777)
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, metricsFile, []byte(FormatPrometheus(summary)), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the metrics file %s, see error: %w", metricsFile, err)
+	}
+	log.Info("Wrote run metrics to '%s'", metricsFile)
+
+	return nil
+}
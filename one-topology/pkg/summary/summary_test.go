@@ -0,0 +1,137 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package summary
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder("match")
+
+	recorder.StartPhase("load projects")
+	recorder.StartPhase("match")
+	recorder.SetCount("HOST", "Matched", 1)
+	recorder.SetCount("HOST", "Matched", 2)
+	recorder.SetCount("HOST", "UnMatched", 3)
+	recorder.SetTotal("sourceEntities", 5)
+
+	summary := recorder.Finish()
+
+	assert.Equal(t, summary.Command, "match")
+	assert.Equal(t, len(summary.Phases), 2)
+	assert.Equal(t, summary.Phases[0].Name, "load projects")
+	assert.Equal(t, summary.Phases[1].Name, "match")
+	assert.DeepEqual(t, summary.Counts, map[string]map[string]int{"HOST": {"Matched": 2, "UnMatched": 3}})
+	assert.DeepEqual(t, summary.Totals, map[string]int{"sourceEntities": 5})
+	assert.Assert(t, summary.PerSecond["sourceEntities"] > 0)
+	assert.Assert(t, summary.PeakMemoryBytes > 0)
+	assert.Assert(t, summary.StartedAt != "")
+	assert.Assert(t, summary.FinishedAt != "")
+}
+
+func TestRecordingBeforeStartIsIgnored(t *testing.T) {
+	recorder := &Recorder{}
+
+	recorder.SetCount("HOST", "Matched", 1)
+	recorder.SetTotal("sourceEntities", 1)
+
+	summary := recorder.Finish()
+	assert.Equal(t, len(summary.Counts), 0)
+	assert.Equal(t, len(summary.Totals), 0)
+}
+
+func TestWrite(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	summary := Summary{Command: "download configs", Counts: map[string]map[string]int{"dashboard": {"Downloaded": 2}}, Totals: map[string]int{"configs": 2}}
+
+	err := Write(fs, "output/project", "metrics/run.prom", summary)
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, "output/project/summary.json")
+	assert.NilError(t, err)
+
+	var written Summary
+	err = json.Unmarshal(content, &written)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, written, summary)
+
+	exists, err := afero.Exists(fs, "metrics/run.prom")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+}
+
+func TestWriteWithoutMetricsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := Write(fs, "output", "", Summary{Command: "match"})
+	assert.NilError(t, err)
+
+	files, err := afero.ReadDir(fs, "output")
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+	assert.Equal(t, files[0].Name(), FileName)
+}
+
+func TestFormatPrometheus(t *testing.T) {
+	summary := Summary{
+		Command:         "match",
+		DurationSeconds: 2.5,
+		Phases:          []Phase{{Name: "match", DurationSeconds: 1.5}},
+		Counts:          map[string]map[string]int{"HOST": {"UnMatched": 1, "Matched": 2}, `a"b`: {"Matched": 3}},
+		Totals:          map[string]int{"sourceEntities": 5},
+		PerSecond:       map[string]float64{"sourceEntities": 2},
+		ApiCalls:        7,
+		Retries:         1,
+		PeakMemoryBytes: 1024,
+	}
+
+	expected := `# HELP monaco_run_duration_seconds Duration of the run
+# TYPE monaco_run_duration_seconds gauge
+monaco_run_duration_seconds{command="match"} 2.5
+# HELP monaco_phase_duration_seconds Duration of each phase of the run
+# TYPE monaco_phase_duration_seconds gauge
+monaco_phase_duration_seconds{command="match",phase="match"} 1.5
+# HELP monaco_items Number of items per type and action or status
+# TYPE monaco_items gauge
+monaco_items{command="match",type="HOST",action="Matched"} 2
+monaco_items{command="match",type="HOST",action="UnMatched"} 1
+monaco_items{command="match",type="a\"b",action="Matched"} 3
+# HELP monaco_processed_items Number of items processed by the run
+# TYPE monaco_processed_items gauge
+monaco_processed_items{command="match",unit="sourceEntities"} 5
+# HELP monaco_processed_items_per_second Number of items processed per second over the whole run
+# TYPE monaco_processed_items_per_second gauge
+monaco_processed_items_per_second{command="match",unit="sourceEntities"} 2
+# HELP monaco_api_calls Number of HTTP requests sent, including retries
+# TYPE monaco_api_calls gauge
+monaco_api_calls{command="match"} 7
+# HELP monaco_api_retries Number of HTTP requests repeated after a failure or a rate limit
+# TYPE monaco_api_retries gauge
+monaco_api_retries{command="match"} 1
+# HELP monaco_peak_memory_bytes Peak memory obtained from the system
+# TYPE monaco_peak_memory_bytes gauge
+monaco_peak_memory_bytes{command="match"} 1024
+`
+
+	assert.Equal(t, FormatPrometheus(summary), expected)
+}
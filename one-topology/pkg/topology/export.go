@@ -0,0 +1,211 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format is the file format the topology is exported as
+type Format string
+
+const (
+	GraphML   Format = "graphml"
+	Dot       Format = "dot"
+	JSONLines Format = "jsonl"
+)
+
+// Formats are the supported export formats
+var Formats = []Format{GraphML, Dot, JSONLines}
+
+// ParseFormat returns the export format of the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown topology format %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// Write exports the graph in the given format
+func Write(w io.Writer, g Graph, format Format) error {
+	bw := bufio.NewWriter(w)
+
+	var err error
+	switch format {
+	case GraphML:
+		err = writeGraphML(bw, g)
+	case Dot:
+		err = writeDot(bw, g)
+	case JSONLines:
+		err = writeJSONLines(bw, g)
+	default:
+		err = fmt.Errorf("unknown topology format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// propertyNames returns the sorted names of the properties of all nodes
+func propertyNames(g Graph) []string {
+	unique := map[string]bool{}
+	for _, n := range g.Nodes {
+		for name := range n.Properties {
+			unique[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeGraphML(w *bufio.Writer, g Graph) error {
+	w.WriteString(xml.Header)
+	w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	w.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	w.WriteString(`  <key id="name" for="node" attr.name="name" attr.type="string"/>` + "\n")
+	w.WriteString(`  <key id="stub" for="node" attr.name="stub" attr.type="boolean"/>` + "\n")
+
+	properties := propertyNames(g)
+	for i, name := range properties {
+		fmt.Fprintf(w, `  <key id="p%d" for="node" attr.name="%s" attr.type="string"/>`+"\n", i, escapeXML(name))
+	}
+	w.WriteString(`  <key id="relationship" for="edge" attr.name="relationship" attr.type="string"/>` + "\n")
+	w.WriteString(`  <graph id="topology" edgedefault="directed">` + "\n")
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, `    <node id="%s">`+"\n", escapeXML(n.Id))
+		fmt.Fprintf(w, `      <data key="type">%s</data>`+"\n", escapeXML(n.Type))
+		if n.Name != "" {
+			fmt.Fprintf(w, `      <data key="name">%s</data>`+"\n", escapeXML(n.Name))
+		}
+		if n.Stub {
+			w.WriteString(`      <data key="stub">true</data>` + "\n")
+		}
+		for i, name := range properties {
+			if value, found := n.Properties[name]; found {
+				fmt.Fprintf(w, `      <data key="p%d">%s</data>`+"\n", i, escapeXML(fmt.Sprint(value)))
+			}
+		}
+		w.WriteString("    </node>\n")
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, `    <edge source="%s" target="%s">`+"\n", escapeXML(e.From), escapeXML(e.To))
+		fmt.Fprintf(w, `      <data key="relationship">%s</data>`+"\n", escapeXML(e.Type))
+		w.WriteString("    </edge>\n")
+	}
+
+	w.WriteString("  </graph>\n")
+	_, err := w.WriteString("</graphml>\n")
+	return err
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeDot(w *bufio.Writer, g Graph) error {
+	w.WriteString("digraph topology {\n")
+
+	for _, n := range g.Nodes {
+		label := n.Name
+		if label == "" {
+			label = n.Id
+		}
+
+		attributes := []string{
+			fmt.Sprintf("label=%s", quoteDot(label)),
+			fmt.Sprintf("type=%s", quoteDot(n.Type)),
+		}
+		if n.Stub {
+			attributes = append(attributes, "stub=true", "style=dashed")
+		}
+		for _, name := range propertyNames(Graph{Nodes: []Node{n}}) {
+			attributes = append(attributes, fmt.Sprintf("%s=%s", quoteDot(name), quoteDot(fmt.Sprint(n.Properties[name]))))
+		}
+
+		fmt.Fprintf(w, "  %s [%s];\n", quoteDot(n.Id), strings.Join(attributes, ", "))
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "  %s -> %s [label=%s];\n", quoteDot(e.From), quoteDot(e.To), quoteDot(e.Type))
+	}
+
+	_, err := w.WriteString("}\n")
+	return err
+}
+
+func quoteDot(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+type jsonNode struct {
+	Kind       string                 `json:"kind"`
+	Id         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name,omitempty"`
+	Stub       bool                   `json:"stub,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type jsonEdge struct {
+	Kind string `json:"kind"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+func writeJSONLines(w *bufio.Writer, g Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	for _, n := range g.Nodes {
+		err := encoder.Encode(jsonNode{Kind: "node", Id: n.Id, Type: n.Type, Name: n.Name, Stub: n.Stub, Properties: n.Properties})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range g.Edges {
+		err := encoder.Encode(jsonEdge{Kind: "edge", From: e.From, To: e.To, Type: e.Type})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
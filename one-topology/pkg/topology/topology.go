@@ -0,0 +1,302 @@
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download"
+	"github.com/spf13/afero"
+)
+
+// Node is an entity of the topology
+type Node struct {
+	Id   string
+	Type string
+	Name string
+	// Properties holds the scalar properties of the entity, e.g. detectedName
+	Properties map[string]interface{}
+	// Stub is true for the entities referenced by relationships, but not part of the downloaded project
+	Stub bool
+}
+
+// Edge is a relationship between two entities, e.g. a PROCESS_GROUP_INSTANCE that runsOn a HOST
+type Edge struct {
+	From string
+	To   string
+	Type string
+}
+
+// Graph holds the nodes sorted by ID, and the edges sorted by source, target and type
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Options configures which relationships become edges of the graph
+type Options struct {
+	// ToRelationships adds the toRelationships of the entities, in addition to their fromRelationships
+	ToRelationships bool
+}
+
+type entity struct {
+	EntityId          string                 `json:"entityId"`
+	Type              string                 `json:"type"`
+	DisplayName       string                 `json:"displayName"`
+	Properties        map[string]interface{} `json:"properties"`
+	FromRelationships map[string][]relation  `json:"fromRelationships"`
+	ToRelationships   map[string][]relation  `json:"toRelationships"`
+}
+
+type relation struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Builder collects the entities of the downloaded entities types into a graph
+type Builder struct {
+	options  Options
+	nodes    map[string]Node
+	edges    map[Edge]bool
+	entities int
+}
+
+func NewBuilder(options Options) *Builder {
+	return &Builder{
+		options: options,
+		nodes:   map[string]Node{},
+		edges:   map[Edge]bool{},
+	}
+}
+
+// Add parses the downloaded JSON array of entities of an entities type.
+// The entities type is used for the entities that do not hold their own type
+func (b *Builder) Add(entitiesType string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var entities []entity
+	err := json.Unmarshal(data, &entities)
+	if err != nil {
+		return fmt.Errorf("failed to parse the entities of type %s, see error: %w", entitiesType, err)
+	}
+
+	for _, e := range entities {
+		if e.EntityId == "" {
+			continue
+		}
+		b.entities++
+
+		entityType := e.Type
+		if entityType == "" {
+			entityType = entitiesType
+		}
+		b.nodes[e.EntityId] = Node{
+			Id:         e.EntityId,
+			Type:       entityType,
+			Name:       e.DisplayName,
+			Properties: scalarProperties(e.Properties),
+		}
+
+		for relationshipType, relations := range e.FromRelationships {
+			for _, r := range relations {
+				b.addEdge(Edge{From: e.EntityId, To: r.Id, Type: relationshipType}, r)
+			}
+		}
+
+		if !b.options.ToRelationships {
+			continue
+		}
+		for relationshipType, relations := range e.ToRelationships {
+			for _, r := range relations {
+				b.addEdge(Edge{From: r.Id, To: e.EntityId, Type: relationshipType}, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Builder) addEdge(edge Edge, r relation) {
+	if r.Id == "" {
+		return
+	}
+	b.edges[edge] = true
+
+	if _, found := b.nodes[r.Id]; !found {
+		b.nodes[r.Id] = Node{Id: r.Id, Type: r.Type, Stub: true}
+	}
+}
+
+// Graph returns the graph of the added entities, the relationships to entities that were not added become stub nodes
+func (b *Builder) Graph() Graph {
+	nodes := make([]Node, 0, len(b.nodes))
+	for _, n := range b.nodes {
+		nodes = append(nodes, n)
+	}
+
+	edges := make([]Edge, 0, len(b.edges))
+	for e := range b.edges {
+		edges = append(edges, e)
+	}
+
+	return newGraph(nodes, edges)
+}
+
+// Load reads the entities project of a download, e.g. download_2023-06-01/project, into a graph
+func Load(fs afero.Fs, projectFolder string, options Options) (Graph, error) {
+	configsPerType, err := download.ReadFromDisk(fs, filepath.Dir(projectFolder), filepath.Base(projectFolder))
+	if err != nil {
+		return Graph{}, err
+	}
+
+	builder := NewBuilder(options)
+	for entitiesType, configs := range configsPerType {
+		if entitiesType == client.TypesAsEntitiesType {
+			continue
+		}
+
+		for _, c := range configs {
+			data, err := c.LoadTemplateBytes()
+			if err != nil {
+				return Graph{}, fmt.Errorf("failed to load the entities of type %s, see error: %w", entitiesType, err)
+			}
+
+			err = builder.Add(entitiesType, data)
+			if err != nil {
+				return Graph{}, err
+			}
+		}
+	}
+
+	if builder.entities == 0 {
+		return Graph{}, fmt.Errorf("no entities found in project %s", projectFolder)
+	}
+
+	graph := builder.Graph()
+	log.Info("Loaded %d entities of %d entities types from %s, with %d relationships", builder.entities, len(configsPerType), projectFolder, len(graph.Edges))
+
+	return graph, nil
+}
+
+// Component returns the connected component of the entity, following the relationships in both directions.
+// A depth greater than 0 limits the number of relationships between the entity and the returned entities
+func (g Graph) Component(entityId string, depth int) (Graph, error) {
+	if _, found := g.node(entityId); !found {
+		return Graph{}, fmt.Errorf("entity %s is not part of the topology", entityId)
+	}
+
+	neighbours := map[string][]string{}
+	for _, e := range g.Edges {
+		neighbours[e.From] = append(neighbours[e.From], e.To)
+		neighbours[e.To] = append(neighbours[e.To], e.From)
+	}
+
+	reached := map[string]bool{entityId: true}
+	current := []string{entityId}
+	for distance := 0; len(current) > 0 && (depth <= 0 || distance < depth); distance++ {
+		var next []string
+		for _, id := range current {
+			for _, neighbour := range neighbours[id] {
+				if !reached[neighbour] {
+					reached[neighbour] = true
+					next = append(next, neighbour)
+				}
+			}
+		}
+		current = next
+	}
+
+	return g.filter(func(n Node) bool { return reached[n.Id] }), nil
+}
+
+// FilterTypes keeps the entities of the given types, and the relationships between them
+func (g Graph) FilterTypes(types []string) Graph {
+	if len(types) == 0 {
+		return g
+	}
+
+	keep := make(map[string]bool, len(types))
+	for _, t := range types {
+		keep[t] = true
+	}
+
+	return g.filter(func(n Node) bool { return keep[n.Type] })
+}
+
+func (g Graph) filter(keep func(Node) bool) Graph {
+	ids := map[string]bool{}
+	var nodes []Node
+	for _, n := range g.Nodes {
+		if keep(n) {
+			ids[n.Id] = true
+			nodes = append(nodes, n)
+		}
+	}
+
+	var edges []Edge
+	for _, e := range g.Edges {
+		if ids[e.From] && ids[e.To] {
+			edges = append(edges, e)
+		}
+	}
+
+	return newGraph(nodes, edges)
+}
+
+func (g Graph) node(id string) (Node, bool) {
+	i := sort.Search(len(g.Nodes), func(i int) bool { return g.Nodes[i].Id >= id })
+	if i < len(g.Nodes) && g.Nodes[i].Id == id {
+		return g.Nodes[i], true
+	}
+	return Node{}, false
+}
+
+func newGraph(nodes []Node, edges []Edge) Graph {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Type < edges[j].Type
+	})
+
+	return Graph{Nodes: nodes, Edges: edges}
+}
+
+// scalarProperties keeps the string, number and boolean properties of an entity
+func scalarProperties(properties map[string]interface{}) map[string]interface{} {
+	scalars := map[string]interface{}{}
+	for name, value := range properties {
+		switch value.(type) {
+		case string, float64, bool:
+			scalars[name] = value
+		}
+	}
+
+	if len(scalars) == 0 {
+		return nil
+	}
+	return scalars
+}
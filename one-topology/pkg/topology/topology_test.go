@@ -0,0 +1,222 @@
+//go:build unit
+
+// @license
+// Copyright 2023 Dynatrace LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/client"
+	config "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/coordinate"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/parameter/value"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/config/v2/template"
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/download"
+	project "github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/pkg/project/v2"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hosts = `[
+  {"entityId": "HOST-1", "type": "HOST", "displayName": "host one", "properties": {"detectedName": "host-1", "ipAddress": ["10.0.0.1"]}},
+  {"entityId": "HOST-2", "type": "HOST", "displayName": "host two", "toRelationships": {"isSiteOf": [{"id": "GEOLOC_SITE-1", "type": "GEOLOC_SITE"}]}}
+]`
+
+const processes = `[
+  {"entityId": "PROCESS_GROUP_INSTANCE-1", "displayName": "java <1>", "fromRelationships": {"isProcessOf": [{"id": "HOST-1", "type": "HOST"}], "isInstanceOf": [{"id": "PROCESS_GROUP-1", "type": "PROCESS_GROUP"}]}},
+  {"entityId": "PROCESS_GROUP_INSTANCE-2", "displayName": "nginx", "fromRelationships": {"isProcessOf": [{"id": "HOST-2", "type": "HOST"}]}}
+]`
+
+func buildGraph(t *testing.T, options Options) Graph {
+	builder := NewBuilder(options)
+	require.NoError(t, builder.Add("HOST", []byte(hosts)))
+	require.NoError(t, builder.Add("PROCESS_GROUP_INSTANCE", []byte(processes)))
+	return builder.Graph()
+}
+
+func TestBuilder(t *testing.T) {
+	graph := buildGraph(t, Options{})
+
+	assert.Equal(t, []Node{
+		{Id: "HOST-1", Type: "HOST", Name: "host one", Properties: map[string]interface{}{"detectedName": "host-1"}},
+		{Id: "HOST-2", Type: "HOST", Name: "host two"},
+		{Id: "PROCESS_GROUP-1", Type: "PROCESS_GROUP", Stub: true},
+		{Id: "PROCESS_GROUP_INSTANCE-1", Type: "PROCESS_GROUP_INSTANCE", Name: "java <1>"},
+		{Id: "PROCESS_GROUP_INSTANCE-2", Type: "PROCESS_GROUP_INSTANCE", Name: "nginx"},
+	}, graph.Nodes)
+	assert.Equal(t, []Edge{
+		{From: "PROCESS_GROUP_INSTANCE-1", To: "HOST-1", Type: "isProcessOf"},
+		{From: "PROCESS_GROUP_INSTANCE-1", To: "PROCESS_GROUP-1", Type: "isInstanceOf"},
+		{From: "PROCESS_GROUP_INSTANCE-2", To: "HOST-2", Type: "isProcessOf"},
+	}, graph.Edges)
+}
+
+func TestBuilderWithToRelationships(t *testing.T) {
+	graph := buildGraph(t, Options{ToRelationships: true})
+
+	assert.Len(t, graph.Nodes, 6)
+	assert.Contains(t, graph.Edges, Edge{From: "GEOLOC_SITE-1", To: "HOST-2", Type: "isSiteOf"})
+}
+
+func TestBuilderFailsOnInvalidJSON(t *testing.T) {
+	err := NewBuilder(Options{}).Add("HOST", []byte(`{"entityId": "HOST-1"}`))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	fs := afero.NewOsFs()
+	outputFolder := t.TempDir()
+
+	configs := project.ConfigsPerType{}
+	for entitiesType, content := range map[string]string{"HOST": hosts, "PROCESS_GROUP_INSTANCE": processes, client.TypesAsEntitiesType: "[]"} {
+		configs[entitiesType] = entityConfigs(entitiesType, content)
+	}
+
+	_, err := download.WriteToDisk(fs, download.WriterContext{
+		ProjectToWrite: download.CreateProjectData(configs, "project"),
+		OutputFolder:   outputFolder,
+	})
+	require.NoError(t, err)
+
+	graph, err := Load(fs, filepath.Join(outputFolder, "project"), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, buildGraph(t, Options{}), graph)
+}
+
+// entityConfigs creates the config of an entities type, as the entities download does
+func entityConfigs(entitiesType string, content string) []config.Config {
+	return []config.Config{{
+		Template: template.NewDownloadTemplate(entitiesType, entitiesType, content),
+		Coordinate: coordinate.Coordinate{
+			Project:  "project",
+			Type:     entitiesType,
+			ConfigId: entitiesType,
+		},
+		Type: config.EntityType{
+			EntitiesType: entitiesType,
+		},
+		Parameters: map[string]parameter.Parameter{
+			config.NameParameter: &value.ValueParameter{Value: entitiesType},
+		},
+	}}
+}
+
+func TestComponent(t *testing.T) {
+	graph := buildGraph(t, Options{})
+
+	component, err := graph.Component("HOST-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOST-1", "PROCESS_GROUP-1", "PROCESS_GROUP_INSTANCE-1"}, nodeIds(component))
+	assert.Len(t, component.Edges, 2)
+
+	component, err = graph.Component("HOST-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOST-1", "PROCESS_GROUP_INSTANCE-1"}, nodeIds(component))
+
+	_, err = graph.Component("HOST-3", 0)
+	assert.Error(t, err)
+}
+
+func TestFilterTypes(t *testing.T) {
+	graph := buildGraph(t, Options{}).FilterTypes([]string{"HOST", "PROCESS_GROUP_INSTANCE"})
+
+	assert.Equal(t, []string{"HOST-1", "HOST-2", "PROCESS_GROUP_INSTANCE-1", "PROCESS_GROUP_INSTANCE-2"}, nodeIds(graph))
+	assert.Len(t, graph.Edges, 2)
+}
+
+func nodeIds(g Graph) []string {
+	ids := make([]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[i] = n.Id
+	}
+	return ids
+}
+
+func TestWrite(t *testing.T) {
+	graph, err := buildGraph(t, Options{}).Component("HOST-1", 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format: JSONLines,
+			expected: `{"kind":"node","id":"HOST-1","type":"HOST","name":"host one","properties":{"detectedName":"host-1"}}
+{"kind":"node","id":"PROCESS_GROUP_INSTANCE-1","type":"PROCESS_GROUP_INSTANCE","name":"java <1>"}
+{"kind":"edge","from":"PROCESS_GROUP_INSTANCE-1","to":"HOST-1","type":"isProcessOf"}
+`,
+		},
+		{
+			format: Dot,
+			expected: `digraph topology {
+  "HOST-1" [label="host one", type="HOST", "detectedName"="host-1"];
+  "PROCESS_GROUP_INSTANCE-1" [label="java <1>", type="PROCESS_GROUP_INSTANCE"];
+  "PROCESS_GROUP_INSTANCE-1" -> "HOST-1" [label="isProcessOf"];
+}
+`,
+		},
+		{
+			format: GraphML,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="type" for="node" attr.name="type" attr.type="string"/>
+  <key id="name" for="node" attr.name="name" attr.type="string"/>
+  <key id="stub" for="node" attr.name="stub" attr.type="boolean"/>
+  <key id="p0" for="node" attr.name="detectedName" attr.type="string"/>
+  <key id="relationship" for="edge" attr.name="relationship" attr.type="string"/>
+  <graph id="topology" edgedefault="directed">
+    <node id="HOST-1">
+      <data key="type">HOST</data>
+      <data key="name">host one</data>
+      <data key="p0">host-1</data>
+    </node>
+    <node id="PROCESS_GROUP_INSTANCE-1">
+      <data key="type">PROCESS_GROUP_INSTANCE</data>
+      <data key="name">java &lt;1&gt;</data>
+    </node>
+    <edge source="PROCESS_GROUP_INSTANCE-1" target="HOST-1">
+      <data key="relationship">isProcessOf</data>
+    </edge>
+  </graph>
+</graphml>
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, Write(&out, graph, tt.format))
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("dot")
+	require.NoError(t, err)
+	assert.Equal(t, Dot, format)
+
+	_, err = ParseFormat("csv")
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "graphml, dot, jsonl"))
+}
@@ -15,6 +15,7 @@
 package writer
 
 import (
+	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/compression"
 	"github.com/Dynatrace/Dynatrace-Config-Manager/one-topology/internal/log"
 	"path/filepath"
 
@@ -31,6 +32,8 @@ type WriterContext struct {
 	OutputDir          string
 	ManifestName       string
 	ParametersSerde    map[string]parameter.ParameterSerDe
+	// Compression of the written templates
+	Compression compression.Format
 }
 
 func WriteToDisk(context *WriterContext, manifestToWrite manifest.Manifest, projects []project.Project) []error {
@@ -79,6 +82,7 @@ func writeProjects(context *WriterContext, projectDefinitions manifest.ProjectDe
 			OutputFolder:    context.OutputDir,
 			ProjectFolder:   definition.Path,
 			ParametersSerde: context.ParametersSerde,
+			Compression:     context.Compression,
 		}, configs)
 
 		errors = append(errors, errs...)